@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"go-rest-api-boilerplate/config"
+	"go-rest-api-boilerplate/internal/db"
+	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/model/reqres"
+	"go-rest-api-boilerplate/internal/usecase/repository"
+	"go-rest-api-boilerplate/internal/usecase/service"
+	"go-rest-api-boilerplate/pkg/util"
+	"go-rest-api-boilerplate/pkg/validation"
+)
+
+func newApiKeyService() domain.ApiKeyService {
+	pg := db.NewPostgreeDb(config.App.DbHost, config.App.DbPort, config.App.DbName, config.App.DbUser, config.App.DbPass)
+	return service.NewApiKeyService(repository.NewApiKeyRepository(pg.Connect().GetConnection()))
+}
+
+func newApiKeyCreateCmd() *cobra.Command {
+	var req reqres.CreateApiKeyReq
+	var createCmd = &cobra.Command{
+		Use:          "create",
+		Short:        "Issue an API key",
+		Long:         "Issue an API key granted the given scopes, the key is printed once and cannot be read again",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			config.Init()
+			if err := validation.New().Struct(&req, ""); err != nil {
+				return fmt.Errorf("invalid api key: %w", err)
+			}
+
+			key, plaintext, err := newApiKeyService().Create(context.Background(), &req)
+			if err != nil {
+				return fmt.Errorf("failed to create the api key: %w", err)
+			}
+
+			log.Infof("api key %d (%s) created, store it now, it will not be shown again", key.ID, key.Prefix)
+			fmt.Println(plaintext)
+			return nil
+		},
+	}
+
+	createCmd.Flags().StringVar(&req.Name, "name", "", "name of the caller the key is issued to")
+	createCmd.Flags().StringSliceVar(&req.Scopes, "scope", nil, "permission granted to the key, repeat or comma separate for several (e.g. user:list,user:read)")
+	createCmd.Flags().DurationVar(&req.TTL, "ttl", 0, "lifetime of the key, the key never expires when unset")
+	return createCmd
+}
+
+func newApiKeyListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "list",
+		Short:        "List the API keys",
+		Long:         "List the API keys, the keys themselves are not stored and only their prefix is shown",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			config.Init()
+			keys, err := newApiKeyService().FindAll(context.Background())
+			if err != nil {
+				return fmt.Errorf("failed to list the api keys: %w", err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tEXPIRES\tLAST USED\tREVOKED")
+			for _, key := range *keys {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Prefix, strings.Join(key.Scopes, ","),
+					formatTime(key.ExpiresAt), formatTime(key.LastUsedAt), formatTime(key.RevokedAt))
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newApiKeyRevokeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "revoke <id>",
+		Short:        "Revoke an API key",
+		Long:         "Revoke an API key, the requests sending it are rejected from now on",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			config.Init()
+			id, err := util.StringToInt64(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid api key id %q: %w", args[0], err)
+			}
+
+			if err = newApiKeyService().Revoke(context.Background(), id); err != nil {
+				return fmt.Errorf("failed to revoke api key %d: %w", id, err)
+			}
+
+			log.Infof("api key %d revoked", id)
+			return nil
+		},
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+func NewApiKeyCmd() *cobra.Command {
+	var apiKeyCmd = &cobra.Command{
+		Use:   "apikey",
+		Short: "Manage the API keys of service-to-service callers",
+		Long:  "Manage the API keys of service-to-service callers",
+		Run: func(c *cobra.Command, args []string) {
+			c.HelpFunc()(c, args)
+		},
+	}
+
+	apiKeyCmd.AddCommand(newApiKeyCreateCmd(), newApiKeyListCmd(), newApiKeyRevokeCmd())
+	return apiKeyCmd
+}
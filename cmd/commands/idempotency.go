@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"go-rest-api-boilerplate/config"
+	"go-rest-api-boilerplate/internal/db"
+	"go-rest-api-boilerplate/internal/usecase/repository"
+	"go-rest-api-boilerplate/internal/usecase/service"
+)
+
+func newIdempotencyPurgeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "purge",
+		Short:        "Remove the expired idempotency keys",
+		Long:         "Remove the idempotency keys remembered longer than IDEMPOTENCY_TTL",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			config.Init()
+			pg := db.NewPostgreeDb(config.App.DbHost, config.App.DbPort, config.App.DbName, config.App.DbUser, config.App.DbPass)
+			svc := service.NewIdempotencyService(repository.NewIdempotencyRepository(pg.Connect().GetConnection()), service.NewIdempotencyConfig())
+
+			n, err := svc.PurgeExpired(context.Background())
+			if err != nil {
+				return fmt.Errorf("failed to purge the expired idempotency keys: %w", err)
+			}
+
+			log.Infof("%d expired idempotency keys have been purged", n)
+			return nil
+		},
+	}
+}
+
+func NewIdempotencyCmd() *cobra.Command {
+	var idempotencyCmd = &cobra.Command{
+		Use:   "idempotency",
+		Short: "Manage the idempotency keys",
+		Long:  "Manage the idempotency keys of the POST requests",
+		Run: func(c *cobra.Command, args []string) {
+			c.HelpFunc()(c, args)
+		},
+	}
+
+	idempotencyCmd.AddCommand(newIdempotencyPurgeCmd())
+	return idempotencyCmd
+}
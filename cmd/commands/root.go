@@ -12,6 +12,6 @@ func NewRootCommand() *cobra.Command {
 			c.HelpFunc()(c, args)
 		},
 	}
-	command.AddCommand(serverCmd, NewMigrateCmd())
+	command.AddCommand(serverCmd, NewMigrateCmd(), NewApiKeyCmd(), NewUsersCmd(), NewIdempotencyCmd())
 	return command
 }
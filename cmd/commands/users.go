@@ -0,0 +1,286 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"go-rest-api-boilerplate/config"
+	"go-rest-api-boilerplate/internal/db"
+	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/model/auth"
+	"go-rest-api-boilerplate/internal/model/reqres"
+	"go-rest-api-boilerplate/internal/model/userfile"
+	"go-rest-api-boilerplate/internal/usecase/repository"
+	"go-rest-api-boilerplate/internal/usecase/service"
+)
+
+func newUserService() domain.UserService {
+	pg := db.NewPostgreeDb(config.App.DbHost, config.App.DbPort, config.App.DbName, config.App.DbUser, config.App.DbPass)
+	conn := pg.Connect().GetConnection()
+	return service.NewUserService(repository.NewUserRepository(conn), repository.NewPostRepository(conn), db.NewTxManager(conn))
+}
+
+// cliContext is the context of the user commands, operators run them with every permission.
+func cliContext() context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "cli", Roles: []auth.Role{auth.RoleAdmin}})
+}
+
+// fileFormat returns the format of a user file from its extension.
+func fileFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl":
+		return userfile.FormatNDJSON
+	}
+	return userfile.FormatCSV
+}
+
+func newUsersPurgeCmd() *cobra.Command {
+	var retention time.Duration
+	var purgeCmd = &cobra.Command{
+		Use:          "purge",
+		Short:        "Permanently remove soft deleted users",
+		Long:         "Permanently remove the users soft deleted longer than the retention window (USER_PURGE_RETENTION) ago",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			config.Init()
+			if !c.Flags().Changed("retention") {
+				retention = config.App.UserPurgeRetention
+			}
+
+			n, err := newUserService().PurgeDeleted(context.Background(), retention)
+			if err != nil {
+				return fmt.Errorf("failed to purge soft deleted users: %w", err)
+			}
+
+			log.Infof("%d users soft deleted before %s have been purged", n, time.Now().Add(-retention).Format(time.RFC3339))
+			return nil
+		},
+	}
+
+	purgeCmd.Flags().DurationVar(&retention, "retention", 0, "purge users soft deleted longer than this ago, defaults to USER_PURGE_RETENTION")
+	return purgeCmd
+}
+
+func newUsersExportCmd() *cobra.Command {
+	var req reqres.ExportUserReq
+	var output string
+	var exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export the users to a CSV or NDJSON file",
+		Long: "Export the users ordered by id to a CSV or NDJSON file, filtered like GET /api/v1/user/export, " +
+			"password hashes are not exported",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			config.Init()
+			if err := reqres.NewValidator().Struct(&req, ""); err != nil {
+				return fmt.Errorf("invalid export: %w", err)
+			}
+
+			var w io.Writer = os.Stdout
+			if output != "" && output != "-" {
+				f, err := os.Create(output)
+				if err != nil {
+					return fmt.Errorf("failed to create the export file: %w", err)
+				}
+				defer f.Close()
+				w = f
+			}
+
+			file, err := userfile.NewWriter(w, req.Format)
+			if err != nil {
+				return fmt.Errorf("failed to export users: %w", err)
+			}
+
+			n := 0
+			err = newUserService().Export(cliContext(), req.ListUserReq(), func(user *domain.User) error {
+				n++
+				return file.Write(user)
+			})
+			if err == nil {
+				err = file.Flush()
+			}
+			if err != nil {
+				return fmt.Errorf("failed to export users: %w", err)
+			}
+
+			log.Infof("%d users have been exported", n)
+			return nil
+		},
+	}
+
+	exportCmd.Flags().StringVar(&req.Format, "format", userfile.FormatCSV, "format of the export, csv or ndjson")
+	exportCmd.Flags().StringVarP(&output, "output", "o", "", "file to export to, defaults to stdout")
+	exportCmd.Flags().StringVar(&req.Email, "email", "", "export the users with this email only")
+	exportCmd.Flags().StringVar(&req.FirstName, "first-name", "", "export the users with this first name only")
+	exportCmd.Flags().StringVar(&req.LastName, "last-name", "", "export the users with this last name only")
+	exportCmd.Flags().StringVar(&req.Q, "q", "", "export the users whose name or email contains this text only")
+	exportCmd.Flags().BoolVar(&req.IncludeDeleted, "include-deleted", false, "export the soft deleted users too")
+	return exportCmd
+}
+
+func newUsersImportCmd() *cobra.Command {
+	var format, rejects string
+	var importCmd = &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import the users of a CSV or NDJSON file",
+		Long: "Import the users of a CSV or NDJSON file, the rows that fail the validation or cannot be created " +
+			"are written to the reject file with their line and error",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			config.Init()
+			path := args[0]
+			if format == "" {
+				format = fileFormat(path)
+			}
+			if rejects == "" {
+				ext := filepath.Ext(path)
+				rejects = strings.TrimSuffix(path, ext) + ".rejects" + ext
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("failed to open the import file: %w", err)
+			}
+			defer f.Close()
+
+			reader, err := userfile.NewReader(f, format)
+			if err != nil {
+				return fmt.Errorf("failed to read the import file: %w", err)
+			}
+
+			rejectFile, err := os.Create(rejects)
+			if err != nil {
+				return fmt.Errorf("failed to create the reject file: %w", err)
+			}
+			defer rejectFile.Close()
+
+			imp := userImport{svc: newUserService(), reader: reader, rejects: userfile.NewRejectWriter(rejectFile, reader)}
+			err = imp.run(cliContext(), config.App.UserBatchMaxSize)
+			if flushErr := imp.rejects.Flush(); err == nil {
+				err = flushErr
+			}
+			if imp.rejected == 0 {
+				os.Remove(rejects)
+			}
+			if err != nil {
+				return fmt.Errorf("import stopped after %d users imported and %d rejected: %w", imp.imported, imp.rejected, err)
+			}
+
+			if imp.rejected > 0 {
+				log.Warnf("%d users have been imported, %d rejected to %s", imp.imported, imp.rejected, rejects)
+				return nil
+			}
+			log.Infof("%d users have been imported", imp.imported)
+			return nil
+		},
+	}
+
+	importCmd.Flags().StringVar(&format, "format", "", "format of the file, csv or ndjson, defaults from the file extension")
+	importCmd.Flags().StringVar(&rejects, "rejects", "", "file the rejected rows are written to, defaults to <file>.rejects.<ext>")
+	return importCmd
+}
+
+// defaultImportBatchSize is the batch size of an import when USER_BATCH_MAX_SIZE does not limit batches.
+const defaultImportBatchSize = 1000
+
+// userImport creates the users read from an import file in partial batches, a row is rejected on its own.
+type userImport struct {
+	svc     domain.UserService
+	reader  *userfile.Reader
+	rejects *userfile.RejectWriter
+
+	imported, rejected int
+}
+
+func (i *userImport) run(ctx context.Context, batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = defaultImportBatchSize
+	}
+
+	validate := reqres.NewValidator()
+	rows := make([]*userfile.Row, 0, batchSize)
+	for {
+		row, err := i.reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if row.Err == nil {
+			row.Err = validate.Struct(&row.User, "")
+		}
+		if row.Err != nil {
+			if err := i.reject(row, row.Err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		rows = append(rows, row)
+		if len(rows) == batchSize {
+			if err := i.create(ctx, rows); err != nil {
+				return err
+			}
+			rows = rows[:0]
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+	return i.create(ctx, rows)
+}
+
+func (i *userImport) create(ctx context.Context, rows []*userfile.Row) error {
+	req := reqres.BatchUserReq{Operations: make([]reqres.BatchUserOpReq, len(rows))}
+	for j, row := range rows {
+		req.Operations[j] = reqres.BatchUserOpReq{Op: reqres.BatchOpCreate, User: &row.User}
+	}
+
+	results, err := i.svc.Batch(ctx, &req)
+	if err != nil {
+		return fmt.Errorf("create users of lines %d to %d: %w", rows[0].Line, rows[len(rows)-1].Line, err)
+	}
+
+	for j, res := range results {
+		if res.Err != nil {
+			if err := i.reject(rows[j], res.Err); err != nil {
+				return err
+			}
+			continue
+		}
+		i.imported++
+	}
+
+	return nil
+}
+
+func (i *userImport) reject(row *userfile.Row, reason error) error {
+	i.rejected++
+	return i.rejects.Reject(row, reason)
+}
+
+func NewUsersCmd() *cobra.Command {
+	var usersCmd = &cobra.Command{
+		Use:   "users",
+		Short: "Manage the users",
+		Long:  "Manage the users",
+		Run: func(c *cobra.Command, args []string) {
+			c.HelpFunc()(c, args)
+		},
+	}
+
+	usersCmd.AddCommand(newUsersPurgeCmd(), newUsersExportCmd(), newUsersImportCmd())
+	return usersCmd
+}
@@ -1,7 +1,14 @@
 package main
 
-import "go-rest-api-boilerplate/cmd/commands"
+import (
+	"os"
+
+	"go-rest-api-boilerplate/cmd/commands"
+)
 
 func main() {
-	commands.NewRootCommand().Execute()
+	// The commands returning an error have it printed by cobra, the process then exits with a failure.
+	if err := commands.NewRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
 }
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/ilyakaznacheev/cleanenv"
 	log "github.com/sirupsen/logrus"
 )
@@ -10,12 +12,55 @@ type configs struct {
 	ServiceAddress     string `env:"SERVICE_ADDRESS" yaml:"service_address" env-default:":8080"`
 	ServiceEnvironment string `env:"SERVICE_ENVIRONMENT" yaml:"service_environment" env-default:"production"`
 
+	HttpErrorFormat string `env:"HTTP_ERROR_FORMAT" yaml:"http_error_format" env-default:"legacy"`
+
+	JwtHmacSecret   string        `env:"JWT_HMAC_SECRET" yaml:"jwt_hmac_secret"`
+	JwtJwksUrl      string        `env:"JWT_JWKS_URL" yaml:"jwt_jwks_url"`
+	JwtJwksFile     string        `env:"JWT_JWKS_FILE" yaml:"jwt_jwks_file"`
+	JwtJwksCacheTTL time.Duration `env:"JWT_JWKS_CACHE_TTL" yaml:"jwt_jwks_cache_ttl" env-default:"15m"`
+	JwtIssuer       string        `env:"JWT_ISSUER" yaml:"jwt_issuer"`
+	JwtAudience     string        `env:"JWT_AUDIENCE" yaml:"jwt_audience"`
+	AuthAllowlist   []string      `env:"AUTH_ALLOWLIST" yaml:"auth_allowlist" env-default:"/healthz,/api/v1/auth/*" env-separator:","`
+
+	AuthAccessTokenTTL  time.Duration `env:"AUTH_ACCESS_TOKEN_TTL" yaml:"auth_access_token_ttl" env-default:"15m"`
+	AuthRefreshTokenTTL time.Duration `env:"AUTH_REFRESH_TOKEN_TTL" yaml:"auth_refresh_token_ttl" env-default:"720h"`
+
+	RateLimitRequests int           `env:"RATE_LIMIT_REQUESTS" yaml:"rate_limit_requests" env-default:"100"`
+	RateLimitPeriod   time.Duration `env:"RATE_LIMIT_PERIOD" yaml:"rate_limit_period" env-default:"1m"`
+	RateLimitRoutes   []string      `env:"RATE_LIMIT_ROUTES" yaml:"rate_limit_routes" env-default:"POST /api/v1/auth/login=10/1m" env-separator:","`
+	// RateLimitAuthFailures is the number of failed authentications allowed per client IP, empty for no limit.
+	RateLimitAuthFailures string `env:"RATE_LIMIT_AUTH_FAILURES" yaml:"rate_limit_auth_failures" env-default:"20/1m"`
+
+	TrustedProxies     []string `env:"TRUSTED_PROXIES" yaml:"trusted_proxies" env-separator:","`
+	AccessLogSample2xx float64  `env:"ACCESS_LOG_SAMPLE_2XX" yaml:"access_log_sample_2xx" env-default:"1"`
+	AccessLogExclude   []string `env:"ACCESS_LOG_EXCLUDE" yaml:"access_log_exclude" env-default:"/healthz" env-separator:","`
+
+	SecurityHstsMaxAge     time.Duration `env:"SECURITY_HSTS_MAX_AGE" yaml:"security_hsts_max_age" env-default:"4320h"`
+	SecurityReferrerPolicy string        `env:"SECURITY_REFERRER_POLICY" yaml:"security_referrer_policy" env-default:"no-referrer"`
+	SecurityCsp            string        `env:"SECURITY_CSP" yaml:"security_csp" env-default:"default-src 'none'; frame-ancestors 'none'"`
+	HttpMaxBodySize        string        `env:"HTTP_MAX_BODY_SIZE" yaml:"http_max_body_size" env-default:"1MB"`
+	HttpMaxBodySizeRoutes  []string      `env:"HTTP_MAX_BODY_SIZE_ROUTES" yaml:"http_max_body_size_routes" env-default:"POST /api/v1/user:batch=10MB" env-separator:","`
+
+	CorsAllowedOrigins   []string      `env:"CORS_ALLOWED_ORIGINS" yaml:"cors_allowed_origins" env-separator:","`
+	CorsAllowedMethods   []string      `env:"CORS_ALLOWED_METHODS" yaml:"cors_allowed_methods" env-default:"GET,POST,PUT,PATCH,DELETE" env-separator:","`
+	CorsAllowedHeaders   []string      `env:"CORS_ALLOWED_HEADERS" yaml:"cors_allowed_headers" env-default:"Accept,Accept-Language,Authorization,Content-Type,Idempotency-Key,If-Match,If-None-Match,X-API-Key,X-Request-ID" env-separator:","`
+	CorsExposedHeaders   []string      `env:"CORS_EXPOSED_HEADERS" yaml:"cors_exposed_headers" env-default:"ETag,Location,X-Request-ID,RateLimit-Limit,RateLimit-Remaining,Retry-After,Idempotent-Replayed" env-separator:","`
+	CorsAllowCredentials bool          `env:"CORS_ALLOW_CREDENTIALS" yaml:"cors_allow_credentials" env-default:"false"`
+	CorsMaxAge           time.Duration `env:"CORS_MAX_AGE" yaml:"cors_max_age" env-default:"10m"`
+
+	IdempotencyTTL time.Duration `env:"IDEMPOTENCY_TTL" yaml:"idempotency_ttl" env-default:"24h"`
+
+	UserPurgeRetention time.Duration `env:"USER_PURGE_RETENTION" yaml:"user_purge_retention" env-default:"720h"`
+	UserBatchMaxSize   int           `env:"USER_BATCH_MAX_SIZE" yaml:"user_batch_max_size" env-default:"1000"`
+
 	DbHost string `env:"DB_HOST" yaml:"db_host" env-default:"localhost"`
 	DbPort string `env:"DB_PORT" yaml:"db_port" env-default:"5432"`
 	DbUser string `env:"DB_USER" yaml:"db_user" env-default:"postgres"`
 	DbPass string `env:"DB_PASSWORD" yaml:"db_password" env-default:"postgres"`
 	DbName string `env:"DB_NAME" yaml:"db_name" env-default:"svc-go-rest-api-boilerplate"`
 
+	DbTxMaxRetries int `env:"DB_TX_MAX_RETRIES" yaml:"db_tx_max_retries" env-default:"3"`
+
 	OtelUptraceDsn string `env:"OTEL_UPTRACE_DSN" yaml:"otel_uptrace_dsn" env-default:"https://ojnMDvABsRBuUbQntWnbnQ@uptrace.dev/860"`
 	//OtelOtlpCollectorUrl string `env:"OTEL_OTLP_COLLECTOR_URL" yaml:"otel_otlp_collector_url" env-default:"localhost:4317"`
 	//OtelInsecOtlpColUrl  bool   `env:"OTEL_INSECURE_OTLP_COLLECTOR" yaml:"otel_insecure_otlp_collector" env-default:"true"`
@@ -3,9 +3,12 @@ package config
 import (
 	log "github.com/sirupsen/logrus"
 	"github.com/uptrace/opentelemetry-go-extra/otellogrus"
+	"go-rest-api-boilerplate/pkg/requestid"
 )
 
 func InitLogger() {
+	log.AddHook(requestid.LogHook{})
+
 	if App.ServiceEnvironment == "production" {
 		log.SetFormatter(&log.JSONFormatter{})
 		log.AddHook(otellogrus.NewHook(otellogrus.WithLevels(
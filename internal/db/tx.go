@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/config"
+	"go-rest-api-boilerplate/internal/domain"
+)
+
+// pqSerializationFailure is the SQLSTATE of a transaction that could not be serialized with a concurrent
+// one, it may succeed when run again.
+const pqSerializationFailure = "40001"
+
+// txRetryBackoff is the wait before the first retry of a transaction, doubled by each retry.
+const txRetryBackoff = 10 * time.Millisecond
+
+// Querier is what the queries need of *sql.DB and *sql.Tx, so a query runs the same in and out of a
+// transaction.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// tx is the transaction a context runs within, with the depth of its savepoints. Like *sql.Tx it is not
+// meant to be used by several goroutines at once.
+type tx struct {
+	*sql.Tx
+	savepoints int
+}
+
+// Conn returns the transaction ctx runs within, or conn outside of a transaction. The repositories run
+// their queries on it so they take part in the transaction of TxManager.WithinTx.
+func Conn(ctx context.Context, conn *sql.DB) Querier {
+	if t, ok := ctx.Value(txKey{}).(*tx); ok {
+		return t.Tx
+	}
+
+	return conn
+}
+
+type txManager struct {
+	conn       *sql.DB
+	maxRetries int
+}
+
+// NewTxManager returns the TxManager of conn, a transaction failing to serialize is run again up to
+// DB_TX_MAX_RETRIES times.
+func NewTxManager(conn *sql.DB) domain.TxManager {
+	return &txManager{conn: conn, maxRetries: config.App.DbTxMaxRetries}
+}
+
+func (m *txManager) WithinTx(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	if t, ok := ctx.Value(txKey{}).(*tx); ok {
+		return withinSavepoint(ctx, t, fn)
+	}
+
+	backoff := txRetryBackoff
+	for retry := 0; ; retry++ {
+		err := m.withinTx(ctx, opts, fn)
+		if retry >= m.maxRetries || !isSerializationFailure(err) {
+			return err
+		}
+
+		log.WithContext(ctx).WithError(err).Warnf("transaction failed to serialize, retry %d of %d", retry+1, m.maxRetries)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (m *txManager) withinTx(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	sqlTx, err := m.conn.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			sqlTx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, &tx{Tx: sqlTx})); err != nil {
+		if rbErr := sqlTx.Rollback(); rbErr != nil {
+			log.WithContext(ctx).WithError(rbErr).Error("error rollback transaction")
+		}
+		return err
+	}
+
+	return sqlTx.Commit()
+}
+
+// withinSavepoint runs fn within a savepoint of t named after its depth, the savepoint is released once fn
+// returns so the next one at the same depth reuses the name.
+func withinSavepoint(ctx context.Context, t *tx, fn func(ctx context.Context) error) error {
+	t.savepoints++
+	defer func() { t.savepoints-- }()
+
+	name := "sp_" + strconv.Itoa(t.savepoints)
+	if _, err := t.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+
+	if err := fn(ctx); err != nil {
+		if _, rbErr := t.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			log.WithContext(ctx).WithError(rbErr).Error("error rollback to savepoint")
+			return err
+		}
+		if _, relErr := t.ExecContext(ctx, "RELEASE SAVEPOINT "+name); relErr != nil {
+			log.WithContext(ctx).WithError(relErr).Error("error release savepoint")
+		}
+		return err
+	}
+
+	_, err := t.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
+
+func isSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pqSerializationFailure
+}
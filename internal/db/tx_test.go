@@ -0,0 +1,225 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTxManagerTest(t *testing.T, maxRetries int) (*txManager, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &txManager{conn: conn, maxRetries: maxRetries}, mock
+}
+
+// txOptionsConnector is a driver connector recording the options its transactions are begun with, which
+// sqlmock does not expose.
+type txOptionsConnector struct {
+	opts []driver.TxOptions
+}
+
+func (c *txOptionsConnector) Connect(context.Context) (driver.Conn, error) {
+	return &txOptionsConn{c}, nil
+}
+func (c *txOptionsConnector) Driver() driver.Driver { return nil }
+
+type txOptionsConn struct {
+	connector *txOptionsConnector
+}
+
+func (c *txOptionsConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (c *txOptionsConn) Close() error              { return nil }
+func (c *txOptionsConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *txOptionsConn) Commit() error             { return nil }
+func (c *txOptionsConn) Rollback() error           { return nil }
+
+func (c *txOptionsConn) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.connector.opts = append(c.connector.opts, opts)
+	return c, nil
+}
+
+func TestConn(t *testing.T) {
+	m, mock := newTxManagerTest(t, 0)
+	assert.Equal(t, m.conn, Conn(context.TODO(), m.conn))
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	err := m.WithinTx(context.TODO(), nil, func(ctx context.Context) error {
+		_, ok := Conn(ctx, m.conn).(*sql.Tx)
+		assert.True(t, ok)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestTxManager_WithinTx(t *testing.T) {
+	t.Run("success:commit", func(t *testing.T) {
+		m, mock := newTxManagerTest(t, 0)
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM users").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := m.WithinTx(context.TODO(), nil, func(ctx context.Context) error {
+			_, err := Conn(ctx, m.conn).ExecContext(ctx, "DELETE FROM users")
+			return err
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("success:options", func(t *testing.T) {
+		connector := &txOptionsConnector{}
+		conn := sql.OpenDB(connector)
+		defer conn.Close()
+		m := &txManager{conn: conn}
+
+		err := m.WithinTx(context.TODO(), &sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}, func(ctx context.Context) error {
+			return nil
+		})
+		assert.NoError(t, err)
+		err = m.WithinTx(context.TODO(), nil, func(ctx context.Context) error { return nil })
+		assert.NoError(t, err)
+
+		assert.Equal(t, []driver.TxOptions{
+			{Isolation: driver.IsolationLevel(sql.LevelSerializable), ReadOnly: true},
+			{Isolation: driver.IsolationLevel(sql.LevelDefault)},
+		}, connector.opts)
+	})
+
+	t.Run("error:rollback", func(t *testing.T) {
+		m, mock := newTxManagerTest(t, 3)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		fnErr := errors.New("Unexpexted Error")
+		err := m.WithinTx(context.TODO(), nil, func(ctx context.Context) error { return fnErr })
+		assert.ErrorIs(t, err, fnErr)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error:rollback on panic", func(t *testing.T) {
+		m, mock := newTxManagerTest(t, 0)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		assert.Panics(t, func() {
+			_ = m.WithinTx(context.TODO(), nil, func(ctx context.Context) error { panic("boom") })
+		})
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error:begin", func(t *testing.T) {
+		m, mock := newTxManagerTest(t, 0)
+		mock.ExpectBegin().WillReturnError(errors.New("Unexpexted Error"))
+
+		err := m.WithinTx(context.TODO(), nil, func(ctx context.Context) error {
+			t.Fatal("fn must not run without a transaction")
+			return nil
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("success:nested savepoints", func(t *testing.T) {
+		m, mock := newTxManagerTest(t, 0)
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("SAVEPOINT sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ROLLBACK TO SAVEPOINT sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("RELEASE SAVEPOINT sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		innerErr := errors.New("inner failure")
+		err := m.WithinTx(context.TODO(), nil, func(ctx context.Context) error {
+			err := m.WithinTx(ctx, nil, func(ctx context.Context) error {
+				err := m.WithinTx(ctx, nil, func(ctx context.Context) error { return innerErr })
+				assert.ErrorIs(t, err, innerErr)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			return m.WithinTx(ctx, nil, func(ctx context.Context) error { return nil })
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("success:retry serialization failure", func(t *testing.T) {
+		m, mock := newTxManagerTest(t, 3)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		runs := 0
+		err := m.WithinTx(context.TODO(), nil, func(ctx context.Context) error {
+			runs++
+			if runs == 1 {
+				return &pq.Error{Code: pqSerializationFailure}
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, runs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error:retries exhausted", func(t *testing.T) {
+		m, mock := newTxManagerTest(t, 1)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		runs := 0
+		err := m.WithinTx(context.TODO(), nil, func(ctx context.Context) error {
+			runs++
+			return &pq.Error{Code: pqSerializationFailure}
+		})
+		assert.True(t, isSerializationFailure(err))
+		assert.Equal(t, 2, runs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error:nested serialization failure retries the transaction", func(t *testing.T) {
+		m, mock := newTxManagerTest(t, 1)
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ROLLBACK TO SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		runs := 0
+		err := m.WithinTx(context.TODO(), nil, func(ctx context.Context) error {
+			return m.WithinTx(ctx, nil, func(ctx context.Context) error {
+				runs++
+				if runs == 1 {
+					return &pq.Error{Code: pqSerializationFailure}
+				}
+				return nil
+			})
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, runs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go-rest-api-boilerplate/internal/model/auth"
+	"go-rest-api-boilerplate/internal/model/reqres"
+)
+
+// ApiKey authenticates a service-to-service caller with the X-API-Key header. Only the hash of the key
+// is stored, the prefix finds the key without scanning the hashes.
+type ApiKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type ApiKeyRepository interface {
+	Save(ctx context.Context, key *ApiKey) error
+	FindByPrefix(ctx context.Context, prefix string) (*ApiKey, error)
+	FindAll(ctx context.Context) (*[]ApiKey, error)
+	Revoke(ctx context.Context, id int64, at time.Time) error
+	TouchLastUsed(ctx context.Context, id int64, at time.Time) error
+}
+
+type ApiKeyService interface {
+	// Create issues a key and returns it with its plaintext, which is not stored and cannot be read again.
+	Create(ctx context.Context, req *reqres.CreateApiKeyReq) (*ApiKey, string, error)
+	FindAll(ctx context.Context) (*[]ApiKey, error)
+	Revoke(ctx context.Context, id int64) error
+	// Authenticate resolves a plaintext key to the principal it authenticates.
+	Authenticate(ctx context.Context, key string) (*auth.Principal, error)
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go-rest-api-boilerplate/internal/model/reqres"
+)
+
+// UserCredentials are what a user logs in with and the role its tokens are granted, PasswordHash is
+// empty for a user created without a password.
+type UserCredentials struct {
+	UserID       int64
+	PasswordHash string
+	Role         string
+}
+
+// RefreshToken is an issued refresh token, only its hash is stored. Every rotation issues a token of the
+// same family, the reuse of a used token revokes the whole family.
+type RefreshToken struct {
+	ID        int64
+	UserID    int64
+	FamilyID  string
+	Hash      string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+type RefreshTokenRepository interface {
+	Save(ctx context.Context, token *RefreshToken) error
+	FindByHash(ctx context.Context, hash string) (*RefreshToken, error)
+	// MarkUsed marks the token used, ErrNotFound when it already is.
+	MarkUsed(ctx context.Context, id int64, at time.Time) error
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+}
+
+type AuthService interface {
+	Login(ctx context.Context, req *reqres.LoginReq) (*reqres.TokenRes, error)
+	Refresh(ctx context.Context, req *reqres.RefreshTokenReq) (*reqres.TokenRes, error)
+	Logout(ctx context.Context, req *reqres.RefreshTokenReq) error
+}
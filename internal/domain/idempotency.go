@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyKey records a request sent with an Idempotency-Key header and, once handled, its response
+// replayed to the retries of the request. Only the hash of the key, scoped to the client, is stored.
+type IdempotencyKey struct {
+	ID      int64
+	KeyHash string
+	// Fingerprint hashes the method, path and body of the request.
+	Fingerprint string
+	Status      int
+	Header      map[string]string
+	Body        []byte
+	CompletedAt *time.Time
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+}
+
+type IdempotencyRepository interface {
+	// Claim inserts the key, or replaces it when it has expired. It reports false when an unexpired key
+	// with the same hash exists.
+	Claim(ctx context.Context, key *IdempotencyKey) (bool, error)
+	FindByKeyHash(ctx context.Context, keyHash string) (*IdempotencyKey, error)
+	// Complete stores the response of the key.
+	Complete(ctx context.Context, key *IdempotencyKey) error
+	Delete(ctx context.Context, keyHash string) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type IdempotencyService interface {
+	// Begin claims key for the request with fingerprint, it returns nil when the key is claimed and the
+	// completed key of an earlier request to replay otherwise. The key of a different request is rejected
+	// with ErrBadParamInput, the key of a request still in flight with ErrConflict.
+	Begin(ctx context.Context, key, fingerprint string) (*IdempotencyKey, error)
+	// Complete stores the response of the claimed key.
+	Complete(ctx context.Context, key string, status int, header map[string]string, body []byte) error
+	// Release forgets the claimed key of a failed request, so it can be retried.
+	Release(ctx context.Context, key string) error
+	PurgeExpired(ctx context.Context) (int64, error)
+}
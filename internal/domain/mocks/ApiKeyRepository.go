@@ -0,0 +1,119 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "go-rest-api-boilerplate/internal/domain"
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ApiKeyRepository is an autogenerated mock type for the ApiKeyRepository type
+type ApiKeyRepository struct {
+	mock.Mock
+}
+
+// FindAll provides a mock function with given fields: ctx
+func (_m *ApiKeyRepository) FindAll(ctx context.Context) (*[]domain.ApiKey, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *[]domain.ApiKey
+	if rf, ok := ret.Get(0).(func(context.Context) *[]domain.ApiKey); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]domain.ApiKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByPrefix provides a mock function with given fields: ctx, prefix
+func (_m *ApiKeyRepository) FindByPrefix(ctx context.Context, prefix string) (*domain.ApiKey, error) {
+	ret := _m.Called(ctx, prefix)
+
+	var r0 *domain.ApiKey
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.ApiKey); ok {
+		r0 = rf(ctx, prefix)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.ApiKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, prefix)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Revoke provides a mock function with given fields: ctx, id, at
+func (_m *ApiKeyRepository) Revoke(ctx context.Context, id int64, at time.Time) error {
+	ret := _m.Called(ctx, id, at)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) error); ok {
+		r0 = rf(ctx, id, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Save provides a mock function with given fields: ctx, key
+func (_m *ApiKeyRepository) Save(ctx context.Context, key *domain.ApiKey) error {
+	ret := _m.Called(ctx, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.ApiKey) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TouchLastUsed provides a mock function with given fields: ctx, id, at
+func (_m *ApiKeyRepository) TouchLastUsed(ctx context.Context, id int64, at time.Time) error {
+	ret := _m.Called(ctx, id, at)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) error); ok {
+		r0 = rf(ctx, id, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewApiKeyRepository interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewApiKeyRepository creates a new instance of ApiKeyRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewApiKeyRepository(t mockConstructorTestingTNewApiKeyRepository) *ApiKeyRepository {
+	mock := &ApiKeyRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
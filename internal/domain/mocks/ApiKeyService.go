@@ -0,0 +1,122 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "go-rest-api-boilerplate/internal/domain"
+	auth "go-rest-api-boilerplate/internal/model/auth"
+	reqres "go-rest-api-boilerplate/internal/model/reqres"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ApiKeyService is an autogenerated mock type for the ApiKeyService type
+type ApiKeyService struct {
+	mock.Mock
+}
+
+// Authenticate provides a mock function with given fields: ctx, key
+func (_m *ApiKeyService) Authenticate(ctx context.Context, key string) (*auth.Principal, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 *auth.Principal
+	if rf, ok := ret.Get(0).(func(context.Context, string) *auth.Principal); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*auth.Principal)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: ctx, req
+func (_m *ApiKeyService) Create(ctx context.Context, req *reqres.CreateApiKeyReq) (*domain.ApiKey, string, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *domain.ApiKey
+	if rf, ok := ret.Get(0).(func(context.Context, *reqres.CreateApiKeyReq) *domain.ApiKey); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.ApiKey)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(context.Context, *reqres.CreateApiKeyReq) string); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *reqres.CreateApiKeyReq) error); ok {
+		r2 = rf(ctx, req)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// FindAll provides a mock function with given fields: ctx
+func (_m *ApiKeyService) FindAll(ctx context.Context) (*[]domain.ApiKey, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *[]domain.ApiKey
+	if rf, ok := ret.Get(0).(func(context.Context) *[]domain.ApiKey); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]domain.ApiKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Revoke provides a mock function with given fields: ctx, id
+func (_m *ApiKeyService) Revoke(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewApiKeyService interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewApiKeyService creates a new instance of ApiKeyService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewApiKeyService(t mockConstructorTestingTNewApiKeyService) *ApiKeyService {
+	mock := &ApiKeyService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,91 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	reqres "go-rest-api-boilerplate/internal/model/reqres"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AuthService is an autogenerated mock type for the AuthService type
+type AuthService struct {
+	mock.Mock
+}
+
+// Login provides a mock function with given fields: ctx, req
+func (_m *AuthService) Login(ctx context.Context, req *reqres.LoginReq) (*reqres.TokenRes, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *reqres.TokenRes
+	if rf, ok := ret.Get(0).(func(context.Context, *reqres.LoginReq) *reqres.TokenRes); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*reqres.TokenRes)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *reqres.LoginReq) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Logout provides a mock function with given fields: ctx, req
+func (_m *AuthService) Logout(ctx context.Context, req *reqres.RefreshTokenReq) error {
+	ret := _m.Called(ctx, req)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *reqres.RefreshTokenReq) error); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Refresh provides a mock function with given fields: ctx, req
+func (_m *AuthService) Refresh(ctx context.Context, req *reqres.RefreshTokenReq) (*reqres.TokenRes, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *reqres.TokenRes
+	if rf, ok := ret.Get(0).(func(context.Context, *reqres.RefreshTokenReq) *reqres.TokenRes); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*reqres.TokenRes)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *reqres.RefreshTokenReq) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewAuthService interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewAuthService creates a new instance of AuthService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewAuthService(t mockConstructorTestingTNewAuthService) *AuthService {
+	mock := &AuthService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
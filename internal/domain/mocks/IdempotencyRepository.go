@@ -0,0 +1,124 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "go-rest-api-boilerplate/internal/domain"
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IdempotencyRepository is an autogenerated mock type for the IdempotencyRepository type
+type IdempotencyRepository struct {
+	mock.Mock
+}
+
+// Claim provides a mock function with given fields: ctx, key
+func (_m *IdempotencyRepository) Claim(ctx context.Context, key *domain.IdempotencyKey) (bool, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.IdempotencyKey) bool); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.IdempotencyKey) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Complete provides a mock function with given fields: ctx, key
+func (_m *IdempotencyRepository) Complete(ctx context.Context, key *domain.IdempotencyKey) error {
+	ret := _m.Called(ctx, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.IdempotencyKey) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Delete provides a mock function with given fields: ctx, keyHash
+func (_m *IdempotencyRepository) Delete(ctx context.Context, keyHash string) error {
+	ret := _m.Called(ctx, keyHash)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, keyHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteExpired provides a mock function with given fields: ctx, before
+func (_m *IdempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByKeyHash provides a mock function with given fields: ctx, keyHash
+func (_m *IdempotencyRepository) FindByKeyHash(ctx context.Context, keyHash string) (*domain.IdempotencyKey, error) {
+	ret := _m.Called(ctx, keyHash)
+
+	var r0 *domain.IdempotencyKey
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.IdempotencyKey); ok {
+		r0 = rf(ctx, keyHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.IdempotencyKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, keyHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewIdempotencyRepository interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewIdempotencyRepository creates a new instance of IdempotencyRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewIdempotencyRepository(t mockConstructorTestingTNewIdempotencyRepository) *IdempotencyRepository {
+	mock := &IdempotencyRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
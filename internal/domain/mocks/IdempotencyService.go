@@ -0,0 +1,102 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "go-rest-api-boilerplate/internal/domain"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IdempotencyService is an autogenerated mock type for the IdempotencyService type
+type IdempotencyService struct {
+	mock.Mock
+}
+
+// Begin provides a mock function with given fields: ctx, key, fingerprint
+func (_m *IdempotencyService) Begin(ctx context.Context, key string, fingerprint string) (*domain.IdempotencyKey, error) {
+	ret := _m.Called(ctx, key, fingerprint)
+
+	var r0 *domain.IdempotencyKey
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.IdempotencyKey); ok {
+		r0 = rf(ctx, key, fingerprint)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.IdempotencyKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, key, fingerprint)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Complete provides a mock function with given fields: ctx, key, status, header, body
+func (_m *IdempotencyService) Complete(ctx context.Context, key string, status int, header map[string]string, body []byte) error {
+	ret := _m.Called(ctx, key, status, header, body)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, map[string]string, []byte) error); ok {
+		r0 = rf(ctx, key, status, header, body)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PurgeExpired provides a mock function with given fields: ctx
+func (_m *IdempotencyService) PurgeExpired(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Release provides a mock function with given fields: ctx, key
+func (_m *IdempotencyService) Release(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewIdempotencyService interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewIdempotencyService creates a new instance of IdempotencyService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewIdempotencyService(t mockConstructorTestingTNewIdempotencyService) *IdempotencyService {
+	mock := &IdempotencyService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
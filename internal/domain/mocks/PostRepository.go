@@ -0,0 +1,158 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "go-rest-api-boilerplate/internal/domain"
+	reqres "go-rest-api-boilerplate/internal/model/reqres"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// PostRepository is an autogenerated mock type for the PostRepository type
+type PostRepository struct {
+	mock.Mock
+}
+
+// Count provides a mock function with given fields: ctx, req
+func (_m *PostRepository) Count(ctx context.Context, req *reqres.ListPostReq) (int64, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, *reqres.ListPostReq) int64); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *reqres.ListPostReq) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteByID provides a mock function with given fields: ctx, id
+func (_m *PostRepository) DeleteByID(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindAll provides a mock function with given fields: ctx, req
+func (_m *PostRepository) FindAll(ctx context.Context, req *reqres.ListPostReq) (*[]domain.Post, *reqres.PageRes, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *[]domain.Post
+	if rf, ok := ret.Get(0).(func(context.Context, *reqres.ListPostReq) *[]domain.Post); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]domain.Post)
+		}
+	}
+
+	var r1 *reqres.PageRes
+	if rf, ok := ret.Get(1).(func(context.Context, *reqres.ListPostReq) *reqres.PageRes); ok {
+		r1 = rf(ctx, req)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*reqres.PageRes)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *reqres.ListPostReq) error); ok {
+		r2 = rf(ctx, req)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// FindByID provides a mock function with given fields: ctx, id
+func (_m *PostRepository) FindByID(ctx context.Context, id int64) (*domain.Post, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *domain.Post
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.Post); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Post)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: ctx, post
+func (_m *PostRepository) Save(ctx context.Context, post *domain.Post) error {
+	ret := _m.Called(ctx, post)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Post) error); ok {
+		r0 = rf(ctx, post)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateByID provides a mock function with given fields: ctx, id, post
+func (_m *PostRepository) UpdateByID(ctx context.Context, id int64, post *domain.Post) (*domain.Post, error) {
+	ret := _m.Called(ctx, id, post)
+
+	var r0 *domain.Post
+	if rf, ok := ret.Get(0).(func(context.Context, int64, *domain.Post) *domain.Post); ok {
+		r0 = rf(ctx, id, post)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Post)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, *domain.Post) error); ok {
+		r1 = rf(ctx, id, post)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewPostRepository interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewPostRepository creates a new instance of PostRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewPostRepository(t mockConstructorTestingTNewPostRepository) *PostRepository {
+	mock := &PostRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,178 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "go-rest-api-boilerplate/internal/domain"
+	reqres "go-rest-api-boilerplate/internal/model/reqres"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// PostService is an autogenerated mock type for the PostService type
+type PostService struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: ctx, req
+func (_m *PostService) Create(ctx context.Context, req *reqres.CreatePostReq) (*domain.Post, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *domain.Post
+	if rf, ok := ret.Get(0).(func(context.Context, *reqres.CreatePostReq) *domain.Post); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Post)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *reqres.CreatePostReq) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteByID provides a mock function with given fields: ctx, id
+func (_m *PostService) DeleteByID(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindAll provides a mock function with given fields: ctx, req
+func (_m *PostService) FindAll(ctx context.Context, req *reqres.ListPostReq) (*[]domain.Post, *reqres.PageRes, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *[]domain.Post
+	if rf, ok := ret.Get(0).(func(context.Context, *reqres.ListPostReq) *[]domain.Post); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]domain.Post)
+		}
+	}
+
+	var r1 *reqres.PageRes
+	if rf, ok := ret.Get(1).(func(context.Context, *reqres.ListPostReq) *reqres.PageRes); ok {
+		r1 = rf(ctx, req)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*reqres.PageRes)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *reqres.ListPostReq) error); ok {
+		r2 = rf(ctx, req)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// FindAllByUser provides a mock function with given fields: ctx, userID, req
+func (_m *PostService) FindAllByUser(ctx context.Context, userID int64, req *reqres.ListPostReq) (*[]domain.Post, *reqres.PageRes, error) {
+	ret := _m.Called(ctx, userID, req)
+
+	var r0 *[]domain.Post
+	if rf, ok := ret.Get(0).(func(context.Context, int64, *reqres.ListPostReq) *[]domain.Post); ok {
+		r0 = rf(ctx, userID, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]domain.Post)
+		}
+	}
+
+	var r1 *reqres.PageRes
+	if rf, ok := ret.Get(1).(func(context.Context, int64, *reqres.ListPostReq) *reqres.PageRes); ok {
+		r1 = rf(ctx, userID, req)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*reqres.PageRes)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, int64, *reqres.ListPostReq) error); ok {
+		r2 = rf(ctx, userID, req)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// FindByID provides a mock function with given fields: ctx, id
+func (_m *PostService) FindByID(ctx context.Context, id int64) (*domain.Post, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *domain.Post
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.Post); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Post)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateByID provides a mock function with given fields: ctx, id, req
+func (_m *PostService) UpdateByID(ctx context.Context, id int64, req *reqres.UpdatePostReq) (*domain.Post, error) {
+	ret := _m.Called(ctx, id, req)
+
+	var r0 *domain.Post
+	if rf, ok := ret.Get(0).(func(context.Context, int64, *reqres.UpdatePostReq) *domain.Post); ok {
+		r0 = rf(ctx, id, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Post)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, *reqres.UpdatePostReq) error); ok {
+		r1 = rf(ctx, id, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewPostService interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewPostService creates a new instance of PostService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewPostService(t mockConstructorTestingTNewPostService) *PostService {
+	mock := &PostService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
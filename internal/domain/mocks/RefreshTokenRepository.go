@@ -0,0 +1,96 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "go-rest-api-boilerplate/internal/domain"
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// RefreshTokenRepository is an autogenerated mock type for the RefreshTokenRepository type
+type RefreshTokenRepository struct {
+	mock.Mock
+}
+
+// FindByHash provides a mock function with given fields: ctx, hash
+func (_m *RefreshTokenRepository) FindByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	ret := _m.Called(ctx, hash)
+
+	var r0 *domain.RefreshToken
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.RefreshToken); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.RefreshToken)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkUsed provides a mock function with given fields: ctx, id, at
+func (_m *RefreshTokenRepository) MarkUsed(ctx context.Context, id int64, at time.Time) error {
+	ret := _m.Called(ctx, id, at)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) error); ok {
+		r0 = rf(ctx, id, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeFamily provides a mock function with given fields: ctx, familyID, at
+func (_m *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	ret := _m.Called(ctx, familyID, at)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, familyID, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Save provides a mock function with given fields: ctx, token
+func (_m *RefreshTokenRepository) Save(ctx context.Context, token *domain.RefreshToken) error {
+	ret := _m.Called(ctx, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.RefreshToken) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewRefreshTokenRepository interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewRefreshTokenRepository creates a new instance of RefreshTokenRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewRefreshTokenRepository(t mockConstructorTestingTNewRefreshTokenRepository) *RefreshTokenRepository {
+	mock := &RefreshTokenRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
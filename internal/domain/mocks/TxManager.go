@@ -0,0 +1,45 @@
+// Code generated by mockery v2.14.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	sql "database/sql"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// TxManager is an autogenerated mock type for the TxManager type
+type TxManager struct {
+	mock.Mock
+}
+
+// WithinTx provides a mock function with given fields: ctx, opts, fn
+func (_m *TxManager) WithinTx(ctx context.Context, opts *sql.TxOptions, fn func(context.Context) error) error {
+	ret := _m.Called(ctx, opts, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.TxOptions, func(context.Context) error) error); ok {
+		r0 = rf(ctx, opts, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewTxManager interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewTxManager creates a new instance of TxManager. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewTxManager(t mockConstructorTestingTNewTxManager) *TxManager {
+	mock := &TxManager{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -5,6 +5,8 @@ package mocks
 import (
 	context "context"
 	domain "go-rest-api-boilerplate/internal/domain"
+	reqres "go-rest-api-boilerplate/internal/model/reqres"
+	time "time"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -14,13 +16,71 @@ type UserRepository struct {
 	mock.Mock
 }
 
-// DeleteByID provides a mock function with given fields: ctx, id
-func (_m *UserRepository) DeleteByID(ctx context.Context, id int64) error {
-	ret := _m.Called(ctx, id)
+// ApplyBatch provides a mock function with given fields: ctx, ops, stopOnError
+func (_m *UserRepository) ApplyBatch(ctx context.Context, ops []domain.UserBatchOp, stopOnError bool) ([]domain.UserBatchResult, error) {
+	ret := _m.Called(ctx, ops, stopOnError)
+
+	var r0 []domain.UserBatchResult
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.UserBatchOp, bool) []domain.UserBatchResult); ok {
+		r0 = rf(ctx, ops, stopOnError)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.UserBatchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []domain.UserBatchOp, bool) error); ok {
+		r1 = rf(ctx, ops, stopOnError)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Count provides a mock function with given fields: ctx, req
+func (_m *UserRepository) Count(ctx context.Context, req *reqres.ListUserReq) (int64, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, *reqres.ListUserReq) int64); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *reqres.ListUserReq) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteByID provides a mock function with given fields: ctx, id, version
+func (_m *UserRepository) DeleteByID(ctx context.Context, id int64, version int64) error {
+	ret := _m.Called(ctx, id, version)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, id, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Each provides a mock function with given fields: ctx, req, fn
+func (_m *UserRepository) Each(ctx context.Context, req *reqres.ListUserReq, fn func(*domain.User) error) error {
+	ret := _m.Called(ctx, req, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *reqres.ListUserReq, func(*domain.User) error) error); ok {
+		r0 = rf(ctx, req, fn)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -28,22 +88,54 @@ func (_m *UserRepository) DeleteByID(ctx context.Context, id int64) error {
 	return r0
 }
 
-// FindAll provides a mock function with given fields: ctx
-func (_m *UserRepository) FindAll(ctx context.Context) (*[]domain.User, error) {
-	ret := _m.Called(ctx)
+// FindAll provides a mock function with given fields: ctx, req
+func (_m *UserRepository) FindAll(ctx context.Context, req *reqres.ListUserReq) (*[]domain.User, *reqres.PageRes, error) {
+	ret := _m.Called(ctx, req)
 
 	var r0 *[]domain.User
-	if rf, ok := ret.Get(0).(func(context.Context) *[]domain.User); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, *reqres.ListUserReq) *[]domain.User); ok {
+		r0 = rf(ctx, req)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*[]domain.User)
 		}
 	}
 
+	var r1 *reqres.PageRes
+	if rf, ok := ret.Get(1).(func(context.Context, *reqres.ListUserReq) *reqres.PageRes); ok {
+		r1 = rf(ctx, req)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*reqres.PageRes)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *reqres.ListUserReq) error); ok {
+		r2 = rf(ctx, req)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// FindByID provides a mock function with given fields: ctx, id, includeDeleted
+func (_m *UserRepository) FindByID(ctx context.Context, id int64, includeDeleted bool) (*domain.User, error) {
+	ret := _m.Called(ctx, id, includeDeleted)
+
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool) *domain.User); ok {
+		r0 = rf(ctx, id, includeDeleted)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, bool) error); ok {
+		r1 = rf(ctx, id, includeDeleted)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -51,8 +143,98 @@ func (_m *UserRepository) FindAll(ctx context.Context) (*[]domain.User, error) {
 	return r0, r1
 }
 
-// FindByID provides a mock function with given fields: ctx, id
-func (_m *UserRepository) FindByID(ctx context.Context, id int64) (*domain.User, error) {
+// FindCredentialsByEmail provides a mock function with given fields: ctx, email
+func (_m *UserRepository) FindCredentialsByEmail(ctx context.Context, email string) (*domain.UserCredentials, error) {
+	ret := _m.Called(ctx, email)
+
+	var r0 *domain.UserCredentials
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.UserCredentials); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UserCredentials)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindCredentialsByID provides a mock function with given fields: ctx, id
+func (_m *UserRepository) FindCredentialsByID(ctx context.Context, id int64) (*domain.UserCredentials, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *domain.UserCredentials
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.UserCredentials); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UserCredentials)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PatchByID provides a mock function with given fields: ctx, id, version, patch
+func (_m *UserRepository) PatchByID(ctx context.Context, id int64, version int64, patch *reqres.PatchUserReq) (*domain.User, error) {
+	ret := _m.Called(ctx, id, version, patch)
+
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, *reqres.PatchUserReq) *domain.User); ok {
+		r0 = rf(ctx, id, version, patch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, *reqres.PatchUserReq) error); ok {
+		r1 = rf(ctx, id, version, patch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeDeleted provides a mock function with given fields: ctx, before
+func (_m *UserRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, id
+func (_m *UserRepository) Restore(ctx context.Context, id int64) (*domain.User, error) {
 	ret := _m.Called(ctx, id)
 
 	var r0 *domain.User
@@ -88,18 +270,27 @@ func (_m *UserRepository) Save(ctx context.Context, user *domain.User) error {
 	return r0
 }
 
-// UpdateByID provides a mock function with given fields: ctx, id, user
-func (_m *UserRepository) UpdateByID(ctx context.Context, id int64, user *domain.User) error {
-	ret := _m.Called(ctx, id, user)
+// UpdateByID provides a mock function with given fields: ctx, id, version, user
+func (_m *UserRepository) UpdateByID(ctx context.Context, id int64, version int64, user *domain.User) (*domain.User, error) {
+	ret := _m.Called(ctx, id, version, user)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, *domain.User) error); ok {
-		r0 = rf(ctx, id, user)
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, *domain.User) *domain.User); ok {
+		r0 = rf(ctx, id, version, user)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, *domain.User) error); ok {
+		r1 = rf(ctx, id, version, user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 type mockConstructorTestingTNewUserRepository interface {
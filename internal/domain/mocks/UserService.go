@@ -5,10 +5,10 @@ package mocks
 import (
 	context "context"
 	domain "go-rest-api-boilerplate/internal/domain"
+	reqres "go-rest-api-boilerplate/internal/model/reqres"
+	time "time"
 
 	mock "github.com/stretchr/testify/mock"
-
-	reqres "go-rest-api-boilerplate/internal/model/reqres"
 )
 
 // UserService is an autogenerated mock type for the UserService type
@@ -16,13 +16,91 @@ type UserService struct {
 	mock.Mock
 }
 
+// Batch provides a mock function with given fields: ctx, req
+func (_m *UserService) Batch(ctx context.Context, req *reqres.BatchUserReq) ([]domain.UserBatchResult, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 []domain.UserBatchResult
+	if rf, ok := ret.Get(0).(func(context.Context, *reqres.BatchUserReq) []domain.UserBatchResult); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.UserBatchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *reqres.BatchUserReq) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: ctx, req
-func (_m *UserService) Create(ctx context.Context, req *reqres.CreateUserReq) error {
+func (_m *UserService) Create(ctx context.Context, req *reqres.CreateUserReq) (*domain.User, error) {
 	ret := _m.Called(ctx, req)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *reqres.CreateUserReq) error); ok {
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, *reqres.CreateUserReq) *domain.User); ok {
 		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *reqres.CreateUserReq) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateWithFirstPost provides a mock function with given fields: ctx, req, postReq
+func (_m *UserService) CreateWithFirstPost(ctx context.Context, req *reqres.CreateUserReq, postReq *reqres.FirstPostReq) (*domain.User, *domain.Post, error) {
+	ret := _m.Called(ctx, req, postReq)
+
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, *reqres.CreateUserReq, *reqres.FirstPostReq) *domain.User); ok {
+		r0 = rf(ctx, req, postReq)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	var r1 *domain.Post
+	if rf, ok := ret.Get(1).(func(context.Context, *reqres.CreateUserReq, *reqres.FirstPostReq) *domain.Post); ok {
+		r1 = rf(ctx, req, postReq)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*domain.Post)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *reqres.CreateUserReq, *reqres.FirstPostReq) error); ok {
+		r2 = rf(ctx, req, postReq)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// DeleteByID provides a mock function with given fields: ctx, id, version
+func (_m *UserService) DeleteByID(ctx context.Context, id int64, version int64) error {
+	ret := _m.Called(ctx, id, version)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, id, version)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -30,13 +108,13 @@ func (_m *UserService) Create(ctx context.Context, req *reqres.CreateUserReq) er
 	return r0
 }
 
-// DeleteByID provides a mock function with given fields: ctx, id
-func (_m *UserService) DeleteByID(ctx context.Context, id int64) error {
-	ret := _m.Called(ctx, id)
+// Export provides a mock function with given fields: ctx, req, fn
+func (_m *UserService) Export(ctx context.Context, req *reqres.ListUserReq, fn func(*domain.User) error) error {
+	ret := _m.Called(ctx, req, fn)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, *reqres.ListUserReq, func(*domain.User) error) error); ok {
+		r0 = rf(ctx, req, fn)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -44,22 +122,77 @@ func (_m *UserService) DeleteByID(ctx context.Context, id int64) error {
 	return r0
 }
 
-// FindAll provides a mock function with given fields: ctx
-func (_m *UserService) FindAll(ctx context.Context) (*[]domain.User, error) {
-	ret := _m.Called(ctx)
+// FindAll provides a mock function with given fields: ctx, req
+func (_m *UserService) FindAll(ctx context.Context, req *reqres.ListUserReq) (*[]domain.User, *reqres.PageRes, error) {
+	ret := _m.Called(ctx, req)
 
 	var r0 *[]domain.User
-	if rf, ok := ret.Get(0).(func(context.Context) *[]domain.User); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, *reqres.ListUserReq) *[]domain.User); ok {
+		r0 = rf(ctx, req)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*[]domain.User)
 		}
 	}
 
+	var r1 *reqres.PageRes
+	if rf, ok := ret.Get(1).(func(context.Context, *reqres.ListUserReq) *reqres.PageRes); ok {
+		r1 = rf(ctx, req)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*reqres.PageRes)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *reqres.ListUserReq) error); ok {
+		r2 = rf(ctx, req)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// FindByID provides a mock function with given fields: ctx, id, includeDeleted
+func (_m *UserService) FindByID(ctx context.Context, id int64, includeDeleted bool) (*domain.User, error) {
+	ret := _m.Called(ctx, id, includeDeleted)
+
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool) *domain.User); ok {
+		r0 = rf(ctx, id, includeDeleted)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, bool) error); ok {
+		r1 = rf(ctx, id, includeDeleted)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PatchByID provides a mock function with given fields: ctx, id, version, req
+func (_m *UserService) PatchByID(ctx context.Context, id int64, version int64, req *reqres.PatchUserReq) (*domain.User, error) {
+	ret := _m.Called(ctx, id, version, req)
+
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, *reqres.PatchUserReq) *domain.User); ok {
+		r0 = rf(ctx, id, version, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, *reqres.PatchUserReq) error); ok {
+		r1 = rf(ctx, id, version, req)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -67,8 +200,29 @@ func (_m *UserService) FindAll(ctx context.Context) (*[]domain.User, error) {
 	return r0, r1
 }
 
-// FindByID provides a mock function with given fields: ctx, id
-func (_m *UserService) FindByID(ctx context.Context, id int64) (*domain.User, error) {
+// PurgeDeleted provides a mock function with given fields: ctx, retention
+func (_m *UserService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	ret := _m.Called(ctx, retention)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) int64); ok {
+		r0 = rf(ctx, retention)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Duration) error); ok {
+		r1 = rf(ctx, retention)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, id
+func (_m *UserService) Restore(ctx context.Context, id int64) (*domain.User, error) {
 	ret := _m.Called(ctx, id)
 
 	var r0 *domain.User
@@ -90,18 +244,27 @@ func (_m *UserService) FindByID(ctx context.Context, id int64) (*domain.User, er
 	return r0, r1
 }
 
-// UpdateByID provides a mock function with given fields: ctx, id, req
-func (_m *UserService) UpdateByID(ctx context.Context, id int64, req *reqres.UpdateUserReq) error {
-	ret := _m.Called(ctx, id, req)
+// UpdateByID provides a mock function with given fields: ctx, id, version, req
+func (_m *UserService) UpdateByID(ctx context.Context, id int64, version int64, req *reqres.UpdateUserReq) (*domain.User, error) {
+	ret := _m.Called(ctx, id, version, req)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, *reqres.UpdateUserReq) error); ok {
-		r0 = rf(ctx, id, req)
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, *reqres.UpdateUserReq) *domain.User); ok {
+		r0 = rf(ctx, id, version, req)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, *reqres.UpdateUserReq) error); ok {
+		r1 = rf(ctx, id, version, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 type mockConstructorTestingTNewUserService interface {
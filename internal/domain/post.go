@@ -1,9 +1,35 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"time"
+
+	"go-rest-api-boilerplate/internal/model/reqres"
+)
 
 type Post struct {
-	Content   string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type PostRepository interface {
+	Save(ctx context.Context, post *Post) error
+	UpdateByID(ctx context.Context, id int64, post *Post) (*Post, error)
+	DeleteByID(ctx context.Context, id int64) error
+	FindAll(ctx context.Context, req *reqres.ListPostReq) (*[]Post, *reqres.PageRes, error)
+	Count(ctx context.Context, req *reqres.ListPostReq) (int64, error)
+	FindByID(ctx context.Context, id int64) (*Post, error)
+}
+
+type PostService interface {
+	Create(ctx context.Context, req *reqres.CreatePostReq) (*Post, error)
+	UpdateByID(ctx context.Context, id int64, req *reqres.UpdatePostReq) (*Post, error)
+	DeleteByID(ctx context.Context, id int64) error
+	FindAll(ctx context.Context, req *reqres.ListPostReq) (*[]Post, *reqres.PageRes, error)
+	FindAllByUser(ctx context.Context, userID int64, req *reqres.ListPostReq) (*[]Post, *reqres.PageRes, error)
+	FindByID(ctx context.Context, id int64) (*Post, error)
 }
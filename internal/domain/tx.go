@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+)
+
+// TxManager runs a unit of work within a database transaction. The repositories called with the context
+// handed to fn take part in the transaction, which is committed when fn returns nil and rolled back
+// otherwise.
+type TxManager interface {
+	// WithinTx runs fn within a transaction begun with opts, nil for the isolation level of the database.
+	// Called within a transaction already, fn runs within a savepoint of it, its error only rolls back to
+	// that savepoint, and opts are ignored since a savepoint keeps the isolation of its transaction. fn may
+	// be run again when the transaction fails to serialize, so it must not have side effects outside of
+	// the database.
+	WithinTx(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error
+}
@@ -8,26 +8,74 @@ import (
 )
 
 type User struct {
-	ID        int64     `json:"id"`
-	FirstName string    `json:"first_name"`
-	LastName  string    `json:"last_name"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        int64      `json:"id"`
+	FirstName string     `json:"first_name"`
+	LastName  string     `json:"last_name"`
+	Email     string     `json:"email"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version is incremented by every write, it is the ETag of the user.
+	Version int64 `json:"version"`
+	// PasswordHash is the bcrypt hash stored by Save, the user queries never read it back.
+	PasswordHash string `json:"-"`
+}
+
+// UserBatchOp is one operation of a user batch, the Op of reqres.BatchUserOpReq on the user ID or with
+// User.
+type UserBatchOp struct {
+	Op      string
+	ID      int64
+	Version int64
+	User    *User
+}
+
+// UserBatchResult is the outcome of the operation at the same index of a batch, the created or updated
+// User, or the Err it failed with.
+type UserBatchResult struct {
+	User *User
+	Err  error
 }
 
 type UserRepository interface {
 	Save(ctx context.Context, user *User) error
-	UpdateByID(ctx context.Context, id int64, user *User) error
-	DeleteByID(ctx context.Context, id int64) error
-	FindAll(ctx context.Context) (*[]User, error)
-	FindByID(ctx context.Context, id int64) (*User, error)
+	// UpdateByID, PatchByID and DeleteByID only write the given version of the user, any version when it
+	// is 0.
+	UpdateByID(ctx context.Context, id int64, version int64, user *User) (*User, error)
+	PatchByID(ctx context.Context, id int64, version int64, patch *reqres.PatchUserReq) (*User, error)
+	DeleteByID(ctx context.Context, id int64, version int64) error
+	Restore(ctx context.Context, id int64) (*User, error)
+	// ApplyBatch runs the operations in order and returns their results, with stopOnError the operations
+	// after the first failed one are left without result. The error is only set when the batch could not run.
+	ApplyBatch(ctx context.Context, ops []UserBatchOp, stopOnError bool) ([]UserBatchResult, error)
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	FindAll(ctx context.Context, req *reqres.ListUserReq) (*[]User, *reqres.PageRes, error)
+	// Each calls fn with the users matching the filters of req by id as they are read, without holding
+	// them in memory.
+	Each(ctx context.Context, req *reqres.ListUserReq, fn func(user *User) error) error
+	Count(ctx context.Context, req *reqres.ListUserReq) (int64, error)
+	FindByID(ctx context.Context, id int64, includeDeleted bool) (*User, error)
+	FindCredentialsByEmail(ctx context.Context, email string) (*UserCredentials, error)
+	FindCredentialsByID(ctx context.Context, id int64) (*UserCredentials, error)
 }
 
 type UserService interface {
-	Create(ctx context.Context, req *reqres.CreateUserReq) error
-	UpdateByID(ctx context.Context, id int64, req *reqres.UpdateUserReq) error
-	DeleteByID(ctx context.Context, id int64) error
-	FindAll(ctx context.Context) (*[]User, error)
-	FindByID(ctx context.Context, id int64) (*User, error)
+	Create(ctx context.Context, req *reqres.CreateUserReq) (*User, error)
+	// CreateWithFirstPost creates the user of req along with its first post, both or neither of them.
+	CreateWithFirstPost(ctx context.Context, req *reqres.CreateUserReq, postReq *reqres.FirstPostReq) (*User, *Post, error)
+	// UpdateByID, PatchByID and DeleteByID fail with ErrPreconditionFailed when the user is no longer at
+	// the given version, a version 0 writes any version.
+	UpdateByID(ctx context.Context, id int64, version int64, req *reqres.UpdateUserReq) (*User, error)
+	PatchByID(ctx context.Context, id int64, version int64, req *reqres.PatchUserReq) (*User, error)
+	DeleteByID(ctx context.Context, id int64, version int64) error
+	Restore(ctx context.Context, id int64) (*User, error)
+	// Batch applies the operations of req and returns the result of each of them. An atomic batch fails
+	// with the error of its first failed operation, its field prefixed with operations[i].
+	Batch(ctx context.Context, req *reqres.BatchUserReq) ([]UserBatchResult, error)
+	PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error)
+	FindAll(ctx context.Context, req *reqres.ListUserReq) (*[]User, *reqres.PageRes, error)
+	// Export calls fn with the users matching the filters of req by id as they are read, the paging and
+	// sort of req are ignored.
+	Export(ctx context.Context, req *reqres.ListUserReq, fn func(user *User) error) error
+	FindByID(ctx context.Context, id int64, includeDeleted bool) (*User, error)
 }
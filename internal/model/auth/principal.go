@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Subject identifies the caller, the sub claim of a JWT.
+	Subject string
+	// Roles grant the permissions of the caller, see Can.
+	Roles []Role
+	// Scopes are permissions granted directly, the scopes of an API key.
+	Scopes []Permission
+	// Claims are all the claims of the token the caller authenticated with.
+	Claims map[string]interface{}
+}
+
+// UserID returns the id of the user the principal is, false when the subject is not a user id.
+func (p *Principal) UserID() (int64, bool) {
+	id, err := strconv.ParseInt(p.Subject, 10, 64)
+	return id, err == nil && id > 0
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of ctx, false when the request is not authenticated.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
@@ -0,0 +1,75 @@
+package auth
+
+// Role is a named set of permissions granted to a principal, read from the roles claim of its token.
+type Role string
+
+const (
+	RoleAdmin Role = "admin"
+	RoleUser  Role = "user"
+)
+
+// Permission is an action a route or a service method requires.
+type Permission string
+
+const (
+	PermUserCreate  Permission = "user:create"
+	PermUserList    Permission = "user:list"
+	PermUserRead    Permission = "user:read"
+	PermUserUpdate  Permission = "user:update"
+	PermUserDelete  Permission = "user:delete"
+	PermUserRestore Permission = "user:restore"
+	// PermUserAny lifts the ownership check, the user permissions are then granted on every user record
+	// instead of only on the caller's own one.
+	PermUserAny Permission = "user:any"
+
+	PermPostCreate Permission = "post:create"
+	PermPostList   Permission = "post:list"
+	PermPostRead   Permission = "post:read"
+	PermPostUpdate Permission = "post:update"
+	PermPostDelete Permission = "post:delete"
+	// PermPostAny lifts the ownership check of the posts, the post permissions are then granted on the posts
+	// of every user instead of only on the caller's own ones.
+	PermPostAny Permission = "post:any"
+)
+
+// rolePermissions grants the permissions of each role, unknown roles grant nothing.
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermUserCreate, PermUserList, PermUserRead, PermUserUpdate, PermUserDelete, PermUserRestore, PermUserAny,
+		PermPostCreate, PermPostList, PermPostRead, PermPostUpdate, PermPostDelete, PermPostAny,
+	},
+	RoleUser: {
+		PermUserRead, PermUserUpdate,
+		PermPostCreate, PermPostList, PermPostRead, PermPostUpdate, PermPostDelete,
+	},
+}
+
+// KnownPermission reports whether perm is a permission of this service, the admin role is granted all of them.
+func KnownPermission(perm Permission) bool {
+	for _, granted := range rolePermissions[RoleAdmin] {
+		if granted == perm {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Can reports whether one of the scopes or one of the roles of the principal grants perm.
+func (p *Principal) Can(perm Permission) bool {
+	for _, scope := range p.Scopes {
+		if scope == perm {
+			return true
+		}
+	}
+
+	for _, role := range p.Roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == perm {
+				return true
+			}
+		}
+	}
+
+	return false
+}
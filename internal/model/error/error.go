@@ -8,4 +8,24 @@ var (
 	ErrNotFound      = errors.New("your requested Item is not found")
 	ErrConflict      = errors.New("your Item already exist")
 	ErrBadParamInput = errors.New("given Param is not valid")
+	ErrUnauthorized  = errors.New("authentication is required")
+	ErrForbidden     = errors.New("you are not allowed to perform this action")
+	ErrRateLimited   = errors.New("too many requests, retry later")
+	// ErrPreconditionFailed rejects a write of a version of the item that is no longer the current one.
+	ErrPreconditionFailed = errors.New("your Item was modified since it was read")
 )
+
+// FieldError ties a domain error to the input field that caused it, Rule names the violated rule (e.g. unique).
+type FieldError struct {
+	Field string
+	Rule  string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
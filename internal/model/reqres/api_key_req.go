@@ -0,0 +1,10 @@
+package reqres
+
+import "time"
+
+// CreateApiKeyReq issues an API key granted the Scopes permissions, a zero TTL issues a key that never expires.
+type CreateApiKeyReq struct {
+	Name   string        `json:"name" validate:"required,max=100"`
+	Scopes []string      `json:"scopes" validate:"required,min=1"`
+	TTL    time.Duration `json:"ttl" validate:"min=0"`
+}
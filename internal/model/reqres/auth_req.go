@@ -0,0 +1,48 @@
+package reqres
+
+import (
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Password policy of the password validation tag, bcrypt ignores the bytes past the 72nd.
+const (
+	PasswordMinLength = 8
+	PasswordMaxLength = 72
+)
+
+type LoginReq struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// RefreshTokenReq carries the refresh token to rotate, or to revoke on logout.
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// TokenRes is the OAuth 2.0 style token response of a login or a refresh.
+type TokenRes struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// ValidatePassword is the password validation tag: PasswordMinLength to PasswordMaxLength bytes with at
+// least a letter and a digit.
+func ValidatePassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	if len(password) < PasswordMinLength || len(password) > PasswordMaxLength {
+		return false
+	}
+
+	var letter, digit bool
+	for _, r := range password {
+		letter = letter || unicode.IsLetter(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+
+	return letter && digit
+}
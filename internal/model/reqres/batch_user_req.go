@@ -0,0 +1,25 @@
+package reqres
+
+// The operations of a user batch.
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// BatchUserReq applies several user operations in one request, in order. An atomic batch applies all of
+// them in a single transaction or none of them, otherwise each operation succeeds or fails on its own.
+type BatchUserReq struct {
+	Atomic     bool             `json:"atomic"`
+	Operations []BatchUserOpReq `json:"operations"`
+}
+
+// BatchUserOpReq is one operation of a batch: the create of User, the full update of the user ID with
+// User or the soft delete of the user ID. Version conditions an update or a delete like If-Match does,
+// 0 applies it to any version.
+type BatchUserOpReq struct {
+	Op      string         `json:"op" validate:"oneof=create update delete"`
+	ID      int64          `json:"id,omitempty" validate:"required_unless=Op create,min=0"`
+	Version int64          `json:"version,omitempty" validate:"min=0"`
+	User    *CreateUserReq `json:"user,omitempty" validate:"required_unless=Op delete"`
+}
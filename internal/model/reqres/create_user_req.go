@@ -1,7 +1,16 @@
 package reqres
 
 type CreateUserReq struct {
-	FirstName string `json:"first_name,omitempty" validate:"required"`
-	LastName  string `json:"last_name,omitempty" `
+	FirstName string `json:"first_name,omitempty" validate:"required,max=40"`
+	LastName  string `json:"last_name,omitempty" validate:"omitempty,max=40"`
 	Email     string `json:"email,omitempty" validate:"email"`
+	// Password lets the user log in, a user created without one cannot log in.
+	Password string `json:"password,omitempty" validate:"omitempty,password"`
+}
+
+// CreateUserWithPostReq is the body of a user create, which may carry the first post of the user to create
+// both of them at once.
+type CreateUserWithPostReq struct {
+	CreateUserReq
+	FirstPost *FirstPostReq `json:"first_post,omitempty"`
 }
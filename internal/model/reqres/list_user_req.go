@@ -0,0 +1,39 @@
+package reqres
+
+const DefaultUserSort = "created_at"
+
+// ListUserReq is the query model of the user list, Sort is a whitelisted field name prefixed with "-" for
+// descending order. Soft deleted users are left out unless IncludeDeleted is set.
+type ListUserReq struct {
+	PageReq
+	Email     string `json:"email" validate:"omitempty,max=40"`
+	FirstName string `json:"first_name" validate:"omitempty,max=40"`
+	LastName  string `json:"last_name" validate:"omitempty,max=40"`
+	Q         string `json:"q" validate:"omitempty,max=100"`
+	Sort      string `json:"sort" validate:"omitempty,oneof=id -id first_name -first_name last_name -last_name email -email created_at -created_at updated_at -updated_at"`
+
+	IncludeDeleted bool `json:"include_deleted"`
+}
+
+// ExportUserReq is the query model of the user export, the filters of ListUserReq without its paging and
+// sort since the users are exported by id.
+type ExportUserReq struct {
+	Format    string `json:"format" validate:"oneof=csv ndjson"`
+	Email     string `json:"email" validate:"omitempty,max=40"`
+	FirstName string `json:"first_name" validate:"omitempty,max=40"`
+	LastName  string `json:"last_name" validate:"omitempty,max=40"`
+	Q         string `json:"q" validate:"omitempty,max=100"`
+
+	IncludeDeleted bool `json:"include_deleted"`
+}
+
+// ListUserReq returns the user list query with the filters of the export.
+func (r *ExportUserReq) ListUserReq() *ListUserReq {
+	return &ListUserReq{
+		Email:          r.Email,
+		FirstName:      r.FirstName,
+		LastName:       r.LastName,
+		Q:              r.Q,
+		IncludeDeleted: r.IncludeDeleted,
+	}
+}
@@ -0,0 +1,51 @@
+package reqres
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// NullString is a string field of a JSON merge patch (RFC 7396). Set reports whether the field was present
+// in the document at all, Valid whether it held a value rather than an explicit null.
+type NullString struct {
+	String string
+	Valid  bool
+	Set    bool
+}
+
+func NewNullString(s string) NullString {
+	return NullString{String: s, Valid: true, Set: true}
+}
+
+func (n *NullString) UnmarshalJSON(b []byte) error {
+	n.Set = true
+	if string(b) == "null" {
+		n.String, n.Valid = "", false
+		return nil
+	}
+
+	err := json.Unmarshal(b, &n.String)
+	if err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+func (n NullString) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(n.String)
+}
+
+// Value implements driver.Valuer, it also lets the validator check the held string.
+func (n NullString) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+
+	return n.String, nil
+}
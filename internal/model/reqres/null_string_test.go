@@ -0,0 +1,39 @@
+package reqres_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go-rest-api-boilerplate/internal/model/reqres"
+)
+
+func TestNullString_UnmarshalJSON(t *testing.T) {
+	cases := []struct {
+		doc      string
+		expected reqres.PatchUserReq
+	}{
+		{doc: `{}`, expected: reqres.PatchUserReq{}},
+		{doc: `{"last_name":"due"}`, expected: reqres.PatchUserReq{LastName: reqres.NewNullString("due")}},
+		{doc: `{"last_name":null}`, expected: reqres.PatchUserReq{LastName: reqres.NullString{Set: true}}},
+		{doc: `{"last_name":""}`, expected: reqres.PatchUserReq{LastName: reqres.NewNullString("")}},
+	}
+
+	for _, c := range cases {
+		var req reqres.PatchUserReq
+		err := json.Unmarshal([]byte(c.doc), &req)
+		assert.NoError(t, err, c.doc)
+		assert.Equal(t, c.expected, req, c.doc)
+	}
+
+	t.Run("error", func(t *testing.T) {
+		var req reqres.PatchUserReq
+		err := json.Unmarshal([]byte(`{"last_name":1}`), &req)
+		assert.Error(t, err)
+	})
+}
+
+func TestPatchUserReq_IsEmpty(t *testing.T) {
+	assert.True(t, (&reqres.PatchUserReq{}).IsEmpty())
+	assert.False(t, (&reqres.PatchUserReq{LastName: reqres.NullString{Set: true}}).IsEmpty())
+}
@@ -0,0 +1,53 @@
+package reqres
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// PageReq is a page request, either offset based or keyset based using an opaque cursor.
+type PageReq struct {
+	Limit     int    `json:"limit" validate:"min=1,max=100"`
+	Offset    int    `json:"offset" validate:"min=0,excluded_with=Cursor"`
+	Cursor    string `json:"cursor"`
+	WithTotal bool   `json:"with_total"`
+}
+
+type PageRes struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// Cursor points at the last row of a page, Value is the sort key of that row and ID the tie breaker.
+// Sort is the sort the cursor was issued for, a cursor is only valid with the same sort.
+type Cursor struct {
+	Value string `json:"v"`
+	ID    int64  `json:"id"`
+	Sort  string `json:"s,omitempty"`
+}
+
+func EncodeCursor(c Cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Cursor
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
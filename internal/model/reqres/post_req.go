@@ -0,0 +1,26 @@
+package reqres
+
+type CreatePostReq struct {
+	UserID  int64  `json:"user_id,omitempty" validate:"required,min=1"`
+	Title   string `json:"title,omitempty" validate:"required,max=120"`
+	Content string `json:"content,omitempty" validate:"required"`
+}
+
+// FirstPostReq is the post created along with its author, see CreateUserWithPostReq.
+type FirstPostReq struct {
+	Title   string `json:"title,omitempty" validate:"required,max=120"`
+	Content string `json:"content,omitempty" validate:"required"`
+}
+
+// UpdatePostReq is the full replacement of a post (PUT), the author of a post cannot change.
+type UpdatePostReq struct {
+	Title   string `json:"title,omitempty" validate:"required,max=120"`
+	Content string `json:"content,omitempty" validate:"required"`
+}
+
+// ListPostReq is the query model of the post list, newest posts first. UserID narrows the list to the
+// posts of one author.
+type ListPostReq struct {
+	PageReq
+	UserID int64 `json:"user_id" validate:"omitempty,min=1"`
+}
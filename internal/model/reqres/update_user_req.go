@@ -1,3 +1,37 @@
 package reqres
 
-type UpdateUserReq CreateUserReq
+import "github.com/go-playground/validator/v10"
+
+// UpdateUserReq is the full replacement of a user (PUT). The password is not part of it, a password sent
+// with it is rejected as an unknown field.
+type UpdateUserReq struct {
+	FirstName string `json:"first_name,omitempty" validate:"required,max=40"`
+	LastName  string `json:"last_name,omitempty" validate:"omitempty,max=40"`
+	Email     string `json:"email,omitempty" validate:"email"`
+}
+
+// PatchUserReq is a JSON merge patch (RFC 7396) of a user: a field left out of the document is not
+// touched, an explicit null clears a nullable field.
+type PatchUserReq struct {
+	FirstName NullString `json:"first_name" validate:"omitempty,max=40"`
+	LastName  NullString `json:"last_name" validate:"omitempty,max=40"`
+	Email     NullString `json:"email" validate:"omitempty,email"`
+}
+
+// IsEmpty reports whether the patch leaves the user untouched.
+func (p *PatchUserReq) IsEmpty() bool {
+	return !p.FirstName.Set && !p.LastName.Set && !p.Email.Set
+}
+
+// ValidatePatchUserReq rejects an explicit null or empty value on the fields a user cannot go without.
+func ValidatePatchUserReq(sl validator.StructLevel) {
+	req := sl.Current().Interface().(PatchUserReq)
+
+	if req.FirstName.Set && (!req.FirstName.Valid || req.FirstName.String == "") {
+		sl.ReportError(req.FirstName, "first_name", "FirstName", "required", "")
+	}
+
+	if req.Email.Set && (!req.Email.Valid || req.Email.String == "") {
+		sl.ReportError(req.Email, "email", "Email", "required", "")
+	}
+}
@@ -0,0 +1,21 @@
+package reqres
+
+import (
+	"fmt"
+
+	"go-rest-api-boilerplate/pkg/validation"
+)
+
+// NewValidator builds the validator of the request models, with the rules they need registered. It is
+// shared by the handlers and the commands.
+func NewValidator() *validation.Validator {
+	validate := validation.New()
+	validate.RegisterValuer(NullString{})
+	validate.RegisterStructValidation(ValidatePatchUserReq, PatchUserReq{})
+	validate.RegisterValidation("password", ValidatePassword, map[string]string{
+		"en": fmt.Sprintf("{0} must be %d to %d characters long with at least a letter and a digit", PasswordMinLength, PasswordMaxLength),
+		"id": fmt.Sprintf("{0} harus %d sampai %d karakter dengan minimal satu huruf dan satu angka", PasswordMinLength, PasswordMaxLength),
+	})
+
+	return validate
+}
@@ -0,0 +1,272 @@
+// Package userfile reads and writes the CSV and NDJSON files users are exported to and imported from.
+package userfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/model/reqres"
+)
+
+// The formats of a user file.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// ContentTypes is the media type of each format.
+var ContentTypes = map[string]string{
+	FormatCSV:    "text/csv; charset=utf-8",
+	FormatNDJSON: "application/x-ndjson",
+}
+
+// ErrUnknownFormat rejects a format other than FormatCSV and FormatNDJSON.
+var ErrUnknownFormat = errors.New("format must be csv or ndjson")
+
+// columns are the CSV columns of an exported user. An import only reads the columns of
+// reqres.CreateUserReq, so an export can be imported as is.
+var columns = []string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}
+
+// maxLineSize caps an NDJSON line, a longer line fails the import.
+const maxLineSize = 1 << 20
+
+// Writer writes the users of an export, buffered until Flush.
+type Writer struct {
+	csv  *csv.Writer
+	json *json.Encoder
+	buf  *bufio.Writer
+}
+
+// NewWriter returns a Writer of format to w, the CSV header is written right away.
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	switch format {
+	case FormatCSV:
+		writer := &Writer{csv: csv.NewWriter(w)}
+		return writer, writer.csv.Write(columns)
+	case FormatNDJSON:
+		buf := bufio.NewWriter(w)
+		return &Writer{json: json.NewEncoder(buf), buf: buf}, nil
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+// Write writes the user as a CSV record or an NDJSON line.
+func (w *Writer) Write(user *domain.User) error {
+	if w.json != nil {
+		return w.json.Encode(user)
+	}
+
+	deletedAt := ""
+	if user.DeletedAt != nil {
+		deletedAt = user.DeletedAt.Format(time.RFC3339Nano)
+	}
+
+	return w.csv.Write([]string{
+		strconv.FormatInt(user.ID, 10),
+		user.FirstName,
+		user.LastName,
+		user.Email,
+		user.CreatedAt.Format(time.RFC3339Nano),
+		user.UpdatedAt.Format(time.RFC3339Nano),
+		deletedAt,
+		strconv.FormatInt(user.Version, 10),
+	})
+}
+
+// Flush writes the buffered users to the underlying writer.
+func (w *Writer) Flush() error {
+	if w.json != nil {
+		return w.buf.Flush()
+	}
+
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// Row is a row of an import file at Line. Err is set when the row could not be decoded into User.
+type Row struct {
+	Line int
+	User reqres.CreateUserReq
+	Err  error
+
+	record []string
+	raw    []byte
+}
+
+// Reader reads the rows of an import file. A CSV file starts with a header naming its columns, the
+// columns other than those of reqres.CreateUserReq are ignored, like the unknown fields of an NDJSON line.
+type Reader struct {
+	csv    *csv.Reader
+	header []string
+	lines  *bufio.Scanner
+	line   int
+}
+
+// NewReader returns a Reader of format from r, the CSV header is read right away.
+func NewReader(r io.Reader, format string) (*Reader, error) {
+	switch format {
+	case FormatCSV:
+		reader := &Reader{csv: csv.NewReader(r)}
+		header, err := reader.csv.Read()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("read csv header: %w", err)
+		}
+		for _, column := range header {
+			reader.header = append(reader.header, strings.ToLower(strings.TrimSpace(column)))
+		}
+		reader.csv.FieldsPerRecord = len(header)
+		return reader, nil
+	case FormatNDJSON:
+		lines := bufio.NewScanner(r)
+		lines.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+		return &Reader{lines: lines}, nil
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+// Read returns the next row, io.EOF after the last one. A row that cannot be decoded is returned with its
+// Err set, the error is only set when the file itself cannot be read further.
+func (r *Reader) Read() (*Row, error) {
+	if r.lines != nil {
+		return r.readLine()
+	}
+
+	record, err := r.csv.Read()
+	var parseErr *csv.ParseError
+	if err != nil && !errors.As(err, &parseErr) {
+		return nil, err
+	}
+
+	row := &Row{Err: err, record: record}
+	if parseErr != nil {
+		row.Line = parseErr.StartLine
+		return row, nil
+	}
+	row.Line, _ = r.csv.FieldPos(0)
+
+	for i, column := range r.header {
+		switch column {
+		case "first_name":
+			row.User.FirstName = record[i]
+		case "last_name":
+			row.User.LastName = record[i]
+		case "email":
+			row.User.Email = record[i]
+		case "password":
+			row.User.Password = record[i]
+		}
+	}
+
+	return row, nil
+}
+
+func (r *Reader) readLine() (*Row, error) {
+	for r.lines.Scan() {
+		r.line++
+		line := r.lines.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		row := &Row{Line: r.line, raw: append([]byte(nil), line...)}
+		row.Err = json.Unmarshal(line, &row.User)
+		return row, nil
+	}
+
+	if err := r.lines.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
+
+// RejectWriter writes the rejected rows of an import file in the format of the file, each with its line
+// and the reason it was rejected. Those columns are ignored by an import, a fixed reject file can be
+// imported again.
+type RejectWriter struct {
+	reader *Reader
+	csv    *csv.Writer
+	buf    *bufio.Writer
+	keep   []int
+}
+
+// NewRejectWriter returns the RejectWriter to w of the rows read by r.
+func NewRejectWriter(w io.Writer, r *Reader) *RejectWriter {
+	if r.lines != nil {
+		return &RejectWriter{reader: r, buf: bufio.NewWriter(w)}
+	}
+
+	return &RejectWriter{reader: r, csv: csv.NewWriter(w)}
+}
+
+// Reject writes the row with the reason it was rejected.
+func (w *RejectWriter) Reject(row *Row, reason error) error {
+	if w.csv != nil {
+		return w.rejectRecord(row, reason)
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(row.raw, &fields); err != nil {
+		raw, _ := json.Marshal(string(row.raw))
+		fields = map[string]json.RawMessage{"raw": raw}
+	}
+	fields["line"], _ = json.Marshal(row.Line)
+	fields["error"], _ = json.Marshal(reason.Error())
+
+	line, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	_, err = w.buf.Write(append(line, '\n'))
+	return err
+}
+
+// rejectRecord writes the line and error columns first, an earlier line or error column of the file is
+// dropped so rejecting a row of a reject file does not repeat them.
+func (w *RejectWriter) rejectRecord(row *Row, reason error) error {
+	if w.keep == nil {
+		header := []string{"line", "error"}
+		w.keep = []int{}
+		for i, column := range w.reader.header {
+			if column != "line" && column != "error" {
+				w.keep = append(w.keep, i)
+				header = append(header, column)
+			}
+		}
+		if err := w.csv.Write(header); err != nil {
+			return err
+		}
+	}
+
+	record := []string{strconv.Itoa(row.Line), reason.Error()}
+	for _, i := range w.keep {
+		value := ""
+		if i < len(row.record) {
+			value = row.record[i]
+		}
+		record = append(record, value)
+	}
+
+	return w.csv.Write(record)
+}
+
+// Flush writes the buffered rejected rows to the underlying writer.
+func (w *RejectWriter) Flush() error {
+	if w.csv == nil {
+		return w.buf.Flush()
+	}
+
+	w.csv.Flush()
+	return w.csv.Error()
+}
@@ -0,0 +1,139 @@
+package userfile_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/model/userfile"
+)
+
+func readAll(t *testing.T, r *userfile.Reader) []*userfile.Row {
+	var rows []*userfile.Row
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		assert.NoError(t, err)
+		rows = append(rows, row)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	created := time.Date(2022, 9, 1, 10, 0, 0, 0, time.UTC)
+	user := &domain.User{ID: 1, FirstName: "john", LastName: "due, jr", Email: "john@email.local", CreatedAt: created, UpdatedAt: created, Version: 2}
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, err := userfile.NewWriter(&buf, userfile.FormatCSV)
+		assert.NoError(t, err)
+		assert.NoError(t, w.Write(user))
+		assert.NoError(t, w.Flush())
+
+		assert.Equal(t, "id,first_name,last_name,email,created_at,updated_at,deleted_at,version\n"+
+			`1,john,"due, jr",john@email.local,2022-09-01T10:00:00Z,2022-09-01T10:00:00Z,,2`+"\n", buf.String())
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, err := userfile.NewWriter(&buf, userfile.FormatNDJSON)
+		assert.NoError(t, err)
+		assert.NoError(t, w.Write(user))
+		assert.Empty(t, buf.String())
+		assert.NoError(t, w.Flush())
+
+		assert.Contains(t, buf.String(), `"email":"john@email.local"`)
+		assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := userfile.NewWriter(&bytes.Buffer{}, "xml")
+		assert.ErrorIs(t, err, userfile.ErrUnknownFormat)
+	})
+}
+
+func TestReader(t *testing.T) {
+	t.Run("csv", func(t *testing.T) {
+		file := "ID,First_Name,email,password\n" +
+			"1,john,john@email.local,secret123\n" +
+			"2,\"jane\n\",jane@email.local\n" +
+			"3,bob,bob@email.local,\n"
+		r, err := userfile.NewReader(strings.NewReader(file), userfile.FormatCSV)
+		assert.NoError(t, err)
+
+		rows := readAll(t, r)
+		assert.Len(t, rows, 3)
+		assert.Equal(t, 2, rows[0].Line)
+		assert.NoError(t, rows[0].Err)
+		assert.Equal(t, "john", rows[0].User.FirstName)
+		assert.Equal(t, "john@email.local", rows[0].User.Email)
+		assert.Equal(t, "secret123", rows[0].User.Password)
+
+		assert.Equal(t, 3, rows[1].Line)
+		assert.Error(t, rows[1].Err)
+
+		assert.Equal(t, 5, rows[2].Line)
+		assert.NoError(t, rows[2].Err)
+		assert.Equal(t, "bob", rows[2].User.FirstName)
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		file := `{"first_name":"john","email":"john@email.local","id":7}` + "\n\n" +
+			`{"first_name":` + "\n" +
+			`{"first_name":"jane","email":"jane@email.local","password":"secret123"}`
+		r, err := userfile.NewReader(strings.NewReader(file), userfile.FormatNDJSON)
+		assert.NoError(t, err)
+
+		rows := readAll(t, r)
+		assert.Len(t, rows, 3)
+		assert.Equal(t, 1, rows[0].Line)
+		assert.Equal(t, "john", rows[0].User.FirstName)
+		assert.Equal(t, 3, rows[1].Line)
+		assert.Error(t, rows[1].Err)
+		assert.Equal(t, 4, rows[2].Line)
+		assert.Equal(t, "secret123", rows[2].User.Password)
+	})
+}
+
+func TestRejectWriter(t *testing.T) {
+	t.Run("csv", func(t *testing.T) {
+		file := "line,error,first_name,email\n" +
+			"2,old error,,john@email.local\n"
+		r, err := userfile.NewReader(strings.NewReader(file), userfile.FormatCSV)
+		assert.NoError(t, err)
+		rows := readAll(t, r)
+
+		var buf bytes.Buffer
+		w := userfile.NewRejectWriter(&buf, r)
+		assert.NoError(t, w.Reject(rows[0], errors.New("first_name is required")))
+		assert.NoError(t, w.Flush())
+
+		assert.Equal(t, "line,error,first_name,email\n"+
+			"2,first_name is required,,john@email.local\n", buf.String())
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		file := `{"email":"john@email.local"}` + "\n" + `not json`
+		r, err := userfile.NewReader(strings.NewReader(file), userfile.FormatNDJSON)
+		assert.NoError(t, err)
+		rows := readAll(t, r)
+
+		var buf bytes.Buffer
+		w := userfile.NewRejectWriter(&buf, r)
+		assert.NoError(t, w.Reject(rows[0], errors.New("first_name is required")))
+		assert.NoError(t, w.Reject(rows[1], rows[1].Err))
+		assert.NoError(t, w.Flush())
+
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		assert.Len(t, lines, 2)
+		assert.JSONEq(t, `{"email":"john@email.local","line":1,"error":"first_name is required"}`, lines[0])
+		assert.Contains(t, lines[1], `"raw":"not json"`)
+		assert.Contains(t, lines[1], `"line":2`)
+	})
+}
@@ -44,7 +44,7 @@ func NewServer() *server {
 }
 
 func (s *server) Run() {
-	c := make(chan os.Signal)
+	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
 
 	srv := http.Server{Handler: s.handler, Addr: s.address}
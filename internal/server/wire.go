@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"github.com/google/wire"
+	"go-rest-api-boilerplate/internal/db"
 	httpTransport "go-rest-api-boilerplate/internal/transport/http"
 	"go-rest-api-boilerplate/internal/usecase/repository"
 	"go-rest-api-boilerplate/internal/usecase/service"
@@ -18,14 +19,36 @@ var userSet = wire.NewSet(
 	service.NewUserService,
 )
 
-//var postSet = wire.NewSet(
-//	repository.NewPostRepository,
-//	service.NewPostService,
-//)
+var postSet = wire.NewSet(
+	repository.NewPostRepository,
+	service.NewPostService,
+)
+
+var apiKeySet = wire.NewSet(
+	repository.NewApiKeyRepository,
+	service.NewApiKeyService,
+)
+
+var authSet = wire.NewSet(
+	repository.NewRefreshTokenRepository,
+	service.NewAuthConfig,
+	service.NewAuthService,
+)
+
+var idempotencySet = wire.NewSet(
+	repository.NewIdempotencyRepository,
+	service.NewIdempotencyConfig,
+	service.NewIdempotencyService,
+)
 
-func InitializedHandlerServer(db *sql.DB) http.Handler {
+func InitializedHandlerServer(conn *sql.DB) http.Handler {
 	wire.Build(
+		db.NewTxManager,
 		userSet,
+		postSet,
+		apiKeySet,
+		authSet,
+		idempotencySet,
 		httpTransport.NewHandler,
 	)
 	return nil
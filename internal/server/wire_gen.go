@@ -9,6 +9,7 @@ package server
 import (
 	"database/sql"
 	"github.com/google/wire"
+	"go-rest-api-boilerplate/internal/db"
 	http2 "go-rest-api-boilerplate/internal/transport/http"
 	"go-rest-api-boilerplate/internal/usecase/repository"
 	"go-rest-api-boilerplate/internal/usecase/service"
@@ -17,13 +18,32 @@ import (
 
 // Injectors from wire.go:
 
-func InitializedHandlerServer(db *sql.DB) http.Handler {
-	userRepository := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepository)
-	handler := http2.NewHandler(userService)
+func InitializedHandlerServer(conn *sql.DB) http.Handler {
+	userRepository := repository.NewUserRepository(conn)
+	postRepository := repository.NewPostRepository(conn)
+	txManager := db.NewTxManager(conn)
+	userService := service.NewUserService(userRepository, postRepository, txManager)
+	postService := service.NewPostService(postRepository, userRepository)
+	apiKeyRepository := repository.NewApiKeyRepository(conn)
+	apiKeyService := service.NewApiKeyService(apiKeyRepository)
+	refreshTokenRepository := repository.NewRefreshTokenRepository(conn)
+	authConfig := service.NewAuthConfig()
+	authService := service.NewAuthService(userRepository, refreshTokenRepository, txManager, authConfig)
+	idempotencyRepository := repository.NewIdempotencyRepository(conn)
+	idempotencyConfig := service.NewIdempotencyConfig()
+	idempotencyService := service.NewIdempotencyService(idempotencyRepository, idempotencyConfig)
+	handler := http2.NewHandler(userService, postService, apiKeyService, authService, idempotencyService)
 	return handler
 }
 
 // wire.go:
 
 var userSet = wire.NewSet(repository.NewUserRepository, service.NewUserService)
+
+var postSet = wire.NewSet(repository.NewPostRepository, service.NewPostService)
+
+var apiKeySet = wire.NewSet(repository.NewApiKeyRepository, service.NewApiKeyService)
+
+var authSet = wire.NewSet(repository.NewRefreshTokenRepository, service.NewAuthConfig, service.NewAuthService)
+
+var idempotencySet = wire.NewSet(repository.NewIdempotencyRepository, service.NewIdempotencyConfig, service.NewIdempotencyService)
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/model/reqres"
+	"go-rest-api-boilerplate/pkg/httputil"
+	"go-rest-api-boilerplate/pkg/validation"
+)
+
+type authHandler struct {
+	authSvc  domain.AuthService
+	validate *validation.Validator
+}
+
+// NewAuthHandlerRegister registers the token endpoints, they are served without a token and have to be in
+// AUTH_ALLOWLIST.
+func NewAuthHandlerRegister(r *mux.Router, service domain.AuthService, validate *validation.Validator) {
+	handler := authHandler{authSvc: service, validate: validate}
+	v1 := r.PathPrefix("/api/v1").Subrouter()
+	{
+		v1.HandleFunc("/auth/login", handler.Login).Methods(http.MethodPost)
+		v1.HandleFunc("/auth/refresh", handler.Refresh).Methods(http.MethodPost)
+		v1.HandleFunc("/auth/logout", handler.Logout).Methods(http.MethodPost)
+	}
+}
+
+// decode decodes and validates the json payload into req, the error is written when it fails.
+func (h *authHandler) decode(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	err := httputil.DecodeJSON(r, req)
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("error decoding json payload")
+		httputil.RespondWithErr(w, r, err)
+		return false
+	}
+
+	err = h.validate.Struct(req, r.Header.Get("Accept-Language"))
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("error validator")
+		httputil.RespondWithErr(w, r, err)
+		return false
+	}
+
+	return true
+}
+
+// respondWithToken writes the token response, which must never be cached (RFC 6749 5.1).
+func respondWithToken(w http.ResponseWriter, token *reqres.TokenRes) {
+	w.Header().Set("Cache-Control", "no-store")
+	httputil.RespondWithJSON(w, http.StatusOK, httputil.ApiResponse{
+		Error:   false,
+		Message: "OK",
+		Data:    token,
+	})
+}
+
+func (h *authHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var loginReq reqres.LoginReq
+	if !h.decode(w, r, &loginReq) {
+		return
+	}
+
+	token, err := h.authSvc.Login(r.Context(), &loginReq)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	respondWithToken(w, token)
+}
+
+func (h *authHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var refreshReq reqres.RefreshTokenReq
+	if !h.decode(w, r, &refreshReq) {
+		return
+	}
+
+	token, err := h.authSvc.Refresh(r.Context(), &refreshReq)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	respondWithToken(w, token)
+}
+
+func (h *authHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var logoutReq reqres.RefreshTokenReq
+	if !h.decode(w, r, &logoutReq) {
+		return
+	}
+
+	err := h.authSvc.Logout(r.Context(), &logoutReq)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	httputil.RespondWithJSON(w, http.StatusOK, httputil.ApiResponse{
+		Error:   false,
+		Message: "OK",
+		Data:    nil,
+	})
+}
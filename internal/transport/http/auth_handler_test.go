@@ -0,0 +1,152 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go-rest-api-boilerplate/internal/domain/mocks"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/model/reqres"
+	"go-rest-api-boilerplate/pkg/httputil"
+)
+
+func TestAuthHandler_Login(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockAuthSvc := mocks.NewAuthService(t)
+		mockAuthSvc.On("Login", mock.Anything, &reqres.LoginReq{Email: "john@m.co", Password: "secret123"}).
+			Return(&reqres.TokenRes{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 900, RefreshToken: "refresh"}, nil)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"john@m.co","password":"secret123"}`))
+
+		handler := authHandler{authSvc: mockAuthSvc, validate: testValidator}
+		handler.Login(w, req)
+
+		var response httputil.ApiResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "access", response.Data.(map[string]interface{})["access_token"])
+		assert.Equal(t, "refresh", response.Data.(map[string]interface{})["refresh_token"])
+	})
+
+	t.Run("error:validation", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"john"}`))
+
+		handler := authHandler{authSvc: mocks.NewAuthService(t), validate: testValidator}
+		handler.Login(w, req)
+
+		var problem httputil.Problem
+		json.NewDecoder(w.Body).Decode(&problem)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Len(t, problem.Errors, 2)
+	})
+
+	t.Run("error:invalid credentials", func(t *testing.T) {
+		mockAuthSvc := mocks.NewAuthService(t)
+		mockAuthSvc.On("Login", mock.Anything, mock.AnythingOfType("*reqres.LoginReq")).
+			Return(nil, fmt.Errorf("%w: invalid email or password", modelErr.ErrUnauthorized))
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"john@m.co","password":"wrong"}`))
+
+		handler := authHandler{authSvc: mockAuthSvc, validate: testValidator}
+		handler.Login(w, req)
+
+		var problem httputil.Problem
+		json.NewDecoder(w.Body).Decode(&problem)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, httputil.CodeUnauthorized, problem.Code)
+	})
+}
+
+func TestAuthHandler_Refresh(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockAuthSvc := mocks.NewAuthService(t)
+		mockAuthSvc.On("Refresh", mock.Anything, &reqres.RefreshTokenReq{RefreshToken: "refresh"}).
+			Return(&reqres.TokenRes{AccessToken: "access", TokenType: "Bearer", RefreshToken: "rotated"}, nil)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", strings.NewReader(`{"refresh_token":"refresh"}`))
+
+		handler := authHandler{authSvc: mockAuthSvc, validate: testValidator}
+		handler.Refresh(w, req)
+
+		var response httputil.ApiResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "rotated", response.Data.(map[string]interface{})["refresh_token"])
+	})
+
+	t.Run("error:reuse", func(t *testing.T) {
+		mockAuthSvc := mocks.NewAuthService(t)
+		mockAuthSvc.On("Refresh", mock.Anything, mock.AnythingOfType("*reqres.RefreshTokenReq")).
+			Return(nil, fmt.Errorf("%w: refresh token reuse detected", modelErr.ErrUnauthorized))
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", strings.NewReader(`{"refresh_token":"refresh"}`))
+
+		handler := authHandler{authSvc: mockAuthSvc, validate: testValidator}
+		handler.Refresh(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockAuthSvc := mocks.NewAuthService(t)
+		mockAuthSvc.On("Logout", mock.Anything, &reqres.RefreshTokenReq{RefreshToken: "refresh"}).Return(nil)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", strings.NewReader(`{"refresh_token":"refresh"}`))
+
+		handler := authHandler{authSvc: mockAuthSvc, validate: testValidator}
+		handler.Logout(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("error:bad payload", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", strings.NewReader(`{`))
+
+		handler := authHandler{authSvc: mocks.NewAuthService(t), validate: testValidator}
+		handler.Logout(w, req)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	cases := []struct {
+		password string
+		valid    bool
+	}{
+		{password: "secret123", valid: true},
+		{password: "s3cret", valid: false},
+		{password: "secretsecret", valid: false},
+		{password: "12345678", valid: false},
+		{password: strings.Repeat("a1", 37), valid: false},
+	}
+
+	for _, c := range cases {
+		err := testValidator.Struct(&reqres.CreateUserReq{FirstName: "john", Email: "john@m.co", Password: c.password}, "")
+		if c.valid {
+			assert.NoError(t, err, c.password)
+			continue
+		}
+
+		var fieldErrorer httputil.FieldErrorer
+		assert.ErrorAs(t, err, &fieldErrorer, c.password)
+		assert.Equal(t, []httputil.FieldError{{
+			Field:   "password",
+			Rule:    "password",
+			Message: "password must be 8 to 72 characters long with at least a letter and a digit",
+		}}, fieldErrorer.FieldErrors(), c.password)
+	}
+}
@@ -2,14 +2,33 @@ package http
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
 	"go-rest-api-boilerplate/config"
 	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/model/reqres"
+	"go-rest-api-boilerplate/internal/transport/http/middleware"
+	"go-rest-api-boilerplate/pkg/httputil"
+	"go-rest-api-boilerplate/pkg/ratelimit"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
 )
 
-func NewHandler(userService domain.UserService) http.Handler {
+// idempotentRoutes are the routes creating resources whose responses are stored for the retries sent with
+// the same Idempotency-Key. The auth routes are left out, their responses carry live tokens.
+var idempotentRoutes = []string{
+	"POST /api/v1/user",
+	"POST /api/v1/user:batch",
+	"POST /api/v1/user/{id}/restore",
+	"POST /api/v1/post",
+}
+
+func NewHandler(userService domain.UserService, postService domain.PostService, apiKeyService domain.ApiKeyService, authService domain.AuthService, idempotencyService domain.IdempotencyService) http.Handler {
+	httputil.SetErrorFormat(httputil.ErrorFormat(config.App.HttpErrorFormat))
+
 	r := mux.NewRouter()
 
 	r.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
@@ -17,10 +36,194 @@ func NewHandler(userService domain.UserService) http.Handler {
 		w.Write([]byte("ok"))
 	})
 
+	proxies, err := httputil.ParseTrustedProxies(config.App.TrustedProxies)
+	if err != nil {
+		log.WithError(err).Fatal("invalid TRUSTED_PROXIES")
+	}
+
 	r.Use(otelmux.Middleware(config.App.ServiceName))
+	r.Use(middleware.RequestID)
+	accessLog := newAccessLogger(proxies)
+	r.Use(accessLog)
+	r.Use(newRecoverer())
+	securityHeaders := newSecurityHeaders()
+	r.Use(securityHeaders)
+	r.Use(newCORS())
+	r.Use(newAuthFailureLimiter(proxies))
+	r.Use(middleware.APIKey(apiKeyService))
+	r.Use(newJWTAuthenticator().Middleware)
+	r.Use(newRateLimiter(proxies))
+	r.Use(newBodyLimit())
+	r.Use(middleware.Idempotency(middleware.IdempotencyConfig{
+		Service:        idempotencyService,
+		Routes:         idempotentRoutes,
+		TrustedProxies: proxies,
+	}))
+
+	validate := reqres.NewValidator()
 
 	//Registered handler
-	NewUserHandlerRegister(r, userService)
+	NewUserHandlerRegister(r, userService, validate)
+	NewPostHandlerRegister(r, postService, validate)
+	NewAuthHandlerRegister(r, authService, validate)
+	registerOptionsRoutes(r)
+
+	// Unmatched requests skip the router middlewares, they are still given a request id and logged.
+	r.NotFoundHandler = middleware.RequestID(accessLog(securityHeaders(http.NotFoundHandler())))
+	r.MethodNotAllowedHandler = middleware.RequestID(accessLog(securityHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))))
 
 	return r
 }
+
+// newJWTAuthenticator builds the bearer token authentication from the JWT_* and AUTH_ALLOWLIST config.
+func newJWTAuthenticator() *middleware.JWTAuthenticator {
+	cfg := middleware.JWTConfig{
+		HMACSecret: []byte(config.App.JwtHmacSecret),
+		Issuer:     config.App.JwtIssuer,
+		Audience:   config.App.JwtAudience,
+		Allowlist:  config.App.AuthAllowlist,
+	}
+	if config.App.JwtJwksUrl != "" || config.App.JwtJwksFile != "" {
+		cfg.JWKS = middleware.NewJWKS(config.App.JwtJwksUrl, config.App.JwtJwksFile, config.App.JwtJwksCacheTTL)
+	}
+
+	return middleware.NewJWTAuthenticator(cfg)
+}
+
+// newRateLimiter builds the in-memory rate limiting from the RATE_LIMIT_* config, the anonymous clients
+// are told apart by the address forwarded by the trusted proxies.
+func newRateLimiter(proxies httputil.TrustedProxies) mux.MiddlewareFunc {
+	routes, err := middleware.ParseRateLimitRoutes(config.App.RateLimitRoutes)
+	if err != nil {
+		log.WithError(err).Fatal("invalid RATE_LIMIT_ROUTES")
+	}
+	if config.App.RateLimitRequests > 0 && config.App.RateLimitPeriod <= 0 {
+		log.Fatal("RATE_LIMIT_PERIOD must be a positive duration")
+	}
+
+	return middleware.RateLimit(middleware.RateLimitConfig{
+		Default:        ratelimit.Limit{Requests: config.App.RateLimitRequests, Period: config.App.RateLimitPeriod},
+		Routes:         routes,
+		Store:          ratelimit.NewMemoryStore(),
+		TrustedProxies: proxies,
+	})
+}
+
+// newAuthFailureLimiter builds the limit of the failed authentications per client IP from the
+// RATE_LIMIT_AUTH_FAILURES config.
+func newAuthFailureLimiter(proxies httputil.TrustedProxies) mux.MiddlewareFunc {
+	var limit ratelimit.Limit
+	if config.App.RateLimitAuthFailures != "" {
+		var err error
+		limit, err = ratelimit.ParseLimit(config.App.RateLimitAuthFailures)
+		if err != nil {
+			log.WithError(err).Fatal("invalid RATE_LIMIT_AUTH_FAILURES")
+		}
+	}
+
+	return middleware.AuthFailureLimit(middleware.AuthFailureLimitConfig{
+		Limit:          limit,
+		Store:          ratelimit.NewMemoryStore(),
+		TrustedProxies: proxies,
+	})
+}
+
+// newAccessLogger builds the access log from the ACCESS_LOG_* config.
+func newAccessLogger(proxies httputil.TrustedProxies) mux.MiddlewareFunc {
+	return middleware.AccessLog(middleware.AccessLogConfig{
+		Sample2xx:      config.App.AccessLogSample2xx,
+		Exclude:        config.App.AccessLogExclude,
+		TrustedProxies: proxies,
+	})
+}
+
+// newRecoverer builds the panic recovery, counting the panics with the http.server.panics metric.
+func newRecoverer() mux.MiddlewareFunc {
+	panics, err := global.Meter(config.App.ServiceName).SyncInt64().Counter(
+		"http.server.panics",
+		instrument.WithDescription("Panics recovered while handling HTTP requests"),
+	)
+	if err != nil {
+		log.WithError(err).Error("failed to create the panics counter")
+	}
+
+	return middleware.Recover(middleware.RecoverConfig{Panics: panics})
+}
+
+// newSecurityHeaders builds the security headers from the SECURITY_* config.
+func newSecurityHeaders() mux.MiddlewareFunc {
+	return middleware.SecurityHeaders(middleware.SecurityHeadersConfig{
+		HSTSMaxAge:            config.App.SecurityHstsMaxAge,
+		ReferrerPolicy:        config.App.SecurityReferrerPolicy,
+		ContentSecurityPolicy: config.App.SecurityCsp,
+	})
+}
+
+// newBodyLimit builds the request body size limits from the HTTP_MAX_BODY_SIZE* config.
+func newBodyLimit() mux.MiddlewareFunc {
+	size, err := middleware.ParseSize(config.App.HttpMaxBodySize)
+	if err != nil {
+		log.WithError(err).Fatal("invalid HTTP_MAX_BODY_SIZE")
+	}
+	routes, err := middleware.ParseBodyLimitRoutes(config.App.HttpMaxBodySizeRoutes)
+	if err != nil {
+		log.WithError(err).Fatal("invalid HTTP_MAX_BODY_SIZE_ROUTES")
+	}
+
+	return middleware.BodyLimit(middleware.BodyLimitConfig{Default: size, Routes: routes})
+}
+
+// newCORS builds the CORS handling from the CORS_* config.
+func newCORS() mux.MiddlewareFunc {
+	return middleware.CORS(middleware.CORSConfig{
+		AllowedOrigins:   config.App.CorsAllowedOrigins,
+		AllowedMethods:   config.App.CorsAllowedMethods,
+		AllowedHeaders:   config.App.CorsAllowedHeaders,
+		ExposedHeaders:   config.App.CorsExposedHeaders,
+		AllowCredentials: config.App.CorsAllowCredentials,
+		MaxAge:           config.App.CorsMaxAge,
+	})
+}
+
+// registerOptionsRoutes adds an OPTIONS route to every path served with a restricted set of methods, so
+// the router hands CORS preflights to the middlewares instead of answering 405. A plain OPTIONS request
+// gets the methods of the path in the Allow header.
+func registerOptionsRoutes(r *mux.Router) {
+	var templates []string
+	allowed := map[string][]string{}
+	r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil || route.GetHandler() == nil {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			// A route without methods already matches OPTIONS.
+			allowed[template] = nil
+			return nil
+		}
+		if _, ok := allowed[template]; !ok {
+			templates = append(templates, template)
+		} else if allowed[template] == nil {
+			return nil
+		}
+		allowed[template] = append(allowed[template], methods...)
+		return nil
+	})
+
+	for _, template := range templates {
+		methods := allowed[template]
+		if methods == nil {
+			continue
+		}
+
+		allow := strings.Join(append(methods, http.MethodOptions), ", ")
+		r.Path(template).Methods(http.MethodOptions).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Allow", allow)
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go-rest-api-boilerplate/internal/transport/http/middleware"
+)
+
+func TestRegisterOptionsRoutes(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	r := mux.NewRouter()
+	r.Use(middleware.CORS(middleware.CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST", "PATCH"},
+	}))
+	r.HandleFunc("/healthz", ok)
+	v1 := r.PathPrefix("/api/v1").Subrouter()
+	v1.HandleFunc("/user", ok).Methods(http.MethodPost)
+	v1.HandleFunc("/user", ok).Methods(http.MethodGet)
+	v1.HandleFunc("/user/{id}", ok).Methods(http.MethodPatch)
+	registerOptionsRoutes(r)
+
+	t.Run("preflight", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/api/v1/user/42", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("plain options", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/api/v1/user", nil))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "POST, GET, OPTIONS", w.Header().Get("Allow"))
+	})
+
+	t.Run("other methods still rejected", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/v1/user", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	t.Run("unknown path", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/api/v1/post", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestIdempotentRoutes(t *testing.T) {
+	r := mux.NewRouter()
+	NewUserHandlerRegister(r, nil, testValidator)
+	NewPostHandlerRegister(r, nil, testValidator)
+	NewAuthHandlerRegister(r, nil, testValidator)
+
+	registered := map[string]bool{}
+	r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, _ := route.GetPathTemplate()
+		methods, _ := route.GetMethods()
+		for _, method := range methods {
+			registered[method+" "+template] = true
+		}
+		return nil
+	})
+
+	for _, route := range idempotentRoutes {
+		assert.True(t, registered[route], route)
+		assert.NotContains(t, route, "/auth/", "auth responses carry tokens and must not be stored")
+	}
+}
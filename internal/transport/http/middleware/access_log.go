@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/pkg/httputil"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type AccessLogConfig struct {
+	// Sample2xx is the share, from 0 to 1, of the successful requests logged. Other requests are always logged.
+	Sample2xx float64
+	// Exclude holds the paths never logged, an entry ending with * matches by prefix.
+	Exclude []string
+	// TrustedProxies are believed for the client address they forward.
+	TrustedProxies httputil.TrustedProxies
+	// Logger defaults to the logrus standard logger.
+	Logger *log.Logger
+}
+
+// AccessLog writes a line per request with its method, route template, status, size, latency, client
+// address, user agent, request id and trace id. It must run after RequestID to log the request id.
+func AccessLog(cfg AccessLogConfig) func(http.Handler) http.Handler {
+	if cfg.Logger == nil {
+		cfg.Logger = log.StandardLogger()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if matchPath(cfg.Exclude, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rw := newResponseWriter(w)
+			next.ServeHTTP(rw, r)
+
+			status := rw.Status()
+			if status < http.StatusMultipleChoices && cfg.Sample2xx < 1 && rand.Float64() >= cfg.Sample2xx {
+				return
+			}
+
+			fields := log.Fields{
+				"method":     r.Method,
+				"route":      routeTemplate(r),
+				"status":     status,
+				"bytes":      rw.bytes,
+				"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+				"remote_ip":  cfg.TrustedProxies.ClientIP(r),
+				"user_agent": r.UserAgent(),
+			}
+			if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+				fields["trace_id"] = sc.TraceID().String()
+			}
+
+			cfg.Logger.WithContext(r.Context()).WithFields(fields).Info("http request")
+		})
+	}
+}
+
+// routeTemplate is the path template of the matched route, like /api/v1/user/{id}, so the requests of a
+// route are logged alike whatever their ids. It is empty for an unmatched request.
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+
+	return template
+}
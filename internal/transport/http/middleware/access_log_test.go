@@ -0,0 +1,85 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-rest-api-boilerplate/internal/transport/http/middleware"
+	"go-rest-api-boilerplate/pkg/httputil"
+	"go-rest-api-boilerplate/pkg/requestid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func accessLogRouter(cfg middleware.AccessLogConfig) (*mux.Router, *test.Hook) {
+	logger, hook := test.NewNullLogger()
+	logger.AddHook(requestid.LogHook{})
+	cfg.Logger = logger
+
+	r := mux.NewRouter()
+	r.Use(middleware.RequestID, middleware.AccessLog(cfg))
+	r.HandleFunc("/api/v1/user/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "0" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("hello"))
+	})
+	r.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+
+	return r, hook
+}
+
+func TestAccessLog(t *testing.T) {
+	t.Run("request line", func(t *testing.T) {
+		proxies, err := httputil.ParseTrustedProxies([]string{"10.0.0.0/8"})
+		require.NoError(t, err)
+		r, hook := accessLogRouter(middleware.AccessLogConfig{Sample2xx: 1, TrustedProxies: proxies})
+
+		traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/user/42", nil)
+		req = req.WithContext(trace.ContextWithSpanContext(req.Context(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})))
+		req.RemoteAddr = "10.0.0.2:5000"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+		req.Header.Set("User-Agent", "curl/7.85.0")
+		req.Header.Set(requestid.Header, "req-1")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Len(t, hook.AllEntries(), 1)
+		entry := hook.LastEntry()
+		assert.Equal(t, log.InfoLevel, entry.Level)
+		assert.Equal(t, http.MethodGet, entry.Data["method"])
+		assert.Equal(t, "/api/v1/user/{id}", entry.Data["route"])
+		assert.Equal(t, http.StatusOK, entry.Data["status"])
+		assert.Equal(t, 5, entry.Data["bytes"])
+		assert.Contains(t, entry.Data, "latency_ms")
+		assert.Equal(t, "198.51.100.1", entry.Data["remote_ip"])
+		assert.Equal(t, "curl/7.85.0", entry.Data["user_agent"])
+		assert.Equal(t, "req-1", entry.Data["request_id"])
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry.Data["trace_id"])
+	})
+
+	t.Run("excluded path", func(t *testing.T) {
+		r, hook := accessLogRouter(middleware.AccessLogConfig{Sample2xx: 1, Exclude: []string{"/healthz"}})
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		assert.Empty(t, hook.AllEntries())
+	})
+
+	t.Run("sampled out 2xx", func(t *testing.T) {
+		r, hook := accessLogRouter(middleware.AccessLogConfig{Sample2xx: 0})
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/user/42", nil))
+		assert.Empty(t, hook.AllEntries())
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/user/0", nil))
+		require.Len(t, hook.AllEntries(), 1)
+		assert.Equal(t, http.StatusNotFound, hook.LastEntry().Data["status"])
+		assert.Equal(t, 0, hook.LastEntry().Data["bytes"])
+	})
+}
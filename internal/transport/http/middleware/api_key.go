@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/internal/model/auth"
+	"go-rest-api-boilerplate/pkg/httputil"
+)
+
+// APIKeyHeader carries the API key of a service-to-service caller.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyResolver resolves a plaintext API key to the principal it authenticates.
+type APIKeyResolver interface {
+	Authenticate(ctx context.Context, key string) (*auth.Principal, error)
+}
+
+// APIKey authenticates the requests sending an X-API-Key header and puts the caller in the request context,
+// an invalid key is rejected with 401. Requests without the header are left to the bearer token
+// authentication, which lets an already authenticated request through.
+func APIKey(resolver APIKeyResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(APIKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, err := resolver.Authenticate(r.Context(), key)
+			if err != nil {
+				log.WithContext(r.Context()).WithError(err).Warn("invalid api key")
+				httputil.RespondWithErr(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
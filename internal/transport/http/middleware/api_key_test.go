@@ -0,0 +1,59 @@
+package middleware_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go-rest-api-boilerplate/internal/domain/mocks"
+	"go-rest-api-boilerplate/internal/model/auth"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/transport/http/middleware"
+)
+
+func TestAPIKey(t *testing.T) {
+	jwtAuth := middleware.NewJWTAuthenticator(middleware.JWTConfig{HMACSecret: testSecret})
+	serveKey := func(svc *mocks.ApiKeyService, key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+		if key != "" {
+			r.Header.Set(middleware.APIKeyHeader, key)
+		}
+		middleware.APIKey(svc)(jwtAuth.Middleware(principalHandler)).ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		svc := mocks.NewApiKeyService(t)
+		svc.On("Authenticate", mock.Anything, "rak_abcd1234_secret").Return(&auth.Principal{Subject: "apikey:7"}, nil)
+
+		w := serveKey(svc, "rak_abcd1234_secret")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "apikey:7", w.Body.String())
+	})
+
+	t.Run("error:no key and no token", func(t *testing.T) {
+		w := serveKey(mocks.NewApiKeyService(t), "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, `Bearer realm="api"`, w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("error:invalid key", func(t *testing.T) {
+		svc := mocks.NewApiKeyService(t)
+		svc.On("Authenticate", mock.Anything, "rak_abcd1234_secret").Return(nil, fmt.Errorf("%w: api key is revoked", modelErr.ErrUnauthorized))
+
+		w := serveKey(svc, "rak_abcd1234_secret")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("error:lookup failure", func(t *testing.T) {
+		svc := mocks.NewApiKeyService(t)
+		svc.On("Authenticate", mock.Anything, "rak_abcd1234_secret").Return(nil, fmt.Errorf("connection refused"))
+
+		w := serveKey(svc, "rak_abcd1234_secret")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/internal/model/auth"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/pkg/httputil"
+)
+
+// Require rejects the requests whose principal lacks perm with 403, and the unauthenticated ones with 401.
+func Require(perm auth.Permission) func(http.Handler) http.Handler {
+	return RequireAny(perm)
+}
+
+// RequireAny is Require for the routes granted by any of perms, like a batch whose operations are
+// authorized one by one further down.
+func RequireAny(perms ...auth.Permission) func(http.Handler) http.Handler {
+	names := make([]string, len(perms))
+	for i, perm := range perms {
+		names[i] = string(perm)
+	}
+	required := strings.Join(names, " or ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := auth.FromContext(r.Context())
+			if !ok {
+				httputil.RespondWithErr(w, r, modelErr.ErrUnauthorized)
+				return
+			}
+
+			for _, perm := range perms {
+				if principal.Can(perm) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			log.WithContext(r.Context()).Warnf("principal %s lacks permission %s", principal.Subject, required)
+			httputil.RespondWithErr(w, r, fmt.Errorf("%w: %s is required", modelErr.ErrForbidden, required))
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-rest-api-boilerplate/pkg/httputil"
+)
+
+type BodyLimitConfig struct {
+	// Default is the max body size in bytes of every route without an override, no limit when zero.
+	Default int64
+	// Routes overrides the limit of a route keyed by its method and path template, like "POST /api/v1/user".
+	Routes map[string]int64
+}
+
+// ParseSize parses a size in bytes, with an optional KB, MB or GB suffix (powers of 1024), like "64KB".
+func ParseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, multiplier = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("size %q is not a number of bytes, KB, MB or GB", s)
+	}
+
+	return n * multiplier, nil
+}
+
+// ParseBodyLimitRoutes parses the route overrides written like "POST /api/v1/user=64KB".
+func ParseBodyLimitRoutes(entries []string) (map[string]int64, error) {
+	routes := make(map[string]int64, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, value, ok := strings.Cut(entry, "=")
+		fields := strings.Fields(route)
+		if !ok || len(fields) != 2 {
+			return nil, fmt.Errorf("body limit route %q is not METHOD /path=size", entry)
+		}
+
+		size, err := ParseSize(value)
+		if err != nil {
+			return nil, err
+		}
+		routes[strings.ToUpper(fields[0])+" "+fields[1]] = size
+	}
+
+	return routes, nil
+}
+
+// BodyLimit caps the size of the request body of each route. A request announcing a larger
+// Content-Length is rejected with 413 at once, a larger body is cut and fails the decoding with
+// httputil.ErrBodyTooLarge.
+func BodyLimit(cfg BodyLimitConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := cfg.Default
+			if override, ok := cfg.Routes[routeKey(r)]; ok {
+				limit = override
+			}
+
+			if limit > 0 {
+				if r.ContentLength > limit {
+					httputil.RespondWithErr(w, r, fmt.Errorf("%w: the limit is %d bytes", httputil.ErrBodyTooLarge, limit))
+					return
+				}
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
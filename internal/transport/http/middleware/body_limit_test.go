@@ -0,0 +1,88 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-rest-api-boilerplate/internal/transport/http/middleware"
+	"go-rest-api-boilerplate/pkg/httputil"
+)
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{"512": 512, "512B": 512, "64KB": 64 << 10, "1 mb": 1 << 20, "2GB": 2 << 30, "0": 0}
+	for s, size := range cases {
+		got, err := middleware.ParseSize(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, size, got, s)
+	}
+
+	for _, s := range []string{"", "MB", "-1KB", "1TB"} {
+		_, err := middleware.ParseSize(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestParseBodyLimitRoutes(t *testing.T) {
+	routes, err := middleware.ParseBodyLimitRoutes([]string{"post /api/v1/user=64KB", " "})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"POST /api/v1/user": 64 << 10}, routes)
+
+	_, err = middleware.ParseBodyLimitRoutes([]string{"/api/v1/user=64KB"})
+	assert.Error(t, err)
+	_, err = middleware.ParseBodyLimitRoutes([]string{"POST /api/v1/user=lots"})
+	assert.Error(t, err)
+}
+
+func TestBodyLimit(t *testing.T) {
+	r := mux.NewRouter()
+	r.Use(middleware.BodyLimit(middleware.BodyLimitConfig{
+		Default: 1 << 10,
+		Routes:  map[string]int64{"POST /api/v1/user": 32},
+	}))
+	decode := func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]string
+		if err := httputil.DecodeJSON(r, &v); err != nil {
+			httputil.RespondWithErr(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+	r.HandleFunc("/api/v1/user", decode).Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/post", decode).Methods(http.MethodPost)
+
+	body := `{"name":"` + strings.Repeat("a", 64) + `"}`
+	send := func(path string, chunked bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if chunked {
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("default limit", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, send("/api/v1/post", false).Code)
+	})
+
+	t.Run("route limit by content length", func(t *testing.T) {
+		w := send("/api/v1/user", false)
+
+		var problem httputil.Problem
+		json.NewDecoder(w.Body).Decode(&problem)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Equal(t, httputil.CodeBodyTooLarge, problem.Code)
+	})
+
+	t.Run("route limit while reading", func(t *testing.T) {
+		w := send("/api/v1/user", true)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
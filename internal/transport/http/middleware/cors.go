@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CORSConfig struct {
+	// AllowedOrigins holds the origins allowed to call the API, like "https://app.example.com". An entry
+	// like "https://*.example.com" allows every subdomain, "*" allows any origin.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers readable by the calling scripts.
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long a preflight response may be cached, none is sent when zero.
+	MaxAge time.Duration
+}
+
+// CORS answers the preflight requests (RFC Fetch, CORS protocol) and sets the CORS headers on the
+// responses to the allowed origins. A preflight is never passed to the next handlers, so it must run
+// before the authentication. A preflight of a disallowed origin, method or header gets no CORS header
+// and is rejected by the browser.
+func CORS(cfg CORSConfig) func(http.Handler) http.Handler {
+	allowedMethods := make(map[string]bool, len(cfg.AllowedMethods))
+	for _, m := range cfg.AllowedMethods {
+		allowedMethods[strings.ToUpper(strings.TrimSpace(m))] = true
+	}
+	allowedHeaders := make(map[string]bool, len(cfg.AllowedHeaders))
+	for _, h := range cfg.AllowedHeaders {
+		allowedHeaders[http.CanonicalHeaderKey(strings.TrimSpace(h))] = true
+	}
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+
+	// The origin is echoed, "*" is only sent when any origin is allowed without credentials.
+	anyOrigin := false
+	for _, o := range cfg.AllowedOrigins {
+		anyOrigin = anyOrigin || (strings.TrimSpace(o) == "*" && !cfg.AllowCredentials)
+	}
+	setAllowOrigin := func(w http.ResponseWriter, origin string) {
+		if anyOrigin {
+			origin = "*"
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if cfg.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Origin")
+			allowed := originAllowed(cfg.AllowedOrigins, origin)
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+				requested := r.Header.Get("Access-Control-Request-Headers")
+				if allowed && allowedMethods[r.Header.Get("Access-Control-Request-Method")] && headersAllowed(allowedHeaders, requested) {
+					setAllowOrigin(w, origin)
+					w.Header().Set("Access-Control-Allow-Methods", methods)
+					if requested != "" {
+						w.Header().Set("Access-Control-Allow-Headers", requested)
+					}
+					if cfg.MaxAge > 0 {
+						w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+					}
+				}
+
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			if allowed {
+				setAllowOrigin(w, origin)
+				if exposed != "" {
+					w.Header().Set("Access-Control-Expose-Headers", exposed)
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func originAllowed(allowed []string, origin string) bool {
+	for _, pattern := range allowed {
+		if matchOrigin(pattern, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchOrigin matches origin against an exact origin, "*" or a wildcard subdomain origin like
+// "https://*.example.com", which does not match https://example.com itself.
+func matchOrigin(pattern, origin string) bool {
+	pattern, origin = strings.ToLower(strings.TrimSpace(pattern)), strings.ToLower(origin)
+	if pattern == "*" || pattern == origin {
+		return true
+	}
+
+	prefix, suffix, ok := strings.Cut(pattern, "*")
+	if !ok || !strings.HasPrefix(suffix, ".") || len(origin) <= len(prefix)+len(suffix) ||
+		!strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+
+	subdomain := origin[len(prefix) : len(origin)-len(suffix)]
+	for _, c := range subdomain {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '.') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// headersAllowed reports whether every header of the comma separated Access-Control-Request-Headers is allowed.
+func headersAllowed(allowed map[string]bool, requested string) bool {
+	for _, h := range strings.Split(requested, ",") {
+		h = strings.TrimSpace(h)
+		if h != "" && !allowed[http.CanonicalHeaderKey(h)] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,107 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go-rest-api-boilerplate/internal/transport/http/middleware"
+)
+
+func TestCORS(t *testing.T) {
+	cfg := middleware.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods:   []string{"GET", "POST", "PATCH"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"X-Request-ID", "Location"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+	var called bool
+	handler := middleware.CORS(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+
+	preflight := func(origin, method, headers string) *httptest.ResponseRecorder {
+		called = false
+		r := httptest.NewRequest(http.MethodOptions, "/api/v1/user", nil)
+		r.Header.Set("Origin", origin)
+		r.Header.Set("Access-Control-Request-Method", method)
+		if headers != "" {
+			r.Header.Set("Access-Control-Request-Headers", headers)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("preflight", func(t *testing.T) {
+		w := preflight("https://app.example.com", http.MethodPatch, "authorization, content-type")
+
+		assert.False(t, called)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "GET, POST, PATCH", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "authorization, content-type", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+		assert.Contains(t, w.Header().Values("Vary"), "Origin")
+	})
+
+	t.Run("preflight of a wildcard subdomain", func(t *testing.T) {
+		w := preflight("https://admin.eu.example.org", http.MethodGet, "")
+
+		assert.Equal(t, "https://admin.eu.example.org", w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("rejected preflights", func(t *testing.T) {
+		cases := []struct{ name, origin, method, headers string }{
+			{name: "origin", origin: "https://evil.com", method: http.MethodGet},
+			{name: "wildcard parent domain", origin: "https://example.org", method: http.MethodGet},
+			{name: "wildcard suffix", origin: "https://a.example.org.evil.com", method: http.MethodGet},
+			{name: "wildcard scheme", origin: "http://a.example.org", method: http.MethodGet},
+			{name: "method", origin: "https://app.example.com", method: http.MethodDelete},
+			{name: "header", origin: "https://app.example.com", method: http.MethodGet, headers: "X-Custom"},
+		}
+
+		for _, c := range cases {
+			w := preflight(c.origin, c.method, c.headers)
+			assert.False(t, called, c.name)
+			assert.Equal(t, http.StatusNoContent, w.Code, c.name)
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), c.name)
+		}
+	})
+
+	t.Run("actual request", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+		r.Header.Set("Origin", "https://app.example.com")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "X-Request-ID, Location", w.Header().Get("Access-Control-Expose-Headers"))
+	})
+
+	t.Run("request without origin", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/api/v1/user", nil))
+
+		assert.True(t, called)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("any origin", func(t *testing.T) {
+		handler := middleware.CORS(middleware.CORSConfig{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}})(http.NotFoundHandler())
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Origin", "https://anything.dev")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	})
+}
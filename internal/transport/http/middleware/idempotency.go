@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/internal/domain"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/pkg/httputil"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	idempotencyKeyMaxLength  = 255
+)
+
+// idempotentHeaders are the response headers stored with the response, the others belong to the request
+// that got them (request id, rate limit, CORS).
+var idempotentHeaders = []string{"Content-Type", "Location", "Cache-Control", "ETag"}
+
+type IdempotencyConfig struct {
+	Service domain.IdempotencyService
+	// Routes are the POST routes, by method and path template like "POST /api/v1/user", whose responses
+	// may be stored. The other routes ignore the Idempotency-Key header, a route answering secrets such
+	// as tokens must not be listed as its responses are stored as is.
+	Routes []string
+	// TrustedProxies are believed for the address of the anonymous clients they forward, see clientKey.
+	TrustedProxies httputil.TrustedProxies
+}
+
+// Idempotency makes the requests to the POST routes of cfg sent with an Idempotency-Key header safe to
+// retry. The response
+// of the first request is stored and replayed with Idempotent-Replayed: true to its retries, the same
+// key sent with a different method, path or body is rejected with 422 and a retry arriving while the
+// first request is in flight with 409. Keys are scoped to the client, so it has to run after the
+// authentication, and after BodyLimit as the body is read in full. A response with a 5xx status is not
+// stored, the request can be retried.
+func Idempotency(cfg IdempotencyConfig) func(http.Handler) http.Handler {
+	svc := cfg.Service
+	routes := make(map[string]bool, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		routes[route] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || header == "" || !routes[routeKey(r)] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(header) > idempotencyKeyMaxLength {
+				httputil.RespondWithErr(w, r, fmt.Errorf("%w: %s must be at most %d characters", modelErr.ErrBadParamInput, IdempotencyKeyHeader, idempotencyKeyMaxLength))
+				return
+			}
+
+			body, err := httputil.ReadBody(r)
+			if err != nil {
+				httputil.RespondWithErr(w, r, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			key := clientKey(r, cfg.TrustedProxies) + " " + header
+			stored, err := svc.Begin(r.Context(), key, fingerprint(r, body))
+			if err != nil {
+				httputil.RespondWithErr(w, r, err)
+				return
+			}
+			if stored != nil {
+				replay(w, stored)
+				return
+			}
+
+			rec := &recordingWriter{responseWriter: newResponseWriter(w)}
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				if err := svc.Release(r.Context(), key); err != nil {
+					log.WithContext(r.Context()).WithError(err).Error("failed to release the idempotency key")
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if rec.Status() >= http.StatusInternalServerError {
+				return
+			}
+
+			headers := make(map[string]string, len(idempotentHeaders))
+			for _, h := range idempotentHeaders {
+				if v := rec.Header().Get(h); v != "" {
+					headers[h] = v
+				}
+			}
+			if err := svc.Complete(r.Context(), key, rec.Status(), headers, rec.body.Bytes()); err != nil {
+				log.WithContext(r.Context()).WithError(err).Error("failed to store the idempotent response")
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// fingerprint hashes the method, path and body, what a retry must repeat.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, stored *domain.IdempotencyKey) {
+	for h, v := range stored.Header {
+		w.Header().Set(h, v)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+// recordingWriter keeps a copy of the body written through it.
+type recordingWriter struct {
+	*responseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	n, err := w.responseWriter.Write(b)
+	w.body.Write(b[:n])
+	return n, err
+}
@@ -0,0 +1,228 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/domain/mocks"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/transport/http/middleware"
+	"go-rest-api-boilerplate/pkg/httputil"
+)
+
+// idempotent serves handler behind the Idempotency middleware on a few routes, the user create is the only
+// idempotent one.
+func idempotent(cfg middleware.IdempotencyConfig, handler http.Handler) http.Handler {
+	cfg.Routes = []string{"POST /api/v1/user"}
+
+	r := mux.NewRouter()
+	r.Use(middleware.Idempotency(cfg))
+	r.Handle("/api/v1/user", handler).Methods(http.MethodPost)
+	r.Handle("/api/v1/user/{id}", handler).Methods(http.MethodPatch)
+	r.Handle("/api/v1/auth/login", handler).Methods(http.MethodPost)
+	return r
+}
+
+func TestIdempotency(t *testing.T) {
+	var calls int
+	var gotBody string
+	create := func(status int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			b, _ := io.ReadAll(r.Body)
+			gotBody = string(b)
+			w.Header().Set("Location", "/api/v1/user/1")
+			w.Header().Set("X-Request-ID", "req-1")
+			httputil.RespondWithJSON(w, status, map[string]int{"id": 1})
+		})
+	}
+	post := func(key, body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/user", strings.NewReader(body))
+		r.RemoteAddr = "1.2.3.4:5000"
+		if key != "" {
+			r.Header.Set(middleware.IdempotencyKeyHeader, key)
+		}
+		return r
+	}
+
+	t.Run("forwarded client", func(t *testing.T) {
+		proxies, err := httputil.ParseTrustedProxies([]string{"1.2.3.4"})
+		assert.NoError(t, err)
+		svc := mocks.NewIdempotencyService(t)
+		svc.On("Begin", mock.Anything, "ip:203.0.113.1 key-1", mock.AnythingOfType("string")).Return(nil, nil)
+		svc.On("Complete", mock.Anything, "ip:203.0.113.1 key-1", http.StatusCreated, mock.Anything, mock.Anything).Return(nil)
+
+		r := post("key-1", `{}`)
+		r.Header.Set("X-Forwarded-For", "203.0.113.1")
+		w := httptest.NewRecorder()
+		idempotent(middleware.IdempotencyConfig{Service: svc, TrustedProxies: proxies}, create(http.StatusCreated)).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("first request", func(t *testing.T) {
+		calls = 0
+		svc := mocks.NewIdempotencyService(t)
+		svc.On("Begin", mock.Anything, "ip:1.2.3.4 key-1", mock.AnythingOfType("string")).Return(nil, nil)
+		svc.On("Complete", mock.Anything, "ip:1.2.3.4 key-1", http.StatusCreated,
+			map[string]string{"Content-Type": "application/json", "Location": "/api/v1/user/1"}, []byte(`{"id":1}`)).Return(nil)
+
+		w := httptest.NewRecorder()
+		idempotent(middleware.IdempotencyConfig{Service: svc}, create(http.StatusCreated)).ServeHTTP(w, post("key-1", `{"email":"john@m.co"}`))
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, `{"email":"john@m.co"}`, gotBody)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get(middleware.IdempotentReplayedHeader))
+	})
+
+	t.Run("same fingerprint for the same request", func(t *testing.T) {
+		var fingerprints []string
+		svc := mocks.NewIdempotencyService(t)
+		svc.On("Begin", mock.Anything, mock.Anything, mock.AnythingOfType("string")).
+			Run(func(args mock.Arguments) { fingerprints = append(fingerprints, args.String(2)) }).
+			Return(nil, nil)
+		svc.On("Complete", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		handler := idempotent(middleware.IdempotencyConfig{Service: svc}, create(http.StatusCreated))
+		handler.ServeHTTP(httptest.NewRecorder(), post("key-1", `{"email":"john@m.co"}`))
+		handler.ServeHTTP(httptest.NewRecorder(), post("key-2", `{"email":"john@m.co"}`))
+		handler.ServeHTTP(httptest.NewRecorder(), post("key-3", `{"email":"jane@m.co"}`))
+
+		assert.Equal(t, fingerprints[0], fingerprints[1])
+		assert.NotEqual(t, fingerprints[0], fingerprints[2])
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		calls = 0
+		completedAt := time.Now()
+		svc := mocks.NewIdempotencyService(t)
+		svc.On("Begin", mock.Anything, "ip:1.2.3.4 key-1", mock.AnythingOfType("string")).Return(&domain.IdempotencyKey{
+			Status:      http.StatusCreated,
+			Header:      map[string]string{"Content-Type": "application/json", "Location": "/api/v1/user/1"},
+			Body:        []byte(`{"id":1}`),
+			CompletedAt: &completedAt,
+		}, nil)
+
+		w := httptest.NewRecorder()
+		idempotent(middleware.IdempotencyConfig{Service: svc}, create(http.StatusCreated)).ServeHTTP(w, post("key-1", `{"email":"john@m.co"}`))
+
+		assert.Equal(t, 0, calls)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "/api/v1/user/1", w.Header().Get("Location"))
+		assert.Equal(t, "true", w.Header().Get(middleware.IdempotentReplayedHeader))
+		assert.Equal(t, `{"id":1}`, w.Body.String())
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		cases := []struct {
+			err    error
+			status int
+		}{
+			{err: fmt.Errorf("%w: different request", modelErr.ErrBadParamInput), status: http.StatusUnprocessableEntity},
+			{err: fmt.Errorf("%w: in progress", modelErr.ErrConflict), status: http.StatusConflict},
+		}
+		for _, c := range cases {
+			calls = 0
+			svc := mocks.NewIdempotencyService(t)
+			svc.On("Begin", mock.Anything, "ip:1.2.3.4 key-1", mock.AnythingOfType("string")).Return(nil, c.err)
+
+			r := post("key-1", `{}`)
+			r.Header.Set("Accept", httputil.ContentTypeProblemJSON)
+			w := httptest.NewRecorder()
+			idempotent(middleware.IdempotencyConfig{Service: svc}, create(http.StatusCreated)).ServeHTTP(w, r)
+
+			var problem httputil.Problem
+			json.NewDecoder(w.Body).Decode(&problem)
+			assert.Equal(t, 0, calls)
+			assert.Equal(t, c.status, w.Code)
+			assert.Equal(t, c.err.Error(), problem.Detail)
+		}
+	})
+
+	t.Run("server error releases the key", func(t *testing.T) {
+		svc := mocks.NewIdempotencyService(t)
+		svc.On("Begin", mock.Anything, "ip:1.2.3.4 key-1", mock.AnythingOfType("string")).Return(nil, nil)
+		svc.On("Release", mock.Anything, "ip:1.2.3.4 key-1").Return(nil)
+
+		w := httptest.NewRecorder()
+		idempotent(middleware.IdempotencyConfig{Service: svc}, create(http.StatusServiceUnavailable)).ServeHTTP(w, post("key-1", `{}`))
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("panic releases the key", func(t *testing.T) {
+		svc := mocks.NewIdempotencyService(t)
+		svc.On("Begin", mock.Anything, "ip:1.2.3.4 key-1", mock.AnythingOfType("string")).Return(nil, nil)
+		svc.On("Release", mock.Anything, "ip:1.2.3.4 key-1").Return(nil)
+
+		handler := idempotent(middleware.IdempotencyConfig{Service: svc}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))
+		assert.Panics(t, func() { handler.ServeHTTP(httptest.NewRecorder(), post("key-1", `{}`)) })
+	})
+
+	t.Run("failed store releases the key", func(t *testing.T) {
+		svc := mocks.NewIdempotencyService(t)
+		svc.On("Begin", mock.Anything, "ip:1.2.3.4 key-1", mock.AnythingOfType("string")).Return(nil, nil)
+		svc.On("Complete", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("db down"))
+		svc.On("Release", mock.Anything, "ip:1.2.3.4 key-1").Return(nil)
+
+		w := httptest.NewRecorder()
+		idempotent(middleware.IdempotencyConfig{Service: svc}, create(http.StatusCreated)).ServeHTTP(w, post("key-1", `{}`))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("ignored requests", func(t *testing.T) {
+		svc := mocks.NewIdempotencyService(t)
+		handler := idempotent(middleware.IdempotencyConfig{Service: svc}, create(http.StatusOK))
+
+		calls = 0
+		handler.ServeHTTP(httptest.NewRecorder(), post("", `{}`))
+		r := httptest.NewRequest(http.MethodPatch, "/api/v1/user/1", strings.NewReader(`{}`))
+		r.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("login response is never stored", func(t *testing.T) {
+		// the mock fails the test on any call, a login is not looked up nor stored
+		svc := mocks.NewIdempotencyService(t)
+		handler := idempotent(middleware.IdempotencyConfig{Service: svc}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			httputil.RespondWithJSON(w, http.StatusOK, map[string]string{"access_token": "secret"})
+		}))
+
+		calls = 0
+		for i := 0; i < 2; i++ {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{}`))
+			r.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Empty(t, w.Header().Get(middleware.IdempotentReplayedHeader))
+		}
+
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("key too long", func(t *testing.T) {
+		svc := mocks.NewIdempotencyService(t)
+
+		w := httptest.NewRecorder()
+		idempotent(middleware.IdempotencyConfig{Service: svc}, create(http.StatusCreated)).ServeHTTP(w, post(strings.Repeat("k", 256), `{}`))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// jwksMinRefresh bounds how often an unknown kid can force a reload of the key set.
+const jwksMinRefresh = time.Minute
+
+// JWKS is a JSON Web Key Set read from a file or an URL. The RSA keys are cached for the ttl, a token
+// signed with an unknown kid reloads the set early so key rotation is picked up.
+type JWKS struct {
+	url    string
+	file   string
+	ttl    time.Duration
+	client *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func NewJWKS(url, file string, ttl time.Duration) *JWKS {
+	return &JWKS{
+		url:    url,
+		file:   file,
+		ttl:    ttl,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// Key returns the RSA public key with the kid.
+func (j *JWKS) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	j.mu.RLock()
+	key, ok := j.keys[kid]
+	loaded, age := j.keys != nil, time.Since(j.fetchedAt)
+	j.mu.RUnlock()
+
+	stale := !loaded || age >= j.ttl
+	if ok && !stale {
+		return key, nil
+	}
+
+	if stale || age >= jwksMinRefresh {
+		err := j.refresh(ctx)
+		if err != nil {
+			if ok {
+				// keep serving the cached key while the source is unavailable
+				return key, nil
+			}
+			return nil, err
+		}
+	}
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	key, ok = j.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	return key, nil
+}
+
+func (j *JWKS) refresh(ctx context.Context) error {
+	b, err := j.load(ctx)
+	if err != nil {
+		return fmt.Errorf("load jwks: %w", err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	err = json.Unmarshal(b, &set)
+	if err != nil {
+		return fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		key, err := rsaPublicKey(k)
+		if err != nil {
+			return fmt.Errorf("decode jwk %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+
+	j.mu.Lock()
+	j.keys, j.fetchedAt = keys, time.Now()
+	j.mu.Unlock()
+	return nil
+}
+
+func (j *JWKS) load(ctx context.Context) ([]byte, error) {
+	if j.file != "" {
+		return os.ReadFile(j.file)
+	}
+
+	if j.url == "" {
+		return nil, errors.New("no jwks source configured")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(res.Body, 1<<20))
+}
+
+func rsaPublicKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
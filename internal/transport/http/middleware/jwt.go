@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/internal/model/auth"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/pkg/httputil"
+)
+
+type JWTConfig struct {
+	// HMACSecret verifies HS256 tokens, HS256 is rejected when empty.
+	HMACSecret []byte
+	// JWKS verifies RS256 tokens by their kid, RS256 is rejected when nil.
+	JWKS *JWKS
+	// Issuer and Audience are checked against iss and aud when set.
+	Issuer   string
+	Audience string
+	// Allowlist holds the paths served without a token, an entry ending with * matches by prefix.
+	Allowlist []string
+}
+
+// JWTAuthenticator authenticates requests with a JWT bearer token (RFC 6750) and puts the caller in the
+// request context as an auth.Principal.
+type JWTAuthenticator struct {
+	cfg    JWTConfig
+	parser *jwt.Parser
+}
+
+func NewJWTAuthenticator(cfg JWTConfig) *JWTAuthenticator {
+	if len(cfg.HMACSecret) == 0 && cfg.JWKS == nil {
+		log.Warn("no JWT key configured, every authenticated request is rejected")
+	}
+
+	return &JWTAuthenticator{
+		cfg:    cfg,
+		parser: jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()})),
+	}
+}
+
+// Middleware rejects the requests outside the allowlist without a valid token with 401, a request already
+// authenticated by an API key is let through.
+func (a *JWTAuthenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := auth.FromContext(r.Context()); ok || a.allowed(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		raw, ok := bearerToken(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			httputil.RespondWithErr(w, r, fmt.Errorf("%w: missing bearer token", modelErr.ErrUnauthorized))
+			return
+		}
+
+		principal, err := a.authenticate(r, raw)
+		if err != nil {
+			log.WithContext(r.Context()).WithError(err).Warn("invalid bearer token")
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="api", error="invalid_token", error_description=%q`, err.Error()))
+			httputil.RespondWithErr(w, r, fmt.Errorf("%w: %s", modelErr.ErrUnauthorized, err.Error()))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+func (a *JWTAuthenticator) allowed(path string) bool {
+	return matchPath(a.cfg.Allowlist, path)
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	if len(h) < 7 || !strings.EqualFold(h[:7], "bearer ") {
+		return "", false
+	}
+
+	token := strings.TrimSpace(h[7:])
+	return token, token != ""
+}
+
+// authenticate verifies the signature, exp and nbf of the token, then iss and aud when configured.
+func (a *JWTAuthenticator) authenticate(r *http.Request, raw string) (*auth.Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method {
+		case jwt.SigningMethodHS256:
+			if len(a.cfg.HMACSecret) == 0 {
+				return nil, errors.New("HS256 is not accepted")
+			}
+			return a.cfg.HMACSecret, nil
+		case jwt.SigningMethodRS256:
+			if a.cfg.JWKS == nil {
+				return nil, errors.New("RS256 is not accepted")
+			}
+			kid, _ := token.Header["kid"].(string)
+			return a.cfg.JWKS.Key(r.Context(), kid)
+		}
+
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	})
+	if err != nil {
+		var validationErr *jwt.ValidationError
+		if errors.As(err, &validationErr) && validationErr.Inner != nil {
+			return nil, validationErr.Inner
+		}
+		return nil, err
+	}
+
+	if a.cfg.Issuer != "" && !claims.VerifyIssuer(a.cfg.Issuer, true) {
+		return nil, errors.New("token issuer is not accepted")
+	}
+
+	if a.cfg.Audience != "" && !claims.VerifyAudience(a.cfg.Audience, true) {
+		return nil, errors.New("token audience is not accepted")
+	}
+
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, errors.New("token has no subject")
+	}
+
+	return &auth.Principal{Subject: sub, Roles: roles(claims), Claims: claims}, nil
+}
+
+// roles reads the roles claim, a token without one authenticates a caller with no permission.
+func roles(claims jwt.MapClaims) []auth.Role {
+	values, _ := claims["roles"].([]interface{})
+	roles := make([]auth.Role, 0, len(values))
+	for _, v := range values {
+		if role, ok := v.(string); ok {
+			roles = append(roles, auth.Role(role))
+		}
+	}
+
+	return roles
+}
@@ -0,0 +1,185 @@
+package middleware_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"go-rest-api-boilerplate/internal/model/auth"
+	"go-rest-api-boilerplate/internal/transport/http/middleware"
+	"go-rest-api-boilerplate/pkg/httputil"
+)
+
+var testSecret = []byte("test-secret")
+
+func signHS256(t *testing.T, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testSecret)
+	assert.NoError(t, err)
+	return token
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+	return signed
+}
+
+func jwksJSON(t *testing.T, key *rsa.PublicKey, kid string) []byte {
+	b, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	assert.NoError(t, err)
+	return b
+}
+
+// principalHandler answers 200 with the subject of the authenticated principal.
+var principalHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	p, ok := auth.FromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusTeapot)
+		return
+	}
+	w.Write([]byte(p.Subject))
+})
+
+func serve(a *middleware.JWTAuthenticator, path, authorization string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+	a.Middleware(principalHandler).ServeHTTP(w, r)
+	return w
+}
+
+func TestJWTAuthenticator_HS256(t *testing.T) {
+	a := middleware.NewJWTAuthenticator(middleware.JWTConfig{
+		HMACSecret: testSecret,
+		Issuer:     "https://issuer.test",
+		Audience:   "api",
+		Allowlist:  []string{"/healthz", "/public/*"},
+	})
+	valid := jwt.MapClaims{"sub": "42", "iss": "https://issuer.test", "aud": "api", "exp": time.Now().Add(time.Minute).Unix()}
+
+	t.Run("success", func(t *testing.T) {
+		w := serve(a, "/api/v1/user", "Bearer "+signHS256(t, valid))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "42", w.Body.String())
+	})
+
+	t.Run("success:roles", func(t *testing.T) {
+		claims := jwt.MapClaims{"sub": "42", "iss": "https://issuer.test", "aud": "api", "roles": []string{"admin", "user"}}
+
+		var principal *auth.Principal
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+		r.Header.Set("Authorization", "Bearer "+signHS256(t, claims))
+		a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, _ = auth.FromContext(r.Context())
+		})).ServeHTTP(w, r)
+
+		assert.Equal(t, []auth.Role{auth.RoleAdmin, auth.RoleUser}, principal.Roles)
+		assert.True(t, principal.Can(auth.PermUserAny))
+	})
+
+	t.Run("success:allowlist", func(t *testing.T) {
+		assert.Equal(t, http.StatusTeapot, serve(a, "/healthz", "").Code)
+		assert.Equal(t, http.StatusTeapot, serve(a, "/public/docs", "").Code)
+	})
+
+	t.Run("error:missing token", func(t *testing.T) {
+		w := serve(a, "/api/v1/user", "")
+
+		var problem httputil.Problem
+		json.NewDecoder(w.Body).Decode(&problem)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, `Bearer realm="api"`, w.Header().Get("WWW-Authenticate"))
+		assert.Equal(t, httputil.CodeUnauthorized, problem.Code)
+	})
+
+	invalid := map[string]string{
+		"expired":    signHS256(t, jwt.MapClaims{"sub": "42", "iss": "https://issuer.test", "aud": "api", "exp": time.Now().Add(-time.Minute).Unix()}),
+		"not before": signHS256(t, jwt.MapClaims{"sub": "42", "iss": "https://issuer.test", "aud": "api", "nbf": time.Now().Add(time.Minute).Unix()}),
+		"issuer":     signHS256(t, jwt.MapClaims{"sub": "42", "iss": "https://other.test", "aud": "api"}),
+		"audience":   signHS256(t, jwt.MapClaims{"sub": "42", "iss": "https://issuer.test", "aud": "other"}),
+		"no subject": signHS256(t, jwt.MapClaims{"iss": "https://issuer.test", "aud": "api"}),
+		"signature":  signHS256(t, valid) + "x",
+		"malformed":  "not.a.token",
+		"alg none": func() string {
+			s, _ := jwt.NewWithClaims(jwt.SigningMethodNone, valid).SignedString(jwt.UnsafeAllowNoneSignatureType)
+			return s
+		}(),
+		"rs256 no key": signRS256(t, mustRSAKey(t), "k1", valid),
+	}
+	for name, token := range invalid {
+		t.Run("error:"+name, func(t *testing.T) {
+			w := serve(a, "/api/v1/user", "Bearer "+token)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="invalid_token"`)
+		})
+	}
+}
+
+func mustRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	return key
+}
+
+func TestJWTAuthenticator_RS256(t *testing.T) {
+	key := mustRSAKey(t)
+	claims := jwt.MapClaims{"sub": "7", "exp": time.Now().Add(time.Minute).Unix()}
+
+	t.Run("success:jwks file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "jwks.json")
+		assert.NoError(t, os.WriteFile(file, jwksJSON(t, &key.PublicKey, "k1"), 0o600))
+
+		a := middleware.NewJWTAuthenticator(middleware.JWTConfig{JWKS: middleware.NewJWKS("", file, time.Hour)})
+		w := serve(a, "/api/v1/user", "Bearer "+signRS256(t, key, "k1", claims))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "7", w.Body.String())
+
+		w = serve(a, "/api/v1/user", "Bearer "+signRS256(t, key, "unknown", claims))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("success:jwks url cached", func(t *testing.T) {
+		hits := 0
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits++
+			w.Write(jwksJSON(t, &key.PublicKey, "k1"))
+		}))
+		defer srv.Close()
+
+		a := middleware.NewJWTAuthenticator(middleware.JWTConfig{JWKS: middleware.NewJWKS(srv.URL, "", time.Hour)})
+		for i := 0; i < 3; i++ {
+			w := serve(a, "/api/v1/user", "Bearer "+signRS256(t, key, "k1", claims))
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
+		assert.Equal(t, 1, hits)
+	})
+
+	t.Run("error:hs256 not accepted", func(t *testing.T) {
+		a := middleware.NewJWTAuthenticator(middleware.JWTConfig{JWKS: middleware.NewJWKS("", "", time.Hour)})
+		w := serve(a, "/api/v1/user", "Bearer "+signHS256(t, claims))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
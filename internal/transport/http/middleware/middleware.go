@@ -1 +1,14 @@
 package middleware
+
+import "strings"
+
+// matchPath reports whether path is one of patterns, a pattern ending with * matches by prefix.
+func matchPath(patterns []string, path string) bool {
+	for _, p := range patterns {
+		if p == path || (strings.HasSuffix(p, "*") && strings.HasPrefix(path, strings.TrimSuffix(p, "*"))) {
+			return true
+		}
+	}
+
+	return false
+}
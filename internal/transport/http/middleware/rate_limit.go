@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/internal/model/auth"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/pkg/httputil"
+	"go-rest-api-boilerplate/pkg/ratelimit"
+)
+
+type RateLimitConfig struct {
+	// Default limits every route without an override, a zero Requests disables it.
+	Default ratelimit.Limit
+	// Routes overrides the limit of a route keyed by its method and path template, like
+	// "POST /api/v1/auth/login". An overridden route has buckets of its own.
+	Routes map[string]ratelimit.Limit
+	Store  ratelimit.Store
+	// TrustedProxies are believed for the address of the anonymous clients they forward.
+	TrustedProxies httputil.TrustedProxies
+}
+
+// ParseRateLimitRoutes parses the route overrides written like "POST /api/v1/auth/login=10/1m".
+func ParseRateLimitRoutes(entries []string) (map[string]ratelimit.Limit, error) {
+	routes := make(map[string]ratelimit.Limit, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, value, ok := strings.Cut(entry, "=")
+		if !ok || len(strings.Fields(route)) != 2 {
+			return nil, fmt.Errorf("rate limit route %q is not METHOD /path=requests/period", entry)
+		}
+
+		limit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := strings.Fields(route)
+		routes[strings.ToUpper(fields[0])+" "+fields[1]] = limit
+	}
+
+	return routes, nil
+}
+
+// RateLimit limits the requests of each client with a token bucket. A client is the API key or the JWT
+// subject of the authenticated principal, the client IP otherwise, so it has to run after the
+// authentication. The limit is advertised with the RateLimit-Limit and RateLimit-Remaining headers, a
+// request over it is rejected with 429 and Retry-After. A failing store lets the requests through.
+func RateLimit(cfg RateLimitConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit, key := cfg.Default, clientKey(r, cfg.TrustedProxies)
+			if route := routeKey(r); route != "" {
+				if override, ok := cfg.Routes[route]; ok {
+					limit, key = override, key+"|"+route
+				}
+			}
+
+			if limit.Requests == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := cfg.Store.Take(r.Context(), key, limit)
+			if err != nil {
+				log.WithContext(r.Context()).WithError(err).Error("rate limit store failed, request let through")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+				log.WithContext(r.Context()).Warnf("rate limit %s exceeded by %s", limit, key)
+				httputil.RespondWithErr(w, r, modelErr.ErrRateLimited)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type AuthFailureLimitConfig struct {
+	// Limit is the number of failed authentications allowed to a client IP, a zero Requests disables it.
+	Limit ratelimit.Limit
+	Store ratelimit.Store
+	// TrustedProxies are believed for the address of the clients they forward.
+	TrustedProxies httputil.TrustedProxies
+}
+
+// AuthFailureLimit limits the failed authentications of each client IP, so API keys, tokens and passwords
+// cannot be guessed without limit. It has to run ahead of the authentication: every 401 response takes a
+// token from the bucket of the client IP, and a client whose bucket is empty is rejected with 429 before
+// its credentials are looked up. The requests that authenticate are not counted. A failing store lets the
+// requests through.
+func AuthFailureLimit(cfg AuthFailureLimitConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if cfg.Limit.Requests == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := "auth-failure:ip:" + cfg.TrustedProxies.ClientIP(r)
+			res, err := cfg.Store.Peek(r.Context(), key, cfg.Limit)
+			if err != nil {
+				log.WithContext(r.Context()).WithError(err).Error("rate limit store failed, request let through")
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+				log.WithContext(r.Context()).Warnf("failed authentication limit %s exceeded by %s", cfg.Limit, key)
+				httputil.RespondWithErr(w, r, modelErr.ErrRateLimited)
+				return
+			}
+
+			rw := newResponseWriter(w)
+			next.ServeHTTP(rw, r)
+			if rw.Status() != http.StatusUnauthorized {
+				return
+			}
+
+			if _, err := cfg.Store.Take(r.Context(), key, cfg.Limit); err != nil {
+				log.WithContext(r.Context()).WithError(err).Error("rate limit store failed, failed authentication not counted")
+			}
+		})
+	}
+}
+
+// clientKey identifies the client of the request, the principal subject already tells an API key
+// (apikey:<id>) from a JWT subject. An anonymous client is its address, the one forwarded by the trusted
+// proxies, so the clients behind a load balancer do not share a key.
+func clientKey(r *http.Request, proxies httputil.TrustedProxies) string {
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		return "sub:" + principal.Subject
+	}
+
+	return "ip:" + proxies.ClientIP(r)
+}
+
+func routeKey(r *http.Request) string {
+	template := routeTemplate(r)
+	if template == "" {
+		return ""
+	}
+
+	return r.Method + " " + template
+}
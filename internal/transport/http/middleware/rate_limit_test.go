@@ -0,0 +1,260 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go-rest-api-boilerplate/internal/domain/mocks"
+	"go-rest-api-boilerplate/internal/model/auth"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/transport/http/middleware"
+	"go-rest-api-boilerplate/pkg/httputil"
+	"go-rest-api-boilerplate/pkg/ratelimit"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func (failingStore) Peek(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func newRateLimitedRouter(cfg middleware.RateLimitConfig) *mux.Router {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	r := mux.NewRouter()
+	r.Use(middleware.RateLimit(cfg))
+	v1 := r.PathPrefix("/api/v1").Subrouter()
+	v1.HandleFunc("/user/{id}", ok).Methods(http.MethodGet)
+	v1.HandleFunc("/auth/login", ok).Methods(http.MethodPost)
+	return r
+}
+
+func TestRateLimit(t *testing.T) {
+	routes, err := middleware.ParseRateLimitRoutes([]string{"post /api/v1/auth/login=1/1m"})
+	assert.NoError(t, err)
+
+	send := func(r http.Handler, method, path, remoteAddr string, principal *auth.Principal) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = remoteAddr
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("limit by ip", func(t *testing.T) {
+		r := newRateLimitedRouter(middleware.RateLimitConfig{
+			Default: ratelimit.Limit{Requests: 2, Period: time.Minute},
+			Store:   ratelimit.NewMemoryStore(),
+		})
+
+		w := send(r, http.MethodGet, "/api/v1/user/1", "10.0.0.1:1234", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+
+		// the bucket is per client, not per path, and ignores the source port
+		assert.Equal(t, http.StatusOK, send(r, http.MethodGet, "/api/v1/user/2", "10.0.0.1:5678", nil).Code)
+
+		w = send(r, http.MethodGet, "/api/v1/user/1", "10.0.0.1:1234", nil)
+		var problem httputil.Problem
+		json.NewDecoder(w.Body).Decode(&problem)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, httputil.CodeRateLimited, problem.Code)
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+		assert.Equal(t, http.StatusOK, send(r, http.MethodGet, "/api/v1/user/1", "10.0.0.2:1234", nil).Code)
+	})
+
+	t.Run("limit by forwarded ip", func(t *testing.T) {
+		proxies, err := httputil.ParseTrustedProxies([]string{"10.0.0.0/8"})
+		assert.NoError(t, err)
+		r := newRateLimitedRouter(middleware.RateLimitConfig{
+			Routes:         routes,
+			Store:          ratelimit.NewMemoryStore(),
+			TrustedProxies: proxies,
+		})
+
+		sendFrom := func(client string) int {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			req.Header.Set("X-Forwarded-For", client)
+			r.ServeHTTP(w, req)
+			return w.Code
+		}
+
+		// the clients behind the load balancer have buckets of their own
+		assert.Equal(t, http.StatusOK, sendFrom("203.0.113.1"))
+		assert.Equal(t, http.StatusTooManyRequests, sendFrom("203.0.113.1"))
+		assert.Equal(t, http.StatusOK, sendFrom("203.0.113.2"))
+	})
+
+	t.Run("limit by principal", func(t *testing.T) {
+		r := newRateLimitedRouter(middleware.RateLimitConfig{
+			Default: ratelimit.Limit{Requests: 1, Period: time.Minute},
+			Store:   ratelimit.NewMemoryStore(),
+		})
+		jwtUser := &auth.Principal{Subject: "1"}
+		apiKey := &auth.Principal{Subject: "apikey:1"}
+
+		assert.Equal(t, http.StatusOK, send(r, http.MethodGet, "/api/v1/user/1", "10.0.0.1:1", jwtUser).Code)
+		assert.Equal(t, http.StatusTooManyRequests, send(r, http.MethodGet, "/api/v1/user/1", "10.0.0.2:1", jwtUser).Code)
+		assert.Equal(t, http.StatusOK, send(r, http.MethodGet, "/api/v1/user/1", "10.0.0.1:1", apiKey).Code)
+		assert.Equal(t, http.StatusOK, send(r, http.MethodGet, "/api/v1/user/1", "10.0.0.1:1", nil).Code)
+	})
+
+	t.Run("route override", func(t *testing.T) {
+		r := newRateLimitedRouter(middleware.RateLimitConfig{
+			Default: ratelimit.Limit{Requests: 5, Period: time.Minute},
+			Routes:  routes,
+			Store:   ratelimit.NewMemoryStore(),
+		})
+
+		w := send(r, http.MethodPost, "/api/v1/auth/login", "10.0.0.1:1", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, http.StatusTooManyRequests, send(r, http.MethodPost, "/api/v1/auth/login", "10.0.0.1:1", nil).Code)
+
+		w = send(r, http.MethodGet, "/api/v1/user/1", "10.0.0.1:1", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "4", w.Header().Get("RateLimit-Remaining"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		r := newRateLimitedRouter(middleware.RateLimitConfig{Store: ratelimit.NewMemoryStore()})
+
+		w := send(r, http.MethodGet, "/api/v1/user/1", "10.0.0.1:1", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	})
+
+	t.Run("store failure lets requests through", func(t *testing.T) {
+		r := newRateLimitedRouter(middleware.RateLimitConfig{
+			Default: ratelimit.Limit{Requests: 1, Period: time.Minute},
+			Store:   failingStore{},
+		})
+
+		assert.Equal(t, http.StatusOK, send(r, http.MethodGet, "/api/v1/user/1", "10.0.0.1:1", nil).Code)
+	})
+}
+
+func TestAuthFailureLimit(t *testing.T) {
+	const goodKey, badKey = "rak_good", "rak_bad"
+	newLimitedAPIKey := func(t *testing.T, cfg middleware.AuthFailureLimitConfig) (http.Handler, *mocks.ApiKeyService) {
+		svc := mocks.NewApiKeyService(t)
+		svc.On("Authenticate", mock.Anything, goodKey).Return(&auth.Principal{Subject: "apikey:1"}, nil).Maybe()
+		svc.On("Authenticate", mock.Anything, badKey).Return(nil, fmt.Errorf("%w: unknown api key", modelErr.ErrUnauthorized)).Maybe()
+
+		jwtAuth := middleware.NewJWTAuthenticator(middleware.JWTConfig{HMACSecret: testSecret})
+		return middleware.AuthFailureLimit(cfg)(middleware.APIKey(svc)(jwtAuth.Middleware(principalHandler))), svc
+	}
+	sendKey := func(h http.Handler, key, remoteAddr string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(middleware.APIKeyHeader, key)
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("repeated bad keys get 429", func(t *testing.T) {
+		h, svc := newLimitedAPIKey(t, middleware.AuthFailureLimitConfig{
+			Limit: ratelimit.Limit{Requests: 3, Period: time.Minute},
+			Store: ratelimit.NewMemoryStore(),
+		})
+
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusUnauthorized, sendKey(h, badKey, "10.0.0.1:1234").Code)
+		}
+
+		w := sendKey(h, badKey, "10.0.0.1:1234")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "20", w.Header().Get("Retry-After"))
+		svc.AssertNumberOfCalls(t, "Authenticate", 3)
+
+		// the client is blocked whatever it sends, the other clients are not
+		assert.Equal(t, http.StatusTooManyRequests, sendKey(h, goodKey, "10.0.0.1:1234").Code)
+		assert.Equal(t, http.StatusOK, sendKey(h, goodKey, "10.0.0.2:1234").Code)
+	})
+
+	t.Run("successful authentications are not counted", func(t *testing.T) {
+		h, _ := newLimitedAPIKey(t, middleware.AuthFailureLimitConfig{
+			Limit: ratelimit.Limit{Requests: 1, Period: time.Minute},
+			Store: ratelimit.NewMemoryStore(),
+		})
+
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, sendKey(h, goodKey, "10.0.0.1:1234").Code)
+		}
+	})
+
+	t.Run("forwarded client", func(t *testing.T) {
+		proxies, err := httputil.ParseTrustedProxies([]string{"10.0.0.0/8"})
+		assert.NoError(t, err)
+		h, _ := newLimitedAPIKey(t, middleware.AuthFailureLimitConfig{
+			Limit:          ratelimit.Limit{Requests: 1, Period: time.Minute},
+			Store:          ratelimit.NewMemoryStore(),
+			TrustedProxies: proxies,
+		})
+
+		sendFrom := func(client string) int {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			req.Header.Set("X-Forwarded-For", client)
+			req.Header.Set(middleware.APIKeyHeader, badKey)
+			h.ServeHTTP(w, req)
+			return w.Code
+		}
+
+		assert.Equal(t, http.StatusUnauthorized, sendFrom("203.0.113.1"))
+		assert.Equal(t, http.StatusTooManyRequests, sendFrom("203.0.113.1"))
+		assert.Equal(t, http.StatusUnauthorized, sendFrom("203.0.113.2"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		h, _ := newLimitedAPIKey(t, middleware.AuthFailureLimitConfig{Store: ratelimit.NewMemoryStore()})
+
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusUnauthorized, sendKey(h, badKey, "10.0.0.1:1234").Code)
+		}
+	})
+
+	t.Run("store failure lets requests through", func(t *testing.T) {
+		h, _ := newLimitedAPIKey(t, middleware.AuthFailureLimitConfig{
+			Limit: ratelimit.Limit{Requests: 1, Period: time.Minute},
+			Store: failingStore{},
+		})
+
+		assert.Equal(t, http.StatusUnauthorized, sendKey(h, badKey, "10.0.0.1:1234").Code)
+		assert.Equal(t, http.StatusUnauthorized, sendKey(h, badKey, "10.0.0.1:1234").Code)
+	})
+}
+
+func TestParseRateLimitRoutes(t *testing.T) {
+	routes, err := middleware.ParseRateLimitRoutes([]string{"POST /api/v1/auth/login=10/1m", " "})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]ratelimit.Limit{"POST /api/v1/auth/login": {Requests: 10, Period: time.Minute}}, routes)
+
+	for _, entry := range []string{"/api/v1/auth/login=10/1m", "POST /api/v1/auth/login", "POST /api/v1/auth/login=10"} {
+		_, err := middleware.ParseRateLimitRoutes([]string{entry})
+		assert.Error(t, err, entry)
+	}
+}
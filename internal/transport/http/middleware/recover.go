@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/pkg/httputil"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// errPanic is answered for a recovered panic, the panic itself is only logged.
+var errPanic = errors.New("handler panicked")
+
+type RecoverConfig struct {
+	// Panics counts the recovered panics, nothing is counted when nil.
+	Panics syncint64.Counter
+	// Logger defaults to the logrus standard logger.
+	Logger *log.Logger
+}
+
+// Recover turns a panic of the next handlers into a 500 answered through httputil.RespondWithErr, unless
+// the response has already started. The panic is logged with its stack, recorded as an error of the
+// request span and counted. It must run after otelmux and RequestID for the span and the request id, an
+// http.ErrAbortHandler panic is let through to abort the response.
+func Recover(cfg RecoverConfig) func(http.Handler) http.Handler {
+	if cfg.Logger == nil {
+		cfg.Logger = log.StandardLogger()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := newResponseWriter(w)
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				err, ok := v.(error)
+				if !ok {
+					err = fmt.Errorf("%v", v)
+				}
+				err = fmt.Errorf("panic: %w", err)
+				stack := string(debug.Stack())
+
+				cfg.Logger.WithContext(r.Context()).WithError(err).WithField("stack", stack).Error("panic while handling request")
+
+				span := trace.SpanFromContext(r.Context())
+				span.RecordError(err, trace.WithAttributes(attribute.String("exception.stacktrace", stack)))
+				span.SetStatus(codes.Error, err.Error())
+
+				if cfg.Panics != nil {
+					cfg.Panics.Add(r.Context(), 1, attribute.String("http.route", routeTemplate(r)))
+				}
+
+				if !rw.Written() {
+					httputil.RespondWithErr(rw, r, errPanic)
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
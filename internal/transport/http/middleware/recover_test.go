@@ -0,0 +1,101 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-rest-api-boilerplate/internal/transport/http/middleware"
+	"go-rest-api-boilerplate/pkg/httputil"
+	"go-rest-api-boilerplate/pkg/requestid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// panicCounter embeds the interface for its unexported method, only Add is called.
+type panicCounter struct {
+	syncint64.Counter
+	total int64
+}
+
+func (c *panicCounter) Add(_ context.Context, incr int64, _ ...attribute.KeyValue) {
+	c.total += incr
+}
+
+func TestRecover(t *testing.T) {
+	t.Run("panic", func(t *testing.T) {
+		logger, hook := test.NewNullLogger()
+		logger.AddHook(requestid.LogHook{})
+		counter := &panicCounter{}
+		spans := tracetest.NewSpanRecorder()
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)).Tracer("test")
+
+		handler := middleware.RequestID(middleware.Recover(middleware.RecoverConfig{Panics: counter, Logger: logger})(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			})))
+
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+		ctx, span := tracer.Start(r.Context(), "GET /api/v1/user")
+		r.Header.Set(requestid.Header, "req-1")
+		r.Header.Set("Accept", httputil.ContentTypeProblemJSON)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r.WithContext(ctx))
+		span.End()
+
+		var problem httputil.Problem
+		json.NewDecoder(w.Body).Decode(&problem)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, httputil.CodeInternal, problem.Code)
+		assert.Equal(t, http.StatusText(http.StatusInternalServerError), problem.Detail)
+		assert.Equal(t, "req-1", problem.RequestID)
+
+		require.NotEmpty(t, hook.AllEntries())
+		entry := hook.AllEntries()[0]
+		assert.Equal(t, "panic while handling request", entry.Message)
+		assert.Equal(t, "req-1", entry.Data["request_id"])
+		assert.Contains(t, entry.Data["stack"], "recover_test.go")
+
+		assert.Equal(t, int64(1), counter.total)
+
+		ended := spans.Ended()
+		require.Len(t, ended, 1)
+		assert.Equal(t, codes.Error, ended[0].Status().Code)
+		require.Len(t, ended[0].Events(), 1)
+		assert.Equal(t, "exception", ended[0].Events()[0].Name)
+	})
+
+	t.Run("panic after the response started", func(t *testing.T) {
+		logger, _ := test.NewNullLogger()
+		handler := middleware.Recover(middleware.RecoverConfig{Logger: logger})(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				panic("boom")
+			}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("abort handler", func(t *testing.T) {
+		handler := middleware.Recover(middleware.RecoverConfig{})(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic(http.ErrAbortHandler)
+			}))
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+	})
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go-rest-api-boilerplate/pkg/requestid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDAttribute is the span attribute holding the request id.
+const RequestIDAttribute = attribute.Key("http.request_id")
+
+// RequestID takes the X-Request-ID of the request, or generates one when it is missing or not valid, puts
+// it in the request context and on the response, and sets it on the span of the request. It must run
+// after otelmux for the span to exist.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		w.Header().Set(requestid.Header, id)
+		trace.SpanFromContext(r.Context()).SetAttributes(RequestIDAttribute.String(id))
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
@@ -0,0 +1,48 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go-rest-api-boilerplate/internal/transport/http/middleware"
+	"go-rest-api-boilerplate/pkg/requestid"
+)
+
+func TestRequestID(t *testing.T) {
+	var got string
+	handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = requestid.FromContext(r.Context())
+	}))
+
+	t.Run("incoming id is kept", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+		r.Header.Set(requestid.Header, "client-req-42")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		assert.Equal(t, "client-req-42", got)
+		assert.Equal(t, "client-req-42", w.Header().Get(requestid.Header))
+	})
+
+	t.Run("missing id is generated", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/user", nil))
+
+		assert.True(t, requestid.Valid(got))
+		assert.Equal(t, got, w.Header().Get(requestid.Header))
+	})
+
+	t.Run("invalid id is replaced", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+		r.Header.Set(requestid.Header, strings.Repeat("a", 200))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		assert.NotEqual(t, strings.Repeat("a", 200), got)
+		assert.True(t, requestid.Valid(got))
+		assert.Equal(t, got, w.Header().Get(requestid.Header))
+	})
+}
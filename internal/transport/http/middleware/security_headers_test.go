@@ -0,0 +1,41 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go-rest-api-boilerplate/internal/transport/http/middleware"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	t.Run("headers", func(t *testing.T) {
+		handler := middleware.SecurityHeaders(middleware.SecurityHeadersConfig{
+			HSTSMaxAge:            180 * 24 * time.Hour,
+			ReferrerPolicy:        "no-referrer",
+			ContentSecurityPolicy: "default-src 'none'",
+		})(http.NotFoundHandler())
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, "max-age=15552000; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+		assert.Equal(t, "default-src 'none'", w.Header().Get("Content-Security-Policy"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		handler := middleware.SecurityHeaders(middleware.SecurityHeadersConfig{})(http.NotFoundHandler())
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Empty(t, w.Header().Get("Strict-Transport-Security"))
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		assert.Empty(t, w.Header().Get("Referrer-Policy"))
+		assert.Empty(t, w.Header().Get("Content-Security-Policy"))
+	})
+}
@@ -0,0 +1,213 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/model/auth"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/model/reqres"
+	"go-rest-api-boilerplate/pkg/httputil"
+	"go-rest-api-boilerplate/pkg/util"
+	"go-rest-api-boilerplate/pkg/validation"
+)
+
+type postHandler struct {
+	postSvc  domain.PostService
+	validate *validation.Validator
+}
+
+func NewPostHandlerRegister(r *mux.Router, service domain.PostService, validate *validation.Validator) {
+	handler := postHandler{postSvc: service, validate: validate}
+	v1 := r.PathPrefix("/api/v1").Subrouter()
+	{
+		v1.Handle("/post", authorize(auth.PermPostCreate, handler.Create)).Methods(http.MethodPost)
+		v1.Handle("/post", authorize(auth.PermPostList, handler.FindAll)).Methods(http.MethodGet)
+		v1.Handle("/post/{id}", authorize(auth.PermPostRead, handler.FindByID)).Methods(http.MethodGet)
+		v1.Handle("/post/{id}", authorize(auth.PermPostDelete, handler.DeleteByID)).Methods(http.MethodDelete)
+		v1.Handle("/post/{id}", authorize(auth.PermPostUpdate, handler.UpdateByID)).Methods(http.MethodPut)
+		v1.Handle("/user/{id}/posts", authorize(auth.PermPostList, handler.FindAllByUser)).Methods(http.MethodGet)
+	}
+}
+
+// postLocation is the URL of a post resource, sent in the Location header of a created post.
+func postLocation(id int64) string {
+	return fmt.Sprintf("/api/v1/post/%d", id)
+}
+
+func (h *postHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var createPostReq reqres.CreatePostReq
+	err := httputil.DecodeJSON(r, &createPostReq)
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("error decoding json payload")
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	err = h.validate.Struct(&createPostReq, r.Header.Get("Accept-Language"))
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("error validator")
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	post, err := h.postSvc.Create(r.Context(), &createPostReq)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", postLocation(post.ID))
+	httputil.RespondWithJSON(w, http.StatusCreated, httputil.ApiResponse{
+		Error:   false,
+		Message: "Created",
+		Data:    post,
+	})
+}
+
+func (h *postHandler) UpdateByID(w http.ResponseWriter, r *http.Request) {
+	id, err := util.StringToInt64(mux.Vars(r)["id"])
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("param id not valid")
+		httputil.RespondWithErr(w, r, fmt.Errorf("%w: id", modelErr.ErrBadParamInput))
+		return
+	}
+
+	var updatePostReq reqres.UpdatePostReq
+	err = httputil.DecodeJSON(r, &updatePostReq)
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("error decoding json payload")
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	err = h.validate.Struct(&updatePostReq, r.Header.Get("Accept-Language"))
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("error validator")
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	post, err := h.postSvc.UpdateByID(r.Context(), id, &updatePostReq)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	httputil.RespondWithJSON(w, http.StatusOK, httputil.ApiResponse{
+		Error:   false,
+		Message: "OK",
+		Data:    post,
+	})
+}
+
+func (h *postHandler) DeleteByID(w http.ResponseWriter, r *http.Request) {
+	id, err := util.StringToInt64(mux.Vars(r)["id"])
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("param id not valid")
+		httputil.RespondWithErr(w, r, fmt.Errorf("%w: id", modelErr.ErrBadParamInput))
+		return
+	}
+
+	err = h.postSvc.DeleteByID(r.Context(), id)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	httputil.RespondWithJSON(w, http.StatusOK, httputil.ApiResponse{
+		Error:   false,
+		Message: "OK",
+		Data:    nil,
+	})
+}
+
+func (h *postHandler) FindAll(w http.ResponseWriter, r *http.Request) {
+	listPostReq, err := parseListPostReq(r.URL.Query(), true)
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("query param not valid")
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	err = h.validate.Struct(listPostReq, r.Header.Get("Accept-Language"))
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("error validator")
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	posts, pageRes, err := h.postSvc.FindAll(r.Context(), listPostReq)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	httputil.RespondWithJSON(w, http.StatusOK, httputil.ApiResponse{
+		Error:      false,
+		Message:    "OK",
+		Data:       posts,
+		Pagination: pageRes,
+	})
+}
+
+// FindAllByUser lists the posts of the user in the path.
+func (h *postHandler) FindAllByUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := util.StringToInt64(mux.Vars(r)["id"])
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("param id not valid")
+		httputil.RespondWithErr(w, r, fmt.Errorf("%w: id", modelErr.ErrBadParamInput))
+		return
+	}
+
+	listPostReq, err := parseListPostReq(r.URL.Query(), false)
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("query param not valid")
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	err = h.validate.Struct(listPostReq, r.Header.Get("Accept-Language"))
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("error validator")
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	posts, pageRes, err := h.postSvc.FindAllByUser(r.Context(), userID, listPostReq)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	httputil.RespondWithJSON(w, http.StatusOK, httputil.ApiResponse{
+		Error:      false,
+		Message:    "OK",
+		Data:       posts,
+		Pagination: pageRes,
+	})
+}
+
+func (h *postHandler) FindByID(w http.ResponseWriter, r *http.Request) {
+	id, err := util.StringToInt64(mux.Vars(r)["id"])
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("param id not valid")
+		httputil.RespondWithErr(w, r, fmt.Errorf("%w: id", modelErr.ErrBadParamInput))
+		return
+	}
+
+	post, err := h.postSvc.FindByID(r.Context(), id)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	httputil.RespondWithJSON(w, http.StatusOK, httputil.ApiResponse{
+		Error:   false,
+		Message: "OK",
+		Data:    post,
+	})
+}
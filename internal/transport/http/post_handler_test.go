@@ -0,0 +1,375 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/domain/mocks"
+	"go-rest-api-boilerplate/internal/model/auth"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/model/reqres"
+	"go-rest-api-boilerplate/pkg/httputil"
+)
+
+func TestPostHandler_Create(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockPostSvc := mocks.NewPostService(t)
+		mockPostSvc.On("Create", mock.Anything, &reqres.CreatePostReq{UserID: 1, Title: "hello", Content: "world"}).
+			Return(&domain.Post{ID: 3, UserID: 1, Title: "hello", Content: "world"}, nil)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/post", strings.NewReader(`{"user_id":1,"title":"hello","content":"world"}`))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		handler := postHandler{postSvc: mockPostSvc, validate: testValidator}
+		handler.Create(w, req)
+
+		var response struct {
+			Data domain.Post `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "/api/v1/post/3", w.Header().Get("Location"))
+		assert.Equal(t, int64(3), response.Data.ID)
+	})
+
+	t.Run("error:validator", func(t *testing.T) {
+		mockPostSvc := mocks.NewPostService(t)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/post", strings.NewReader(`{"user_id":1,"content":"world"}`))
+		assert.NoError(t, err)
+
+		handler := postHandler{postSvc: mockPostSvc, validate: testValidator}
+		handler.Create(w, req)
+
+		var response httputil.Problem
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "title", response.Errors[0].Field)
+	})
+
+	t.Run("error:unknown author", func(t *testing.T) {
+		mockPostSvc := mocks.NewPostService(t)
+		mockPostSvc.On("Create", mock.Anything, mock.AnythingOfType("*reqres.CreatePostReq")).
+			Return(nil, &modelErr.FieldError{Field: "user_id", Rule: "exists", Err: modelErr.ErrBadParamInput})
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/post", strings.NewReader(`{"user_id":9,"title":"hello","content":"world"}`))
+		assert.NoError(t, err)
+
+		handler := postHandler{postSvc: mockPostSvc, validate: testValidator}
+		handler.Create(w, req)
+
+		var response httputil.Problem
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "user_id does not exist", response.Errors[0].Message)
+	})
+}
+
+func TestPostHandler_UpdateByID(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockPostSvc := mocks.NewPostService(t)
+		mockPostSvc.On("UpdateByID", mock.Anything, int64(1), &reqres.UpdatePostReq{Title: "hello", Content: "world"}).
+			Return(&domain.Post{ID: 1, Title: "hello", Content: "world"}, nil)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPut, "/api/v1/post/1", strings.NewReader(`{"title":"hello","content":"world"}`))
+		assert.NoError(t, err)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := postHandler{postSvc: mockPostSvc, validate: testValidator}
+		handler.UpdateByID(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("error:not found", func(t *testing.T) {
+		mockPostSvc := mocks.NewPostService(t)
+		mockPostSvc.On("UpdateByID", mock.Anything, int64(1), mock.AnythingOfType("*reqres.UpdatePostReq")).
+			Return(nil, modelErr.ErrNotFound)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPut, "/api/v1/post/1", strings.NewReader(`{"title":"hello","content":"world"}`))
+		assert.NoError(t, err)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := postHandler{postSvc: mockPostSvc, validate: testValidator}
+		handler.UpdateByID(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestPostHandler_DeleteByID(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockPostSvc := mocks.NewPostService(t)
+		mockPostSvc.On("DeleteByID", mock.Anything, int64(1)).Return(nil)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodDelete, "/api/v1/post/1", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := postHandler{postSvc: mockPostSvc, validate: testValidator}
+		handler.DeleteByID(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("error:param id", func(t *testing.T) {
+		mockPostSvc := mocks.NewPostService(t)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodDelete, "/api/v1/post/x", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "x"})
+		handler := postHandler{postSvc: mockPostSvc, validate: testValidator}
+		handler.DeleteByID(w, req)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+}
+
+func TestPostHandler_FindAll(t *testing.T) {
+	posts := []domain.Post{{ID: 1, UserID: 2, Title: "hello"}}
+
+	t.Run("success", func(t *testing.T) {
+		mockPostSvc := mocks.NewPostService(t)
+		mockPostSvc.On("FindAll", mock.Anything, &reqres.ListPostReq{PageReq: reqres.PageReq{Limit: 5}, UserID: 2}).
+			Return(&posts, &reqres.PageRes{}, nil)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/post?limit=5&user_id=2", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		handler := postHandler{postSvc: mockPostSvc, validate: testValidator}
+		handler.FindAll(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("error:query param", func(t *testing.T) {
+		for _, c := range []string{"user_id=x", "user_id=0", "limit=0", "sort=id"} {
+			mockPostSvc := mocks.NewPostService(t)
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/post?"+c, strings.NewReader(""))
+			assert.NoError(t, err)
+
+			handler := postHandler{postSvc: mockPostSvc, validate: testValidator}
+			handler.FindAll(w, req)
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, c)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockPostSvc := mocks.NewPostService(t)
+		mockPostSvc.On("FindAll", mock.Anything, mock.AnythingOfType("*reqres.ListPostReq")).
+			Return(nil, nil, errors.New("Unexpexted Error"))
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/post", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		handler := postHandler{postSvc: mockPostSvc, validate: testValidator}
+		handler.FindAll(w, req)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestPostHandler_FindAllByUser(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		posts := []domain.Post{{ID: 1, UserID: 2, Title: "hello"}}
+		mockPostSvc := mocks.NewPostService(t)
+		mockPostSvc.On("FindAllByUser", mock.Anything, int64(2), &reqres.ListPostReq{PageReq: reqres.PageReq{Limit: reqres.DefaultPageLimit}}).
+			Return(&posts, &reqres.PageRes{}, nil)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/user/2/posts", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "2"})
+		handler := postHandler{postSvc: mockPostSvc, validate: testValidator}
+		handler.FindAllByUser(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("error:user_id param", func(t *testing.T) {
+		mockPostSvc := mocks.NewPostService(t)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/user/2/posts?user_id=3", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "2"})
+		handler := postHandler{postSvc: mockPostSvc, validate: testValidator}
+		handler.FindAllByUser(w, req)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("error:user not found", func(t *testing.T) {
+		mockPostSvc := mocks.NewPostService(t)
+		mockPostSvc.On("FindAllByUser", mock.Anything, int64(2), mock.AnythingOfType("*reqres.ListPostReq")).
+			Return(nil, nil, modelErr.ErrNotFound)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/user/2/posts", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "2"})
+		handler := postHandler{postSvc: mockPostSvc, validate: testValidator}
+		handler.FindAllByUser(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestPostHandler_FindByID(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockPostSvc := mocks.NewPostService(t)
+		mockPostSvc.On("FindByID", mock.Anything, int64(1)).Return(&domain.Post{ID: 1, Title: "hello"}, nil)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/post/1", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := postHandler{postSvc: mockPostSvc, validate: testValidator}
+		handler.FindByID(w, req)
+
+		var response struct {
+			Data domain.Post `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "hello", response.Data.Title)
+	})
+
+	t.Run("error:not found", func(t *testing.T) {
+		mockPostSvc := mocks.NewPostService(t)
+		mockPostSvc.On("FindByID", mock.Anything, int64(1)).Return(nil, modelErr.ErrNotFound)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/post/1", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := postHandler{postSvc: mockPostSvc, validate: testValidator}
+		handler.FindByID(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestPostHandler_Authorization(t *testing.T) {
+	routes := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		mock   func(m *mocks.PostService)
+	}{
+		{
+			name: "Create", method: http.MethodPost, path: "/api/v1/post", body: `{"user_id":2,"title":"hello","content":"world"}`,
+			mock: func(m *mocks.PostService) {
+				m.On("Create", mock.Anything, mock.AnythingOfType("*reqres.CreatePostReq")).Return(&domain.Post{ID: 1, UserID: 2}, nil)
+			},
+		},
+		{
+			name: "FindAll", method: http.MethodGet, path: "/api/v1/post",
+			mock: func(m *mocks.PostService) {
+				m.On("FindAll", mock.Anything, mock.AnythingOfType("*reqres.ListPostReq")).Return(&[]domain.Post{}, &reqres.PageRes{}, nil)
+			},
+		},
+		{
+			name: "FindByID", method: http.MethodGet, path: "/api/v1/post/1",
+			mock: func(m *mocks.PostService) {
+				m.On("FindByID", mock.Anything, int64(1)).Return(&domain.Post{ID: 1}, nil)
+			},
+		},
+		{
+			name: "UpdateByID", method: http.MethodPut, path: "/api/v1/post/1", body: `{"title":"hello","content":"world"}`,
+			mock: func(m *mocks.PostService) {
+				m.On("UpdateByID", mock.Anything, int64(1), mock.AnythingOfType("*reqres.UpdatePostReq")).Return(&domain.Post{ID: 1}, nil)
+			},
+		},
+		{
+			name: "DeleteByID", method: http.MethodDelete, path: "/api/v1/post/1",
+			mock: func(m *mocks.PostService) {
+				m.On("DeleteByID", mock.Anything, int64(1)).Return(nil)
+			},
+		},
+		{
+			name: "FindAllByUser", method: http.MethodGet, path: "/api/v1/user/2/posts",
+			mock: func(m *mocks.PostService) {
+				m.On("FindAllByUser", mock.Anything, int64(2), mock.AnythingOfType("*reqres.ListPostReq")).Return(&[]domain.Post{}, &reqres.PageRes{}, nil)
+			},
+		},
+	}
+
+	// allowed lists the routes each caller passes, the others are answered with 403.
+	all := map[string]bool{"Create": true, "FindAll": true, "FindByID": true, "UpdateByID": true, "DeleteByID": true, "FindAllByUser": true}
+	callers := []struct {
+		name      string
+		principal *auth.Principal
+		allowed   map[string]bool
+	}{
+		{name: "admin", principal: &auth.Principal{Subject: "1", Roles: []auth.Role{auth.RoleAdmin}}, allowed: all},
+		{name: "user", principal: &auth.Principal{Subject: "2", Roles: []auth.Role{auth.RoleUser}}, allowed: all},
+		{name: "read scope", principal: &auth.Principal{Subject: "svc", Scopes: []auth.Permission{auth.PermPostRead}}, allowed: map[string]bool{"FindByID": true}},
+		{name: "no role", principal: &auth.Principal{Subject: "2"}, allowed: map[string]bool{}},
+	}
+
+	serve := func(svc domain.PostService, principal *auth.Principal, method, path, body string) *httptest.ResponseRecorder {
+		r := mux.NewRouter()
+		NewPostHandlerRegister(r, svc, testValidator)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, route := range routes {
+		for _, c := range callers {
+			t.Run(route.name+"/"+c.name, func(t *testing.T) {
+				mockPostSvc := mocks.NewPostService(t)
+				if c.allowed[route.name] {
+					route.mock(mockPostSvc)
+				}
+
+				w := serve(mockPostSvc, c.principal, route.method, route.path, route.body)
+				if c.allowed[route.name] {
+					assert.Less(t, w.Code, 300)
+					return
+				}
+
+				var problem httputil.Problem
+				json.NewDecoder(w.Body).Decode(&problem)
+				assert.Equal(t, http.StatusForbidden, w.Code)
+				assert.Equal(t, httputil.CodeForbidden, problem.Code)
+			})
+		}
+
+		t.Run(route.name+"/unauthenticated", func(t *testing.T) {
+			w := serve(mocks.NewPostService(t), nil, route.method, route.path, route.body)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+
+	t.Run("ownership error from the service", func(t *testing.T) {
+		mockPostSvc := mocks.NewPostService(t)
+		mockPostSvc.On("DeleteByID", mock.Anything, int64(1)).Return(modelErr.ErrForbidden)
+
+		w := serve(mockPostSvc, &auth.Principal{Subject: "2", Roles: []auth.Role{auth.RoleUser}}, http.MethodDelete, "/api/v1/post/1", "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,180 @@
+package http
+
+import (
+	"net/url"
+	"strconv"
+
+	"go-rest-api-boilerplate/internal/model/reqres"
+	"go-rest-api-boilerplate/internal/model/userfile"
+	"go-rest-api-boilerplate/pkg/httputil"
+	"go-rest-api-boilerplate/pkg/validation"
+)
+
+var listUserParams = map[string]bool{
+	"limit": true, "offset": true, "cursor": true, "with_total": true,
+	"email": true, "first_name": true, "last_name": true, "q": true, "sort": true, "include_deleted": true,
+}
+
+// parsePageReq reads limit, offset, cursor and with_total from the query string.
+func parsePageReq(q url.Values) (*reqres.PageReq, error) {
+	page := reqres.PageReq{Limit: reqres.DefaultPageLimit}
+
+	var errs validation.Errors
+	var err error
+	if v := q.Get("limit"); v != "" {
+		page.Limit, err = strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, queryParamError("limit", "number", "limit must be a number"))
+		}
+	}
+
+	if v := q.Get("offset"); v != "" {
+		page.Offset, err = strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, queryParamError("offset", "number", "offset must be a number"))
+		}
+	}
+
+	if v := q.Get("with_total"); v != "" {
+		page.WithTotal, err = strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, queryParamError("with_total", "boolean", "with_total must be a boolean"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	page.Cursor = q.Get("cursor")
+	return &page, nil
+}
+
+// checkQueryParams rejects the params that are not known instead of silently ignoring them.
+func checkQueryParams(q url.Values, known map[string]bool) error {
+	var errs validation.Errors
+	for k := range q {
+		if !known[k] {
+			errs = append(errs, queryParamError(k, "unknown", k+" is not a known query param"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// parseListUserReq reads the user list query.
+func parseListUserReq(q url.Values) (*reqres.ListUserReq, error) {
+	err := checkQueryParams(q, listUserParams)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := parsePageReq(q)
+	if err != nil {
+		return nil, err
+	}
+
+	includeDeleted, err := parseIncludeDeleted(q)
+	if err != nil {
+		return nil, err
+	}
+
+	req := reqres.ListUserReq{
+		PageReq:   *page,
+		Email:     q.Get("email"),
+		FirstName: q.Get("first_name"),
+		LastName:  q.Get("last_name"),
+		Q:         q.Get("q"),
+		Sort:      q.Get("sort"),
+
+		IncludeDeleted: includeDeleted,
+	}
+
+	if req.Cursor != "" {
+		cursor, err := reqres.DecodeCursor(req.Cursor)
+		if err != nil || cursor.Sort != req.Sort {
+			return nil, validation.Errors{queryParamError("cursor", "cursor", "cursor is not valid for this query")}
+		}
+	}
+
+	return &req, nil
+}
+
+var exportUserParams = map[string]bool{
+	"format": true, "email": true, "first_name": true, "last_name": true, "q": true, "include_deleted": true,
+}
+
+// parseExportUserReq reads the user export query, the format defaults to csv.
+func parseExportUserReq(q url.Values) (*reqres.ExportUserReq, error) {
+	err := checkQueryParams(q, exportUserParams)
+	if err != nil {
+		return nil, err
+	}
+
+	includeDeleted, err := parseIncludeDeleted(q)
+	if err != nil {
+		return nil, err
+	}
+
+	req := reqres.ExportUserReq{
+		Format:         q.Get("format"),
+		Email:          q.Get("email"),
+		FirstName:      q.Get("first_name"),
+		LastName:       q.Get("last_name"),
+		Q:              q.Get("q"),
+		IncludeDeleted: includeDeleted,
+	}
+	if req.Format == "" {
+		req.Format = userfile.FormatCSV
+	}
+
+	return &req, nil
+}
+
+// parseListPostReq reads the post list query, user_id is only known on /post, the nested
+// /user/{id}/posts list takes the user from the path.
+func parseListPostReq(q url.Values, withUserID bool) (*reqres.ListPostReq, error) {
+	known := map[string]bool{"limit": true, "offset": true, "cursor": true, "with_total": true, "user_id": withUserID}
+	err := checkQueryParams(q, known)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := parsePageReq(q)
+	if err != nil {
+		return nil, err
+	}
+
+	req := reqres.ListPostReq{PageReq: *page}
+	if v := q.Get("user_id"); v != "" {
+		req.UserID, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || req.UserID < 1 {
+			return nil, validation.Errors{queryParamError("user_id", "number", "user_id must be a positive number")}
+		}
+	}
+
+	return &req, nil
+}
+
+// parseIncludeDeleted reads the include_deleted flag that lets soft deleted users show up.
+func parseIncludeDeleted(q url.Values) (bool, error) {
+	v := q.Get("include_deleted")
+	if v == "" {
+		return false, nil
+	}
+
+	includeDeleted, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, validation.Errors{queryParamError("include_deleted", "boolean", "include_deleted must be a boolean")}
+	}
+
+	return includeDeleted, nil
+}
+
+func queryParamError(field, rule, message string) httputil.FieldError {
+	return httputil.FieldError{Field: field, Rule: rule, Message: message}
+}
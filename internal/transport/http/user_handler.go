@@ -1,61 +1,134 @@
 package http
 
 import (
-	"database/sql"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/config"
 	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/model/auth"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
 	"go-rest-api-boilerplate/internal/model/reqres"
+	"go-rest-api-boilerplate/internal/model/userfile"
+	"go-rest-api-boilerplate/internal/transport/http/middleware"
 	"go-rest-api-boilerplate/pkg/httputil"
 	"go-rest-api-boilerplate/pkg/util"
+	"go-rest-api-boilerplate/pkg/validation"
 )
 
 type userHandler struct {
-	userSvc domain.UserService
+	userSvc  domain.UserService
+	validate *validation.Validator
+	// batchMaxSize caps the operations of a batch, 0 for no limit.
+	batchMaxSize int
 }
 
-func NewUserHandlerRegister(r *mux.Router, service domain.UserService) {
-	handler := userHandler{userSvc: service}
+func NewUserHandlerRegister(r *mux.Router, service domain.UserService, validate *validation.Validator) {
+	handler := userHandler{userSvc: service, validate: validate, batchMaxSize: config.App.UserBatchMaxSize}
 	v1 := r.PathPrefix("/api/v1").Subrouter()
 	{
-		v1.HandleFunc("/user", handler.Create).Methods(http.MethodPost)
-		v1.HandleFunc("/user", handler.FindAll).Methods(http.MethodGet)
-		v1.HandleFunc("/user/{id}", handler.FindByID).Methods(http.MethodGet)
-		v1.HandleFunc("/user/{id}", handler.DeleteByID).Methods(http.MethodDelete)
-		v1.HandleFunc("/user/{id}", handler.UpdateByID).Methods(http.MethodPatch)
+		v1.Handle("/user", authorize(auth.PermUserCreate, handler.Create)).Methods(http.MethodPost)
+		v1.Handle("/user:batch", middleware.RequireAny(auth.PermUserCreate, auth.PermUserUpdate, auth.PermUserDelete)(http.HandlerFunc(handler.Batch))).
+			Methods(http.MethodPost)
+		v1.Handle("/user", authorize(auth.PermUserList, handler.FindAll)).Methods(http.MethodGet)
+		// Registered before /user/{id}, which would take export for an id.
+		v1.Handle("/user/export", authorize(auth.PermUserList, handler.Export)).Methods(http.MethodGet)
+		v1.Handle("/user/{id}", authorize(auth.PermUserRead, handler.FindByID)).Methods(http.MethodGet)
+		v1.Handle("/user/{id}", authorize(auth.PermUserDelete, handler.DeleteByID)).Methods(http.MethodDelete)
+		v1.Handle("/user/{id}", authorize(auth.PermUserUpdate, handler.UpdateByID)).Methods(http.MethodPut)
+		v1.Handle("/user/{id}", authorize(auth.PermUserUpdate, handler.PatchByID)).Methods(http.MethodPatch)
+		v1.Handle("/user/{id}/restore", authorize(auth.PermUserRestore, handler.Restore)).Methods(http.MethodPost)
 	}
 }
 
+// authorize guards a route with the permission it requires. The user and post services further restrict
+// the routes to the caller's own records unless the caller has auth.PermUserAny or auth.PermPostAny.
+func authorize(perm auth.Permission, h http.HandlerFunc) http.Handler {
+	return middleware.Require(perm)(h)
+}
+
+// userETag is the strong entity tag of a user, its version.
+func userETag(user *domain.User) string {
+	return httputil.ETag(strconv.FormatInt(user.Version, 10))
+}
+
+// ifMatchVersion reads the version a write is conditioned on from the If-Match header, 0 without a
+// condition or for "*". Only the single ETag of a user is understood, anything else cannot match the
+// current version and fails with ErrPreconditionFailed.
+func ifMatchVersion(r *http.Request) (int64, error) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, nil
+	}
+
+	tags := httputil.ParseETags(header)
+	if len(tags) == 1 && tags[0] == "*" {
+		return 0, nil
+	}
+
+	if len(tags) == 1 && len(tags[0]) > 2 && strings.HasPrefix(tags[0], `"`) && strings.HasSuffix(tags[0], `"`) {
+		version, err := strconv.ParseInt(tags[0][1:len(tags[0])-1], 10, 64)
+		if err == nil && version > 0 {
+			return version, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: If-Match %s is not the ETag of a user", modelErr.ErrPreconditionFailed, header)
+}
+
+// userLocation is the URL of a user resource, sent in the Location header of a created user.
+func userLocation(id int64) string {
+	return fmt.Sprintf("/api/v1/user/%d", id)
+}
+
+// userWithFirstPost is the user created along with its first post.
+type userWithFirstPost struct {
+	*domain.User
+	FirstPost *domain.Post `json:"first_post"`
+}
+
 func (h *userHandler) Create(w http.ResponseWriter, r *http.Request) {
-	var createUserReq reqres.CreateUserReq
-	err := json.NewDecoder(r.Body).Decode(&createUserReq)
+	var createUserReq reqres.CreateUserWithPostReq
+	err := httputil.DecodeJSON(r, &createUserReq)
 	if err != nil {
 		log.WithContext(r.Context()).WithError(err).Warn("error decoding json payload")
-		httputil.RespondWithError(w, http.StatusUnprocessableEntity, "cannot receive the payload schema")
+		httputil.RespondWithErr(w, r, err)
 		return
 	}
 
-	err = validator.New().Struct(&createUserReq)
+	err = h.validate.Struct(&createUserReq, r.Header.Get("Accept-Language"))
 	if err != nil {
 		log.WithContext(r.Context()).WithError(err).Warn("error validator")
-		httputil.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		httputil.RespondWithErr(w, r, err)
 		return
 	}
 
-	err = h.userSvc.Create(r.Context(), &createUserReq)
+	var user *domain.User
+	var data interface{}
+	if createUserReq.FirstPost != nil {
+		var post *domain.Post
+		user, post, err = h.userSvc.CreateWithFirstPost(r.Context(), &createUserReq.CreateUserReq, createUserReq.FirstPost)
+		data = userWithFirstPost{User: user, FirstPost: post}
+	} else {
+		user, err = h.userSvc.Create(r.Context(), &createUserReq.CreateUserReq)
+		data = user
+	}
 	if err != nil {
-		httputil.RespondWithError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		httputil.RespondWithErr(w, r, err)
 		return
 	}
 
-	httputil.RespondWithJSON(w, http.StatusOK, httputil.ApiResponse{
+	w.Header().Set("Location", userLocation(user.ID))
+	w.Header().Set("ETag", userETag(user))
+	httputil.RespondWithJSON(w, http.StatusCreated, httputil.ApiResponse{
 		Error:   false,
-		Message: "OK",
-		Data:    nil,
+		Message: "Created",
+		Data:    data,
 	})
 }
 
@@ -63,35 +136,86 @@ func (h *userHandler) UpdateByID(w http.ResponseWriter, r *http.Request) {
 	id, err := util.StringToInt64(mux.Vars(r)["id"])
 	if err != nil {
 		log.WithContext(r.Context()).WithError(err).Warn("param id not valid")
-		httputil.RespondWithError(w, http.StatusUnprocessableEntity, "id not valid")
+		httputil.RespondWithErr(w, r, fmt.Errorf("%w: id", modelErr.ErrBadParamInput))
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
 		return
 	}
 
 	var updateUserReq reqres.UpdateUserReq
-	err = json.NewDecoder(r.Body).Decode(&updateUserReq)
+	err = httputil.DecodeJSON(r, &updateUserReq)
 	if err != nil {
 		log.WithContext(r.Context()).WithError(err).Warn("error decoding json payload")
-		httputil.RespondWithError(w, http.StatusUnprocessableEntity, "cannot receive the payload schema")
+		httputil.RespondWithErr(w, r, err)
 		return
 	}
 
-	err = validator.New().Struct(&updateUserReq)
+	err = h.validate.Struct(&updateUserReq, r.Header.Get("Accept-Language"))
 	if err != nil {
 		log.WithContext(r.Context()).WithError(err).Warn("error validator")
-		httputil.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		httputil.RespondWithErr(w, r, err)
 		return
 	}
 
-	err = h.userSvc.UpdateByID(r.Context(), id, &updateUserReq)
+	user, err := h.userSvc.UpdateByID(r.Context(), id, version, &updateUserReq)
 	if err != nil {
-		httputil.RespondWithError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		httputil.RespondWithErr(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", userETag(user))
 	httputil.RespondWithJSON(w, http.StatusOK, httputil.ApiResponse{
 		Error:   false,
 		Message: "OK",
-		Data:    nil,
+		Data:    user,
+	})
+}
+
+// PatchByID applies a JSON merge patch (RFC 7396), sent as application/merge-patch+json or application/json.
+func (h *userHandler) PatchByID(w http.ResponseWriter, r *http.Request) {
+	id, err := util.StringToInt64(mux.Vars(r)["id"])
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("param id not valid")
+		httputil.RespondWithErr(w, r, fmt.Errorf("%w: id", modelErr.ErrBadParamInput))
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	var patchUserReq reqres.PatchUserReq
+	err = httputil.DecodeJSON(r, &patchUserReq, httputil.ContentTypeMergePatchJSON)
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("error decoding json payload")
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	err = h.validate.Struct(&patchUserReq, r.Header.Get("Accept-Language"))
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("error validator")
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	user, err := h.userSvc.PatchByID(r.Context(), id, version, &patchUserReq)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", userETag(user))
+	httputil.RespondWithJSON(w, http.StatusOK, httputil.ApiResponse{
+		Error:   false,
+		Message: "OK",
+		Data:    user,
 	})
 }
 
@@ -99,13 +223,19 @@ func (h *userHandler) DeleteByID(w http.ResponseWriter, r *http.Request) {
 	id, err := util.StringToInt64(mux.Vars(r)["id"])
 	if err != nil {
 		log.WithContext(r.Context()).WithError(err).Warn("param id not valid")
-		httputil.RespondWithError(w, http.StatusUnprocessableEntity, "id not valid")
+		httputil.RespondWithErr(w, r, fmt.Errorf("%w: id", modelErr.ErrBadParamInput))
 		return
 	}
 
-	err = h.userSvc.DeleteByID(r.Context(), id)
+	version, err := ifMatchVersion(r)
 	if err != nil {
-		httputil.RespondWithError(w, http.StatusInternalServerError, "")
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	err = h.userSvc.DeleteByID(r.Context(), id, version)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
 		return
 	}
 
@@ -116,36 +246,282 @@ func (h *userHandler) DeleteByID(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (h *userHandler) FindAll(w http.ResponseWriter, r *http.Request) {
-	users, err := h.userSvc.FindAll(r.Context())
+// Restore brings back a soft deleted user.
+func (h *userHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id, err := util.StringToInt64(mux.Vars(r)["id"])
 	if err != nil {
-		httputil.RespondWithError(w, http.StatusInternalServerError, "")
+		log.WithContext(r.Context()).WithError(err).Warn("param id not valid")
+		httputil.RespondWithErr(w, r, fmt.Errorf("%w: id", modelErr.ErrBadParamInput))
 		return
 	}
 
+	user, err := h.userSvc.Restore(r.Context(), id)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", userETag(user))
 	httputil.RespondWithJSON(w, http.StatusOK, httputil.ApiResponse{
 		Error:   false,
 		Message: "OK",
-		Data:    users,
+		Data:    user,
+	})
+}
+
+// batchUserRes is the outcome of one operation of a batch, its Status and the user or the error it
+// would have been answered with on its own.
+type batchUserRes struct {
+	Index   int                   `json:"index"`
+	Status  int                   `json:"status"`
+	Code    string                `json:"code,omitempty"`
+	Message string                `json:"message,omitempty"`
+	Errors  []httputil.FieldError `json:"errors,omitempty"`
+	Data    *domain.User          `json:"data,omitempty"`
+}
+
+// batchOpStatus is the status of a successful operation of a batch.
+var batchOpStatus = map[string]int{
+	reqres.BatchOpCreate: http.StatusCreated,
+	reqres.BatchOpUpdate: http.StatusOK,
+	reqres.BatchOpDelete: http.StatusOK,
+}
+
+// Batch applies a batch of user operations. An atomic batch answers 200 with the result of every
+// operation, or the error of the first failed one. Otherwise the operations failing validation are left
+// out and the batch answers 207 with the status of each operation.
+func (h *userHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	var batchReq reqres.BatchUserReq
+	err := httputil.DecodeJSON(r, &batchReq)
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("error decoding json payload")
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	if len(batchReq.Operations) == 0 {
+		httputil.RespondWithErr(w, r, validation.Errors{{
+			Field:   "operations",
+			Rule:    "required",
+			Message: "operations must hold at least 1 operation",
+		}})
+		return
+	}
+	if h.batchMaxSize > 0 && len(batchReq.Operations) > h.batchMaxSize {
+		httputil.RespondWithErr(w, r, validation.Errors{{
+			Field:   "operations",
+			Rule:    "max",
+			Message: fmt.Sprintf("operations must hold at most %d operations", h.batchMaxSize),
+		}})
+		return
+	}
+
+	res := make([]batchUserRes, len(batchReq.Operations))
+	validReq := reqres.BatchUserReq{Atomic: batchReq.Atomic}
+	var indexes []int
+	var fieldErrs validation.Errors
+	for i := range batchReq.Operations {
+		err = h.validate.Struct(&batchReq.Operations[i], r.Header.Get("Accept-Language"))
+		if err != nil {
+			res[i] = newBatchUserRes(r, i, "", nil, err)
+			fieldErrs = append(fieldErrs, batchFieldErrors(i, err)...)
+			continue
+		}
+
+		validReq.Operations = append(validReq.Operations, batchReq.Operations[i])
+		indexes = append(indexes, i)
+	}
+
+	if batchReq.Atomic && len(fieldErrs) > 0 {
+		log.WithContext(r.Context()).Warn("error validator")
+		httputil.RespondWithErr(w, r, fieldErrs)
+		return
+	}
+
+	if len(validReq.Operations) > 0 {
+		results, err := h.userSvc.Batch(r.Context(), &validReq)
+		if err != nil {
+			httputil.RespondWithErr(w, r, err)
+			return
+		}
+
+		for j, result := range results {
+			res[indexes[j]] = newBatchUserRes(r, indexes[j], validReq.Operations[j].Op, result.User, result.Err)
+		}
+	}
+
+	status := http.StatusMultiStatus
+	if batchReq.Atomic {
+		status = http.StatusOK
+	}
+	httputil.RespondWithJSON(w, status, httputil.ApiResponse{
+		Error:   false,
+		Message: http.StatusText(status),
+		Data:    res,
+	})
+}
+
+func newBatchUserRes(r *http.Request, index int, op string, user *domain.User, err error) batchUserRes {
+	if err == nil {
+		return batchUserRes{Index: index, Status: batchOpStatus[op], Data: user}
+	}
+
+	status, code := httputil.ErrorStatus(err)
+	if status >= http.StatusInternalServerError {
+		log.WithContext(r.Context()).WithError(err).Errorf("internal error on operation %d of a batch", index)
+	}
+	message, fieldErrs := httputil.ErrorMessage(err)
+
+	return batchUserRes{Index: index, Status: status, Code: code, Message: message, Errors: fieldErrs}
+}
+
+// batchFieldErrors names the fields of the validation errors of an operation as operations[i].field.
+func batchFieldErrors(index int, err error) validation.Errors {
+	var fieldErrorer httputil.FieldErrorer
+	if !errors.As(err, &fieldErrorer) {
+		return validation.Errors{{Field: fmt.Sprintf("operations[%d]", index), Message: err.Error()}}
+	}
+
+	fieldErrs := fieldErrorer.FieldErrors()
+	errs := make(validation.Errors, len(fieldErrs))
+	for i, fieldErr := range fieldErrs {
+		fieldErr.Field = fmt.Sprintf("operations[%d].%s", index, fieldErr.Field)
+		errs[i] = fieldErr
+	}
+
+	return errs
+}
+
+func (h *userHandler) FindAll(w http.ResponseWriter, r *http.Request) {
+	listUserReq, err := parseListUserReq(r.URL.Query())
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("query param not valid")
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	err = h.validate.Struct(listUserReq, r.Header.Get("Accept-Language"))
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("error validator")
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	users, pageRes, err := h.userSvc.FindAll(r.Context(), listUserReq)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	httputil.RespondWithJSON(w, http.StatusOK, httputil.ApiResponse{
+		Error:      false,
+		Message:    "OK",
+		Data:       users,
+		Pagination: pageRes,
 	})
 }
 
+// exportFlushRows is how many users an export buffers before sending them to the client.
+const exportFlushRows = 500
+
+// Export streams the users as a CSV or NDJSON file, the users are sent as they are read instead of being
+// held in memory. A failure once the file has been partly sent aborts the response, so the client cannot
+// take a cut file for a complete one.
+func (h *userHandler) Export(w http.ResponseWriter, r *http.Request) {
+	exportUserReq, err := parseExportUserReq(r.URL.Query())
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("query param not valid")
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	err = h.validate.Struct(exportUserReq, r.Header.Get("Accept-Language"))
+	if err != nil {
+		log.WithContext(r.Context()).WithError(err).Warn("error validator")
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	sent := &sentWriter{ResponseWriter: w}
+	file, err := userfile.NewWriter(sent, exportUserReq.Format)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", userfile.ContentTypes[exportUserReq.Format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="users.%s"`, exportUserReq.Format))
+
+	flusher, _ := w.(http.Flusher)
+	n := 0
+	err = h.userSvc.Export(r.Context(), exportUserReq.ListUserReq(), func(user *domain.User) error {
+		if err := file.Write(user); err != nil {
+			return err
+		}
+
+		n++
+		if n%exportFlushRows != 0 {
+			return nil
+		}
+		if err := file.Flush(); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = file.Flush()
+	}
+	if err == nil {
+		return
+	}
+
+	if !sent.sent {
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	log.WithContext(r.Context()).WithError(err).Errorf("user export aborted after %d users", n)
+	panic(http.ErrAbortHandler)
+}
+
+// sentWriter tells whether a body has started to be sent.
+type sentWriter struct {
+	http.ResponseWriter
+	sent bool
+}
+
+func (w *sentWriter) Write(b []byte) (int, error) {
+	w.sent = true
+	return w.ResponseWriter.Write(b)
+}
+
 func (h *userHandler) FindByID(w http.ResponseWriter, r *http.Request) {
 	id, err := util.StringToInt64(mux.Vars(r)["id"])
 	if err != nil {
 		log.WithContext(r.Context()).WithError(err).Warn("param id not valid")
-		httputil.RespondWithError(w, http.StatusUnprocessableEntity, "id not valid")
+		httputil.RespondWithErr(w, r, fmt.Errorf("%w: id", modelErr.ErrBadParamInput))
 		return
 	}
 
-	user, err := h.userSvc.FindByID(r.Context(), id)
+	includeDeleted, err := parseIncludeDeleted(r.URL.Query())
 	if err != nil {
-		status := http.StatusInternalServerError
-		if err == sql.ErrNoRows {
-			status = http.StatusNotFound
-		}
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
+
+	user, err := h.userSvc.FindByID(r.Context(), id, includeDeleted)
+	if err != nil {
+		httputil.RespondWithErr(w, r, err)
+		return
+	}
 
-		httputil.RespondWithError(w, status, "")
+	etag := userETag(user)
+	w.Header().Set("ETag", etag)
+	if httputil.IfNoneMatch(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
@@ -14,10 +14,14 @@ import (
 	"github.com/stretchr/testify/mock"
 	"go-rest-api-boilerplate/internal/domain"
 	"go-rest-api-boilerplate/internal/domain/mocks"
+	"go-rest-api-boilerplate/internal/model/auth"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
 	"go-rest-api-boilerplate/internal/model/reqres"
 	"go-rest-api-boilerplate/pkg/httputil"
 )
 
+var testValidator = reqres.NewValidator()
+
 func TestUserHandler_FindAll(t *testing.T) {
 	mockUsers := []domain.User{
 		{
@@ -34,13 +38,14 @@ func TestUserHandler_FindAll(t *testing.T) {
 		//r := mux.NewRouter()
 		mockUserSvc := mocks.NewUserService(t)
 		//NewUserHandlerRegister(r, mockUserSvc)
-		mockUserSvc.On("FindAll", mock.Anything).Return(&mockUsers, nil)
+		mockUserSvc.On("FindAll", mock.Anything, mock.AnythingOfType("*reqres.ListUserReq")).
+			Return(&mockUsers, &reqres.PageRes{HasMore: true, NextCursor: "next"}, nil)
 
 		w := httptest.NewRecorder()
 		req, err := http.NewRequest(http.MethodGet, "/api/v1/user", strings.NewReader(""))
 		assert.NoError(t, err)
 
-		handler := userHandler{userSvc: mockUserSvc}
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
 		handler.FindAll(w, req)
 		//r.ServeHTTP(w, req)
 
@@ -58,24 +63,190 @@ func TestUserHandler_FindAll(t *testing.T) {
 
 		assert.Equal(t, len(mockUsers), len(responseUsers))
 		assert.Equal(t, mockUsers[0].FirstName, responseUsers[0].FirstName)
+
+		b, err = json.Marshal(response.Pagination)
+		assert.NoError(t, err)
+
+		var page reqres.PageRes
+		err = json.Unmarshal(b, &page)
+		assert.NoError(t, err)
+		assert.True(t, page.HasMore)
+		assert.Equal(t, "next", page.NextCursor)
+	})
+
+	t.Run("success:filter and sort", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("FindAll", mock.Anything, &reqres.ListUserReq{
+			PageReq: reqres.PageReq{Limit: 5},
+			Email:   "john@email.local",
+			Q:       "jo",
+			Sort:    "-created_at",
+		}).Return(&mockUsers, &reqres.PageRes{}, nil)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/user?limit=5&email=john@email.local&q=jo&sort=-created_at", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.FindAll(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("success:include deleted", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("FindAll", mock.Anything, &reqres.ListUserReq{
+			PageReq:        reqres.PageReq{Limit: reqres.DefaultPageLimit},
+			IncludeDeleted: true,
+		}).Return(&mockUsers, &reqres.PageRes{}, nil)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/user?include_deleted=true", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.FindAll(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("error:query param", func(t *testing.T) {
+		cases := []string{
+			"limit=x", "limit=0", "limit=101", "offset=-1", "cursor=x", "offset=10&cursor=eyJ2IjoiIiwiaWQiOjF9",
+			"sort=password", "sort=-created_at&cursor=eyJ2IjoiIiwiaWQiOjF9", "password=x", "include_deleted=maybe",
+		}
+		for _, c := range cases {
+			mockUserSvc := mocks.NewUserService(t)
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/user?"+c, strings.NewReader(""))
+			assert.NoError(t, err)
+
+			handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+			handler.FindAll(w, req)
+
+			var response httputil.Problem
+			json.NewDecoder(w.Body).Decode(&response)
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, c)
+			assert.Equal(t, httputil.CodeValidationFailed, response.Code, c)
+			assert.NotEmpty(t, response.Errors, c)
+		}
 	})
 
 	t.Run("error", func(t *testing.T) {
 		mockUserSvc := mocks.NewUserService(t)
-		mockUserSvc.On("FindAll", mock.Anything).Return(nil, errors.New("Unexpexted Error"))
+		mockUserSvc.On("FindAll", mock.Anything, mock.AnythingOfType("*reqres.ListUserReq")).Return(nil, nil, errors.New("Unexpexted Error"))
 
 		w := httptest.NewRecorder()
 		req, err := http.NewRequest(http.MethodGet, "/api/v1/user", strings.NewReader(""))
 		assert.NoError(t, err)
+		req.Header.Set("Accept", httputil.ContentTypeProblemJSON)
 
-		handler := userHandler{userSvc: mockUserSvc}
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
 		handler.FindAll(w, req)
 
-		var response httputil.ApiResponse
+		var response httputil.Problem
 		json.NewDecoder(w.Body).Decode(&response)
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
-		assert.Equal(t, true, response.Error)
-		assert.Nil(t, response.Data)
+		assert.Equal(t, http.StatusInternalServerError, response.Status)
+		assert.Equal(t, httputil.CodeInternal, response.Code)
+	})
+}
+
+func TestUserHandler_Export(t *testing.T) {
+	created := time.Date(2022, 9, 1, 10, 0, 0, 0, time.UTC)
+	mockUsers := []domain.User{
+		{ID: 1, FirstName: "john", LastName: "due", Email: "john@email.local", CreatedAt: created, UpdatedAt: created, Version: 1},
+		{ID: 2, FirstName: "jane", Email: "jane@email.local", CreatedAt: created, UpdatedAt: created, DeletedAt: &created, Version: 2},
+	}
+	export := func(args mock.Arguments) {
+		fn := args.Get(2).(func(user *domain.User) error)
+		for i := range mockUsers {
+			_ = fn(&mockUsers[i])
+		}
+	}
+
+	t.Run("success:csv", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("Export", mock.Anything, &reqres.ListUserReq{Q: "email", IncludeDeleted: true}, mock.Anything).
+			Return(nil).Run(export)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/user/export?q=email&include_deleted=true", nil)
+
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.Export(w, req)
+
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="users.csv"`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "id,first_name,last_name,email,created_at,updated_at,deleted_at,version\n"+
+			"1,john,due,john@email.local,2022-09-01T10:00:00Z,2022-09-01T10:00:00Z,,1\n"+
+			"2,jane,,jane@email.local,2022-09-01T10:00:00Z,2022-09-01T10:00:00Z,2022-09-01T10:00:00Z,2\n", w.Body.String())
+	})
+
+	t.Run("success:ndjson", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("Export", mock.Anything, &reqres.ListUserReq{}, mock.Anything).Return(nil).Run(export)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/user/export?format=ndjson", nil)
+
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.Export(w, req)
+
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+		lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+		assert.Len(t, lines, 2)
+		var user domain.User
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), &user))
+		assert.Equal(t, mockUsers[1].Email, user.Email)
+	})
+
+	t.Run("error:unknown format", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/user/export?format=xml", nil)
+
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.Export(w, req)
+
+		var response httputil.ApiResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, 422, w.Code)
+		assert.Equal(t, "format", response.Errors[0].Field)
+	})
+
+	t.Run("error:before the first user", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("Export", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("Unexpexted Error"))
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/user/export?format=ndjson", nil)
+
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.Export(w, req)
+
+		assert.Equal(t, 500, w.Code)
+	})
+
+	t.Run("error:after the first user", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("Export", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("Unexpexted Error")).
+			Run(func(args mock.Arguments) {
+				fn := args.Get(2).(func(user *domain.User) error)
+				for i := 0; i < exportFlushRows; i++ {
+					_ = fn(&mockUsers[0])
+				}
+			})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/user/export", nil)
+
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() { handler.Export(w, req) })
+		assert.Equal(t, 200, w.Code)
 	})
 }
 
@@ -91,14 +262,14 @@ func TestUserHandler_FindByID(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		mockUserSvc := mocks.NewUserService(t)
-		mockUserSvc.On("FindByID", mock.Anything, mock.AnythingOfType("int64")).Return(&mockUser, nil)
+		mockUserSvc.On("FindByID", mock.Anything, mock.AnythingOfType("int64"), false).Return(&mockUser, nil)
 
 		w := httptest.NewRecorder()
 		req, err := http.NewRequest(http.MethodGet, "/api/v1/user/1", strings.NewReader(""))
 		assert.NoError(t, err)
 
 		req = mux.SetURLVars(req, map[string]string{"id": "1"})
-		handler := userHandler{userSvc: mockUserSvc}
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
 		handler.FindByID(w, req)
 
 		var response httputil.ApiResponse
@@ -117,26 +288,137 @@ func TestUserHandler_FindByID(t *testing.T) {
 		assert.Equal(t, mockUser.FirstName, resUser.FirstName)
 	})
 
+	t.Run("success:include deleted", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("FindByID", mock.Anything, int64(1), true).Return(&mockUser, nil)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/user/1?include_deleted=1", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.FindByID(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("success:etag", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("FindByID", mock.Anything, int64(1), false).Return(&domain.User{ID: 1, Version: 3}, nil)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/user/1", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.FindByID(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("success:not modified", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("FindByID", mock.Anything, int64(1), false).Return(&domain.User{ID: 1, Version: 3}, nil)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/user/1", strings.NewReader(""))
+		assert.NoError(t, err)
+		req.Header.Set("If-None-Match", `"2", "3"`)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.FindByID(w, req)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+		assert.Empty(t, w.Body.String())
+	})
+
 	t.Run("error", func(t *testing.T) {
 		mockUserSvc := mocks.NewUserService(t)
-		mockUserSvc.On("FindByID", mock.Anything, mock.AnythingOfType("int64")).Return(nil, errors.New("Unexpexted Error"))
+		mockUserSvc.On("FindByID", mock.Anything, mock.AnythingOfType("int64"), false).Return(nil, errors.New("Unexpexted Error"))
 
 		w := httptest.NewRecorder()
 		req, err := http.NewRequest(http.MethodGet, "/api/v1/user/1", strings.NewReader(""))
 		assert.NoError(t, err)
+		req.Header.Set("Accept", httputil.ContentTypeProblemJSON)
 
 		req = mux.SetURLVars(req, map[string]string{"id": "1"})
-		handler := userHandler{userSvc: mockUserSvc}
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
 		handler.FindByID(w, req)
 
-		var response httputil.ApiResponse
+		var response httputil.Problem
 		json.NewDecoder(w.Body).Decode(&response)
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
-		assert.Equal(t, true, response.Error)
-		assert.Nil(t, response.Data)
+		assert.Equal(t, http.StatusInternalServerError, response.Status)
+		assert.Equal(t, httputil.CodeInternal, response.Code)
 	})
 }
 
+func TestUserHandler_NotFound(t *testing.T) {
+	cases := []struct {
+		name    string
+		method  string
+		body    string
+		handler func(h *userHandler) http.HandlerFunc
+		mock    func(m *mocks.UserService)
+	}{
+		{
+			name:    "FindByID",
+			method:  http.MethodGet,
+			handler: func(h *userHandler) http.HandlerFunc { return h.FindByID },
+			mock: func(m *mocks.UserService) {
+				m.On("FindByID", mock.Anything, int64(1), false).Return(nil, modelErr.ErrNotFound)
+			},
+		},
+		{
+			name:    "UpdateByID",
+			method:  http.MethodPut,
+			body:    `{"first_name":"john","email":"john@m.co"}`,
+			handler: func(h *userHandler) http.HandlerFunc { return h.UpdateByID },
+			mock: func(m *mocks.UserService) {
+				m.On("UpdateByID", mock.Anything, int64(1), int64(0), mock.AnythingOfType("*reqres.UpdateUserReq")).Return(nil, modelErr.ErrNotFound)
+			},
+		},
+		{
+			name:    "DeleteByID",
+			method:  http.MethodDelete,
+			handler: func(h *userHandler) http.HandlerFunc { return h.DeleteByID },
+			mock: func(m *mocks.UserService) {
+				m.On("DeleteByID", mock.Anything, int64(1), int64(0)).Return(modelErr.ErrNotFound)
+			},
+		},
+		{
+			name:    "Restore",
+			method:  http.MethodPost,
+			handler: func(h *userHandler) http.HandlerFunc { return h.Restore },
+			mock: func(m *mocks.UserService) {
+				m.On("Restore", mock.Anything, int64(1)).Return(nil, modelErr.ErrNotFound)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mockUserSvc := mocks.NewUserService(t)
+			c.mock(mockUserSvc)
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(c.method, "/api/v1/user/1", strings.NewReader(c.body))
+			assert.NoError(t, err)
+
+			req = mux.SetURLVars(req, map[string]string{"id": "1"})
+			handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+			c.handler(&handler)(w, req)
+
+			var response httputil.Problem
+			json.NewDecoder(w.Body).Decode(&response)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Equal(t, httputil.CodeNotFound, response.Code)
+		})
+	}
+}
+
 func TestUserHandler_Create(t *testing.T) {
 	userReq := reqres.CreateUserReq{
 		FirstName: "john",
@@ -149,37 +431,163 @@ func TestUserHandler_Create(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		mockUserSvc := mocks.NewUserService(t)
-		mockUserSvc.On("Create", mock.Anything, mock.AnythingOfType("*reqres.CreateUserReq")).Return(nil)
+		mockUserSvc.On("Create", mock.Anything, mock.AnythingOfType("*reqres.CreateUserReq")).Return(&domain.User{ID: 1, FirstName: "john", LastName: "x", Email: "john@m.co"}, nil)
 
 		w := httptest.NewRecorder()
 		req, err := http.NewRequest(http.MethodPost, "/api/v1/user", strings.NewReader(string(b)))
 		assert.NoError(t, err)
 		req.Header.Set("Content-Type", "application/json")
 
-		handler := userHandler{userSvc: mockUserSvc}
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
 		handler.Create(w, req)
 
-		var response httputil.ApiResponse
+		var response struct {
+			Message string      `json:"message"`
+			Data    domain.User `json:"data"`
+		}
 		json.NewDecoder(w.Body).Decode(&response)
-		assert.Equal(t, 200, w.Code)
-		assert.Equal(t, "OK", response.Message)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "/api/v1/user/1", w.Header().Get("Location"))
+		assert.Equal(t, "Created", response.Message)
+		assert.Equal(t, int64(1), response.Data.ID)
+	})
+
+	t.Run("success:with first post", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("CreateWithFirstPost", mock.Anything, &userReq, &reqres.FirstPostReq{Title: "hello", Content: "world"}).
+			Return(&domain.User{ID: 1, FirstName: "john"}, &domain.Post{ID: 3, UserID: 1, Title: "hello"}, nil)
+
+		body := `{"first_name":"john","last_name":"due","email":"john@m.co","first_post":{"title":"hello","content":"world"}}`
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/user", strings.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.Create(w, req)
+
+		var response struct {
+			Data struct {
+				ID        int64       `json:"id"`
+				FirstPost domain.Post `json:"first_post"`
+			} `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "/api/v1/user/1", w.Header().Get("Location"))
+		assert.Equal(t, int64(1), response.Data.ID)
+		assert.Equal(t, int64(3), response.Data.FirstPost.ID)
+	})
+
+	t.Run("error:first post validator", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+
+		body := `{"first_name":"john","email":"john@m.co","first_post":{"title":"hello"}}`
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/user", strings.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.Create(w, req)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "content")
 	})
 
 	t.Run("error:service", func(t *testing.T) {
 		mockUserSvc := mocks.NewUserService(t)
-		mockUserSvc.On("Create", mock.Anything, mock.AnythingOfType("*reqres.CreateUserReq")).Return(errors.New("Unexpexted Error"))
+		mockUserSvc.On("Create", mock.Anything, mock.AnythingOfType("*reqres.CreateUserReq")).Return(nil, errors.New("Unexpexted Error"))
 
 		w := httptest.NewRecorder()
 		req, err := http.NewRequest(http.MethodPost, "/api/v1/user", strings.NewReader(string(b)))
 		assert.NoError(t, err)
+		req.Header.Set("Accept", httputil.ContentTypeProblemJSON)
 		req.Header.Set("Content-Type", "application/json")
 
-		handler := userHandler{userSvc: mockUserSvc}
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
 		handler.Create(w, req)
 
-		var response httputil.ApiResponse
+		var response httputil.Problem
 		json.NewDecoder(w.Body).Decode(&response)
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, http.StatusInternalServerError, response.Status)
+	})
+
+	t.Run("error:unknown field", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/user", strings.NewReader(`{"first_name":"john","email":"john@m.co","role":"admin"}`))
+		assert.NoError(t, err)
+		req.Header.Set("Accept", httputil.ContentTypeProblemJSON)
+		req.Header.Set("Content-Type", "application/json")
+
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.Create(w, req)
+
+		var response httputil.Problem
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, httputil.CodeBadParamInput, response.Code)
+		assert.Contains(t, response.Detail, `unknown field "role"`)
+	})
+
+	t.Run("error:content type", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/user", strings.NewReader(string(b)))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.Create(w, req)
+
+		var response httputil.Problem
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Equal(t, httputil.CodeUnsupportedMedia, response.Code)
+	})
+
+	t.Run("error:validator translated", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/user", strings.NewReader(`{"first_name":"john","email":"john"}`))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", "id-ID,id;q=0.9,en;q=0.8")
+
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.Create(w, req)
+
+		var response httputil.Problem
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, []httputil.FieldError{
+			{Field: "email", Rule: "email", Message: "email harus berupa alamat email yang valid"},
+		}, response.Errors)
+	})
+
+	t.Run("error:conflict", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("Create", mock.Anything, mock.AnythingOfType("*reqres.CreateUserReq")).
+			Return(nil, &modelErr.FieldError{Field: "email", Rule: "unique", Err: modelErr.ErrConflict})
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/user", strings.NewReader(string(b)))
+		assert.NoError(t, err)
+		req.Header.Set("Accept", httputil.ContentTypeProblemJSON)
+		req.Header.Set("Content-Type", "application/json")
+
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.Create(w, req)
+
+		var response httputil.Problem
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, httputil.ContentTypeProblemJSON, w.Header().Get("Content-Type"))
+		assert.Equal(t, []httputil.FieldError{{Field: "email", Rule: "unique", Message: "email is already in use"}}, response.Errors)
 	})
 
 	t.Run("error:validator", func(t *testing.T) {
@@ -190,12 +598,15 @@ func TestUserHandler_Create(t *testing.T) {
 		assert.NoError(t, err)
 		req.Header.Set("Content-Type", "application/json")
 
-		handler := userHandler{userSvc: mockUserSvc}
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
 		handler.Create(w, req)
 
-		var response httputil.ApiResponse
+		var response httputil.Problem
 		json.NewDecoder(w.Body).Decode(&response)
 		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, httputil.CodeValidationFailed, response.Code)
+		assert.Equal(t, "first_name", response.Errors[0].Field)
+		assert.Equal(t, "required", response.Errors[0].Rule)
 	})
 }
 
@@ -211,16 +622,16 @@ func TestUserHandler_UpdateByID(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		mockUserSvc := mocks.NewUserService(t)
-		mockUserSvc.On("UpdateByID", mock.Anything, mock.AnythingOfType("int64"), mock.AnythingOfType("*reqres.UpdateUserReq")).
-			Return(nil)
+		mockUserSvc.On("UpdateByID", mock.Anything, mock.AnythingOfType("int64"), int64(0), mock.AnythingOfType("*reqres.UpdateUserReq")).
+			Return(&domain.User{ID: 1, FirstName: "john", LastName: "x", Email: "john@m.co"}, nil)
 
 		w := httptest.NewRecorder()
-		req, err := http.NewRequest(http.MethodPatch, "/api/v1/user", strings.NewReader(string(b)))
+		req, err := http.NewRequest(http.MethodPut, "/api/v1/user", strings.NewReader(string(b)))
 		assert.NoError(t, err)
 		req.Header.Set("Content-Type", "application/json")
 
 		req = mux.SetURLVars(req, map[string]string{"id": "1"})
-		handler := userHandler{userSvc: mockUserSvc}
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
 		handler.UpdateByID(w, req)
 
 		var response httputil.ApiResponse
@@ -231,52 +642,256 @@ func TestUserHandler_UpdateByID(t *testing.T) {
 
 	t.Run("error:service", func(t *testing.T) {
 		mockUserSvc := mocks.NewUserService(t)
-		mockUserSvc.On("UpdateByID", mock.Anything, mock.AnythingOfType("int64"), mock.AnythingOfType("*reqres.UpdateUserReq")).
-			Return(errors.New("Unexpexted Error"))
+		mockUserSvc.On("UpdateByID", mock.Anything, mock.AnythingOfType("int64"), int64(0), mock.AnythingOfType("*reqres.UpdateUserReq")).
+			Return(nil, errors.New("Unexpexted Error"))
 
 		w := httptest.NewRecorder()
-		req, err := http.NewRequest(http.MethodPatch, "/api/v1/user", strings.NewReader(string(b)))
+		req, err := http.NewRequest(http.MethodPut, "/api/v1/user", strings.NewReader(string(b)))
 		assert.NoError(t, err)
+		req.Header.Set("Accept", httputil.ContentTypeProblemJSON)
 		req.Header.Set("Content-Type", "application/json")
 
 		req = mux.SetURLVars(req, map[string]string{"id": "1"})
-		handler := userHandler{userSvc: mockUserSvc}
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
 		handler.UpdateByID(w, req)
 
-		var response httputil.ApiResponse
+		var response httputil.Problem
 		json.NewDecoder(w.Body).Decode(&response)
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, http.StatusInternalServerError, response.Status)
+	})
+
+	t.Run("error:conflict", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("UpdateByID", mock.Anything, mock.AnythingOfType("int64"), int64(0), mock.AnythingOfType("*reqres.UpdateUserReq")).
+			Return(nil, &modelErr.FieldError{Field: "email", Rule: "unique", Err: modelErr.ErrConflict})
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPut, "/api/v1/user", strings.NewReader(string(b)))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.UpdateByID(w, req)
+
+		var response httputil.Problem
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "email", response.Errors[0].Field)
 	})
 
 	t.Run("error:validator", func(t *testing.T) {
 		mockUserSvc := mocks.NewUserService(t)
 
 		w := httptest.NewRecorder()
-		req, err := http.NewRequest(http.MethodPatch, "/api/v1/user", strings.NewReader("{\"first_name\":\"\"}"))
+		req, err := http.NewRequest(http.MethodPut, "/api/v1/user", strings.NewReader("{\"first_name\":\"\"}"))
 		assert.NoError(t, err)
 		req.Header.Set("Content-Type", "application/json")
 
 		req = mux.SetURLVars(req, map[string]string{"id": "1"})
-		handler := userHandler{userSvc: mockUserSvc}
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
 		handler.UpdateByID(w, req)
 
-		var response httputil.ApiResponse
+		var response httputil.Problem
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, httputil.CodeValidationFailed, response.Code)
+		assert.Equal(t, "first_name", response.Errors[0].Field)
+		assert.Equal(t, "required", response.Errors[0].Rule)
+	})
+
+	t.Run("error:validator max length", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+
+		body := `{"first_name":"` + strings.Repeat("j", 41) + `","email":"john@m.co"}`
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPut, "/api/v1/user", strings.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.UpdateByID(w, req)
+
+		var response httputil.Problem
 		json.NewDecoder(w.Body).Decode(&response)
 		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "first_name", response.Errors[0].Field)
+		assert.Equal(t, "max", response.Errors[0].Rule)
+	})
+
+	t.Run("error:password", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPut, "/api/v1/user", strings.NewReader(`{"first_name":"john","email":"john@m.co","password":"secret123"}`))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.UpdateByID(w, req)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "password")
+	})
+
+	t.Run("success:if-match", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("UpdateByID", mock.Anything, int64(1), int64(3), mock.AnythingOfType("*reqres.UpdateUserReq")).
+			Return(&domain.User{ID: 1, Version: 4}, nil)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPut, "/api/v1/user/1", strings.NewReader(string(b)))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"3"`)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.UpdateByID(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("error:precondition failed", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("UpdateByID", mock.Anything, int64(1), int64(3), mock.AnythingOfType("*reqres.UpdateUserReq")).
+			Return(nil, modelErr.ErrPreconditionFailed)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPut, "/api/v1/user/1", strings.NewReader(string(b)))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"3"`)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.UpdateByID(w, req)
+
+		var response httputil.Problem
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Equal(t, httputil.CodePrecondition, response.Code)
+	})
+
+	t.Run("error:weak if-match", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPut, "/api/v1/user/1", strings.NewReader(string(b)))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `W/"3"`)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.UpdateByID(w, req)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	})
+}
+
+func TestUserHandler_PatchByID(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("PatchByID", mock.Anything, int64(1), int64(0), &reqres.PatchUserReq{
+			LastName: reqres.NewNullString("x"),
+		}).Return(&domain.User{ID: 1, FirstName: "john", LastName: "x", Email: "john@m.co"}, nil)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPatch, "/api/v1/user/1", strings.NewReader(`{"last_name":"x"}`))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.PatchByID(w, req)
+
+		var response struct {
+			Message string      `json:"message"`
+			Data    domain.User `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "OK", response.Message)
+		assert.Equal(t, "x", response.Data.LastName)
+	})
+
+	t.Run("success:null clears nullable field", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("PatchByID", mock.Anything, int64(1), int64(0), &reqres.PatchUserReq{
+			LastName: reqres.NullString{Set: true},
+		}).Return(&domain.User{ID: 1, FirstName: "john", Email: "john@m.co"}, nil)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPatch, "/api/v1/user/1", strings.NewReader(`{"last_name":null}`))
+		assert.NoError(t, err)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.PatchByID(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("error:validator", func(t *testing.T) {
+		cases := []struct {
+			doc   string
+			field string
+			rule  string
+		}{
+			{doc: `{"first_name":null}`, field: "first_name", rule: "required"},
+			{doc: `{"first_name":""}`, field: "first_name", rule: "required"},
+			{doc: `{"email":null}`, field: "email", rule: "required"},
+			{doc: `{"email":""}`, field: "email", rule: "required"},
+			{doc: `{"email":"john"}`, field: "email", rule: "email"},
+		}
+
+		for _, c := range cases {
+			mockUserSvc := mocks.NewUserService(t)
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPatch, "/api/v1/user/1", strings.NewReader(c.doc))
+			assert.NoError(t, err)
+
+			req = mux.SetURLVars(req, map[string]string{"id": "1"})
+			handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+			handler.PatchByID(w, req)
+
+			var response httputil.Problem
+			json.NewDecoder(w.Body).Decode(&response)
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, c.doc)
+			assert.Equal(t, c.field, response.Errors[0].Field, c.doc)
+			assert.Equal(t, c.rule, response.Errors[0].Rule, c.doc)
+		}
+	})
+
+	t.Run("error:service", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("PatchByID", mock.Anything, int64(1), int64(0), mock.AnythingOfType("*reqres.PatchUserReq")).
+			Return(nil, modelErr.ErrNotFound)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPatch, "/api/v1/user/1", strings.NewReader(`{"last_name":"x"}`))
+		assert.NoError(t, err)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.PatchByID(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
 }
 
 func TestUserHandler_DeleteByID(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockUserSvc := mocks.NewUserService(t)
-		mockUserSvc.On("DeleteByID", mock.Anything, mock.AnythingOfType("int64")).Return(nil)
+		mockUserSvc.On("DeleteByID", mock.Anything, mock.AnythingOfType("int64"), int64(0)).Return(nil)
 
 		w := httptest.NewRecorder()
 		req, err := http.NewRequest(http.MethodDelete, "/api/v1/user/1", strings.NewReader(""))
 		assert.NoError(t, err)
 
 		req = mux.SetURLVars(req, map[string]string{"id": "1"})
-		handler := userHandler{userSvc: mockUserSvc}
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
 		handler.DeleteByID(w, req)
 
 		var response httputil.ApiResponse
@@ -285,22 +900,328 @@ func TestUserHandler_DeleteByID(t *testing.T) {
 		assert.Equal(t, "OK", response.Message)
 	})
 
+	t.Run("success:if-match", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("DeleteByID", mock.Anything, int64(1), int64(2)).Return(nil)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodDelete, "/api/v1/user/1", strings.NewReader(""))
+		assert.NoError(t, err)
+		req.Header.Set("If-Match", `"2"`)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.DeleteByID(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
 	t.Run("error", func(t *testing.T) {
 		mockUserSvc := mocks.NewUserService(t)
-		mockUserSvc.On("DeleteByID", mock.Anything, mock.AnythingOfType("int64")).Return(errors.New("Unexpexted Error"))
+		mockUserSvc.On("DeleteByID", mock.Anything, mock.AnythingOfType("int64"), int64(0)).Return(errors.New("Unexpexted Error"))
 
 		w := httptest.NewRecorder()
 		req, err := http.NewRequest(http.MethodDelete, "/api/v1/user/1", strings.NewReader(""))
 		assert.NoError(t, err)
+		req.Header.Set("Accept", httputil.ContentTypeProblemJSON)
 
 		req = mux.SetURLVars(req, map[string]string{"id": "1"})
-		handler := userHandler{userSvc: mockUserSvc}
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
 		handler.DeleteByID(w, req)
 
-		var response httputil.ApiResponse
+		var response httputil.Problem
 		json.NewDecoder(w.Body).Decode(&response)
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
-		assert.Equal(t, true, response.Error)
-		assert.Nil(t, response.Data)
+		assert.Equal(t, http.StatusInternalServerError, response.Status)
+		assert.Equal(t, httputil.CodeInternal, response.Code)
+	})
+}
+
+func TestUserHandler_Restore(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("Restore", mock.Anything, int64(1)).Return(&domain.User{ID: 1, FirstName: "john"}, nil)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/user/1/restore", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.Restore(w, req)
+
+		var response struct {
+			Data domain.User `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int64(1), response.Data.ID)
+	})
+
+	t.Run("error:conflict", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("Restore", mock.Anything, int64(1)).
+			Return(nil, &modelErr.FieldError{Field: "email", Rule: "unique", Err: modelErr.ErrConflict})
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/user/1/restore", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		handler := userHandler{userSvc: mockUserSvc, validate: testValidator}
+		handler.Restore(w, req)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestUserHandler_Batch(t *testing.T) {
+	serve := func(svc domain.UserService, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/user:batch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		handler := userHandler{userSvc: svc, validate: testValidator, batchMaxSize: 2}
+		handler.Batch(w, req)
+		return w
+	}
+
+	type batchRes struct {
+		Data []batchUserRes `json:"data"`
+	}
+
+	t.Run("success:atomic", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("Batch", mock.Anything, &reqres.BatchUserReq{Atomic: true, Operations: []reqres.BatchUserOpReq{
+			{Op: reqres.BatchOpCreate, User: &reqres.CreateUserReq{FirstName: "john", Email: "john@m.co"}},
+			{Op: reqres.BatchOpDelete, ID: 2, Version: 3},
+		}}).Return([]domain.UserBatchResult{{User: &domain.User{ID: 1}}, {}}, nil)
+
+		w := serve(mockUserSvc, `{"atomic":true,"operations":[{"op":"create","user":{"first_name":"john","email":"john@m.co"}},{"op":"delete","id":2,"version":3}]}`)
+
+		var response batchRes
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []batchUserRes{
+			{Index: 0, Status: http.StatusCreated, Data: &domain.User{ID: 1}},
+			{Index: 1, Status: http.StatusOK},
+		}, response.Data)
+	})
+
+	t.Run("success:partial", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("Batch", mock.Anything, &reqres.BatchUserReq{Operations: []reqres.BatchUserOpReq{
+			{Op: reqres.BatchOpDelete, ID: 2},
+		}}).Return([]domain.UserBatchResult{{Err: modelErr.ErrNotFound}}, nil)
+
+		w := serve(mockUserSvc, `{"operations":[{"op":"create","user":{"first_name":""}},{"op":"delete","id":2}]}`)
+
+		var response batchRes
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusMultiStatus, w.Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Data[0].Status)
+		assert.Equal(t, httputil.CodeValidationFailed, response.Data[0].Code)
+		assert.Equal(t, "first_name", response.Data[0].Errors[0].Field)
+		assert.Equal(t, 1, response.Data[1].Index)
+		assert.Equal(t, http.StatusNotFound, response.Data[1].Status)
+		assert.Equal(t, httputil.CodeNotFound, response.Data[1].Code)
+	})
+
+	t.Run("error:atomic validation", func(t *testing.T) {
+		w := serve(mocks.NewUserService(t), `{"atomic":true,"operations":[{"op":"delete","id":2},{"op":"update","id":2}]}`)
+
+		var response httputil.Problem
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "operations[1].user", response.Errors[0].Field)
+		assert.Equal(t, "required_unless", response.Errors[0].Rule)
+	})
+
+	t.Run("error:atomic failed operation", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("Batch", mock.Anything, mock.AnythingOfType("*reqres.BatchUserReq")).
+			Return(nil, &modelErr.FieldError{Field: "operations[0].email", Rule: "unique", Err: modelErr.ErrConflict})
+
+		w := serve(mockUserSvc, `{"atomic":true,"operations":[{"op":"create","user":{"first_name":"john","email":"john@m.co"}}]}`)
+
+		var response httputil.Problem
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "operations[0].email", response.Errors[0].Field)
+	})
+
+	t.Run("error:too many operations", func(t *testing.T) {
+		w := serve(mocks.NewUserService(t), `{"operations":[{"op":"delete","id":1},{"op":"delete","id":2},{"op":"delete","id":3}]}`)
+
+		var response httputil.Problem
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "operations", response.Errors[0].Field)
+		assert.Equal(t, "max", response.Errors[0].Rule)
+		assert.Equal(t, "operations must hold at most 2 operations", response.Errors[0].Message)
+	})
+
+	t.Run("error:no operations without limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/user:batch", strings.NewReader(`{"operations":[]}`))
+
+		handler := userHandler{userSvc: mocks.NewUserService(t), validate: testValidator}
+		handler.Batch(w, req)
+
+		var response httputil.Problem
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "operations", response.Errors[0].Field)
+		assert.Equal(t, "required", response.Errors[0].Rule)
+		assert.Equal(t, "operations must hold at least 1 operation", response.Errors[0].Message)
+	})
+}
+
+func TestUserHandler_Authorization(t *testing.T) {
+	routes := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		mock   func(m *mocks.UserService)
+	}{
+		{
+			name: "Create", method: http.MethodPost, path: "/api/v1/user", body: `{"first_name":"john","email":"john@m.co"}`,
+			mock: func(m *mocks.UserService) {
+				m.On("Create", mock.Anything, mock.AnythingOfType("*reqres.CreateUserReq")).Return(&domain.User{ID: 2}, nil)
+			},
+		},
+		{
+			name: "FindAll", method: http.MethodGet, path: "/api/v1/user",
+			mock: func(m *mocks.UserService) {
+				m.On("FindAll", mock.Anything, mock.AnythingOfType("*reqres.ListUserReq")).Return(&[]domain.User{}, &reqres.PageRes{}, nil)
+			},
+		},
+		{
+			name: "Export", method: http.MethodGet, path: "/api/v1/user/export",
+			mock: func(m *mocks.UserService) {
+				m.On("Export", mock.Anything, mock.AnythingOfType("*reqres.ListUserReq"), mock.Anything).Return(nil)
+			},
+		},
+		{
+			name: "FindByID", method: http.MethodGet, path: "/api/v1/user/2",
+			mock: func(m *mocks.UserService) {
+				m.On("FindByID", mock.Anything, int64(2), false).Return(&domain.User{ID: 2}, nil)
+			},
+		},
+		{
+			name: "UpdateByID", method: http.MethodPut, path: "/api/v1/user/2", body: `{"first_name":"john","email":"john@m.co"}`,
+			mock: func(m *mocks.UserService) {
+				m.On("UpdateByID", mock.Anything, int64(2), int64(0), mock.AnythingOfType("*reqres.UpdateUserReq")).Return(&domain.User{ID: 2}, nil)
+			},
+		},
+		{
+			name: "PatchByID", method: http.MethodPatch, path: "/api/v1/user/2", body: `{"last_name":"due"}`,
+			mock: func(m *mocks.UserService) {
+				m.On("PatchByID", mock.Anything, int64(2), int64(0), mock.AnythingOfType("*reqres.PatchUserReq")).Return(&domain.User{ID: 2}, nil)
+			},
+		},
+		{
+			name: "DeleteByID", method: http.MethodDelete, path: "/api/v1/user/2",
+			mock: func(m *mocks.UserService) {
+				m.On("DeleteByID", mock.Anything, int64(2), int64(0)).Return(nil)
+			},
+		},
+		{
+			name: "Restore", method: http.MethodPost, path: "/api/v1/user/2/restore",
+			mock: func(m *mocks.UserService) {
+				m.On("Restore", mock.Anything, int64(2)).Return(&domain.User{ID: 2}, nil)
+			},
+		},
+		{
+			name: "Batch", method: http.MethodPost, path: "/api/v1/user:batch", body: `{"operations":[{"op":"delete","id":2}]}`,
+			mock: func(m *mocks.UserService) {
+				m.On("Batch", mock.Anything, mock.AnythingOfType("*reqres.BatchUserReq")).Return([]domain.UserBatchResult{{}}, nil)
+			},
+		},
+	}
+
+	// allowed lists the routes each role passes, the others are answered with 403.
+	callers := []struct {
+		name      string
+		principal *auth.Principal
+		allowed   map[string]bool
+	}{
+		{
+			name:      "admin",
+			principal: &auth.Principal{Subject: "1", Roles: []auth.Role{auth.RoleAdmin}},
+			allowed: map[string]bool{
+				"Create": true, "FindAll": true, "Export": true, "FindByID": true, "UpdateByID": true, "PatchByID": true, "DeleteByID": true, "Restore": true, "Batch": true,
+			},
+		},
+		{
+			name:      "user",
+			principal: &auth.Principal{Subject: "2", Roles: []auth.Role{auth.RoleUser}},
+			allowed:   map[string]bool{"FindByID": true, "UpdateByID": true, "PatchByID": true, "Batch": true},
+		},
+		{
+			name:      "no role",
+			principal: &auth.Principal{Subject: "2"},
+			allowed:   map[string]bool{},
+		},
+		{
+			name:      "unknown role",
+			principal: &auth.Principal{Subject: "2", Roles: []auth.Role{"guest"}},
+			allowed:   map[string]bool{},
+		},
+	}
+
+	serve := func(svc domain.UserService, principal *auth.Principal, method, path, body string) *httptest.ResponseRecorder {
+		r := mux.NewRouter()
+		NewUserHandlerRegister(r, svc, testValidator)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, route := range routes {
+		for _, c := range callers {
+			t.Run(route.name+"/"+c.name, func(t *testing.T) {
+				mockUserSvc := mocks.NewUserService(t)
+				if c.allowed[route.name] {
+					route.mock(mockUserSvc)
+				}
+
+				w := serve(mockUserSvc, c.principal, route.method, route.path, route.body)
+				if c.allowed[route.name] {
+					assert.Less(t, w.Code, 300)
+					return
+				}
+
+				var problem httputil.Problem
+				json.NewDecoder(w.Body).Decode(&problem)
+				assert.Equal(t, http.StatusForbidden, w.Code)
+				assert.Equal(t, httputil.CodeForbidden, problem.Code)
+			})
+		}
+
+		t.Run(route.name+"/unauthenticated", func(t *testing.T) {
+			w := serve(mocks.NewUserService(t), nil, route.method, route.path, route.body)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+
+	t.Run("ownership error from the service", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("FindByID", mock.Anything, int64(3), false).Return(nil, modelErr.ErrForbidden)
+
+		w := serve(mockUserSvc, &auth.Principal{Subject: "2", Roles: []auth.Role{auth.RoleUser}}, http.MethodGet, "/api/v1/user/3", "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("include deleted error from the service", func(t *testing.T) {
+		mockUserSvc := mocks.NewUserService(t)
+		mockUserSvc.On("FindByID", mock.Anything, int64(2), true).Return(nil, modelErr.ErrForbidden)
+
+		w := serve(mockUserSvc, &auth.Principal{Subject: "2", Roles: []auth.Role{auth.RoleUser}}, http.MethodGet, "/api/v1/user/2?include_deleted=true", "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
 	})
 }
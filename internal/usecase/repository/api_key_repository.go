@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/internal/domain"
+)
+
+const apiKeyColumns = "id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at"
+
+type apiKeyRepository struct {
+	db *sql.DB
+}
+
+func NewApiKeyRepository(db *sql.DB) domain.ApiKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
+
+func scanApiKey(row rowScanner) (*domain.ApiKey, error) {
+	var key domain.ApiKey
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Hash, pq.Array(&key.Scopes), &expiresAt, &lastUsedAt, &revokedAt, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	key.ExpiresAt, key.LastUsedAt, key.RevokedAt = nullTimePtr(expiresAt), nullTimePtr(lastUsedAt), nullTimePtr(revokedAt)
+	return &key, nil
+}
+
+// Save inserts the key and sets its generated id.
+func (a *apiKeyRepository) Save(ctx context.Context, key *domain.ApiKey) error {
+	q := "INSERT INTO api_keys (name, prefix, key_hash, scopes, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	err := conn(ctx, a.db).QueryRowContext(ctx, q, key.Name, key.Prefix, key.Hash, pq.Array(key.Scopes), key.ExpiresAt, key.CreatedAt).Scan(&key.ID)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error save api key repository")
+		return translateError(err)
+	}
+
+	return nil
+}
+
+// FindByPrefix returns the key of the prefix, revoked and expired keys included.
+func (a *apiKeyRepository) FindByPrefix(ctx context.Context, prefix string) (*domain.ApiKey, error) {
+	q := "SELECT " + apiKeyColumns + " FROM api_keys WHERE prefix = $1"
+	key, err := scanApiKey(conn(ctx, a.db).QueryRowContext(ctx, q, prefix))
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error FindByPrefix api key repository")
+		return nil, translateError(err)
+	}
+
+	return key, nil
+}
+
+func (a *apiKeyRepository) FindAll(ctx context.Context) (*[]domain.ApiKey, error) {
+	rows, err := conn(ctx, a.db).QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY id")
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error FindAll api key repository")
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]domain.ApiKey, 0)
+	for rows.Next() {
+		key, err := scanApiKey(rows)
+		if err != nil {
+			log.WithError(err).Error("error while scan row")
+			return nil, err
+		}
+
+		result = append(result, *key)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.WithError(err).Error("error FindAll api key repository")
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Revoke revokes the key, ErrNotFound when there is no such key not revoked yet.
+func (a *apiKeyRepository) Revoke(ctx context.Context, id int64, at time.Time) error {
+	q := "UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL"
+	res, err := conn(ctx, a.db).ExecContext(ctx, q, at, id)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error Revoke api key repository")
+		return err
+	}
+
+	return checkRowsAffected(res)
+}
+
+func (a *apiKeyRepository) TouchLastUsed(ctx context.Context, id int64, at time.Time) error {
+	_, err := conn(ctx, a.db).ExecContext(ctx, "UPDATE api_keys SET last_used_at = $1 WHERE id = $2", at, id)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error TouchLastUsed api key repository")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"go-rest-api-boilerplate/internal/domain"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/usecase/repository"
+)
+
+var apiKeyRowColumns = []string{"id", "name", "prefix", "key_hash", "scopes", "expires_at", "last_used_at", "revoked_at", "created_at"}
+
+func TestApiKeyRepository_Save(t *testing.T) {
+	expectSQL := "INSERT INTO api_keys (name, prefix, key_hash, scopes, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	key := domain.ApiKey{Name: "batch", Prefix: "abcd1234", Hash: "hash", Scopes: []string{"user:list", "user:read"}, CreatedAt: time.Now()}
+
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery(expectSQL).WithArgs("batch", "abcd1234", "hash", "{\"user:list\",\"user:read\"}", nil, key.CreatedAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+
+		newKey := key
+		repo := repository.NewApiKeyRepository(db)
+		err := repo.Save(context.TODO(), &newKey)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), newKey.ID)
+	})
+
+	t.Run("error:duplicate prefix", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery(expectSQL).WillReturnError(&pq.Error{Code: "23505", Constraint: "api_keys_prefix_unique_idx"})
+
+		newKey := key
+		repo := repository.NewApiKeyRepository(db)
+		err := repo.Save(context.TODO(), &newKey)
+		assert.ErrorIs(t, err, modelErr.ErrConflict)
+	})
+}
+
+func TestApiKeyRepository_FindByPrefix(t *testing.T) {
+	expectSQL := "SELECT id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at FROM api_keys WHERE prefix = $1"
+
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		expiresAt := time.Now().Add(time.Hour)
+		rows := sqlmock.NewRows(apiKeyRowColumns).AddRow(1, "batch", "abcd1234", "hash", "{user:list}", expiresAt, nil, nil, time.Now())
+		mock.ExpectQuery(expectSQL).WithArgs("abcd1234").WillReturnRows(rows)
+
+		repo := repository.NewApiKeyRepository(db)
+		key, err := repo.FindByPrefix(context.TODO(), "abcd1234")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user:list"}, key.Scopes)
+		assert.Equal(t, expiresAt, *key.ExpiresAt)
+		assert.Nil(t, key.LastUsedAt)
+		assert.Nil(t, key.RevokedAt)
+	})
+
+	t.Run("error:not found", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery(expectSQL).WithArgs("abcd1234").WillReturnRows(sqlmock.NewRows(apiKeyRowColumns))
+
+		repo := repository.NewApiKeyRepository(db)
+		_, err := repo.FindByPrefix(context.TODO(), "abcd1234")
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
+	})
+}
+
+func TestApiKeyRepository_FindAll(t *testing.T) {
+	expectSQL := "SELECT id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at FROM api_keys ORDER BY id"
+
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows(apiKeyRowColumns).
+			AddRow(1, "batch", "abcd1234", "hash", "{user:list}", nil, time.Now(), nil, time.Now()).
+			AddRow(2, "old", "ffff0000", "hash", "{}", nil, nil, time.Now(), time.Now())
+		mock.ExpectQuery(expectSQL).WillReturnRows(rows)
+
+		repo := repository.NewApiKeyRepository(db)
+		keys, err := repo.FindAll(context.TODO())
+		assert.NoError(t, err)
+		assert.Len(t, *keys, 2)
+		assert.NotNil(t, (*keys)[0].LastUsedAt)
+		assert.NotNil(t, (*keys)[1].RevokedAt)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery(expectSQL).WillReturnError(errors.New("Unexpexted Error"))
+
+		repo := repository.NewApiKeyRepository(db)
+		_, err := repo.FindAll(context.TODO())
+		assert.Error(t, err)
+	})
+}
+
+func TestApiKeyRepository_Revoke(t *testing.T) {
+	expectSQL := "UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL"
+
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectExec(expectSQL).WithArgs(AnyTime{}, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		repo := repository.NewApiKeyRepository(db)
+		assert.NoError(t, repo.Revoke(context.TODO(), 1, time.Now()))
+	})
+
+	t.Run("error:not found", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectExec(expectSQL).WithArgs(AnyTime{}, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		repo := repository.NewApiKeyRepository(db)
+		assert.ErrorIs(t, repo.Revoke(context.TODO(), 1, time.Now()), modelErr.ErrNotFound)
+	})
+}
+
+func TestApiKeyRepository_TouchLastUsed(t *testing.T) {
+	db, mock := newUserDBTest(t)
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE api_keys SET last_used_at = $1 WHERE id = $2").WithArgs(AnyTime{}, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := repository.NewApiKeyRepository(db)
+	assert.NoError(t, repo.TouchLastUsed(context.TODO(), 1, time.Now()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+)
+
+const (
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+)
+
+// uniqueConstraintFields maps the unique constraints/indexes to the input field they guard.
+var uniqueConstraintFields = map[string]string{
+	"users_email_unique_idx":     "email",
+	"api_keys_prefix_unique_idx": "prefix",
+}
+
+// foreignKeyFields maps the foreign key constraints to the input field referencing the other row.
+var foreignKeyFields = map[string]string{
+	"posts_user_id_fkey": "user_id",
+}
+
+// translateError turns driver specific errors into the domain errors of internal/model/error, so
+// nothing above the repository has to know about database/sql or lib/pq.
+func translateError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return modelErr.ErrNotFound
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case pqUniqueViolation:
+			return &modelErr.FieldError{
+				Field: uniqueConstraintFields[pqErr.Constraint],
+				Rule:  "unique",
+				Err:   modelErr.ErrConflict,
+			}
+		case pqForeignKeyViolation:
+			return &modelErr.FieldError{
+				Field: foreignKeyFields[pqErr.Constraint],
+				Rule:  "exists",
+				Err:   modelErr.ErrBadParamInput,
+			}
+		}
+	}
+
+	return err
+}
+
+// checkRowsAffected reports ErrNotFound when a write by id matched no row.
+func checkRowsAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return modelErr.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/internal/domain"
+)
+
+const idempotencyKeyColumns = "id, key_hash, fingerprint, status, headers, body, completed_at, expires_at, created_at"
+
+type idempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) domain.IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Claim takes over an expired key in the same statement, so of two concurrent claims only one wins.
+func (i *idempotencyRepository) Claim(ctx context.Context, key *domain.IdempotencyKey) (bool, error) {
+	q := "INSERT INTO idempotency_keys (key_hash, fingerprint, expires_at, created_at) VALUES ($1, $2, $3, $4) " +
+		"ON CONFLICT (key_hash) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, status = NULL, headers = NULL, body = NULL, " +
+		"completed_at = NULL, expires_at = EXCLUDED.expires_at, created_at = EXCLUDED.created_at " +
+		"WHERE idempotency_keys.expires_at <= EXCLUDED.created_at"
+	res, err := conn(ctx, i.db).ExecContext(ctx, q, key.KeyHash, key.Fingerprint, key.ExpiresAt, key.CreatedAt)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error Claim idempotency repository")
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+func (i *idempotencyRepository) FindByKeyHash(ctx context.Context, keyHash string) (*domain.IdempotencyKey, error) {
+	q := "SELECT " + idempotencyKeyColumns + " FROM idempotency_keys WHERE key_hash = $1"
+
+	var key domain.IdempotencyKey
+	var status sql.NullInt64
+	var headers sql.NullString
+	var completedAt sql.NullTime
+	err := conn(ctx, i.db).QueryRowContext(ctx, q, keyHash).Scan(&key.ID, &key.KeyHash, &key.Fingerprint, &status, &headers,
+		&key.Body, &completedAt, &key.ExpiresAt, &key.CreatedAt)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error FindByKeyHash idempotency repository")
+		return nil, translateError(err)
+	}
+
+	key.Status, key.CompletedAt = int(status.Int64), nullTimePtr(completedAt)
+	if headers.Valid {
+		if err = json.Unmarshal([]byte(headers.String), &key.Header); err != nil {
+			return nil, err
+		}
+	}
+
+	return &key, nil
+}
+
+func (i *idempotencyRepository) Complete(ctx context.Context, key *domain.IdempotencyKey) error {
+	headers, err := json.Marshal(key.Header)
+	if err != nil {
+		return err
+	}
+
+	q := "UPDATE idempotency_keys SET status = $1, headers = $2, body = $3, completed_at = $4 WHERE key_hash = $5"
+	res, err := conn(ctx, i.db).ExecContext(ctx, q, key.Status, string(headers), key.Body, key.CompletedAt, key.KeyHash)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error Complete idempotency repository")
+		return err
+	}
+
+	return checkRowsAffected(res)
+}
+
+func (i *idempotencyRepository) Delete(ctx context.Context, keyHash string) error {
+	q := "DELETE FROM idempotency_keys WHERE key_hash = $1"
+	_, err := conn(ctx, i.db).ExecContext(ctx, q, keyHash)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error Delete idempotency repository")
+		return err
+	}
+
+	return nil
+}
+
+func (i *idempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	q := "DELETE FROM idempotency_keys WHERE expires_at <= $1"
+	res, err := conn(ctx, i.db).ExecContext(ctx, q, before)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error DeleteExpired idempotency repository")
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,143 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go-rest-api-boilerplate/internal/domain"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/usecase/repository"
+)
+
+var idempotencyKeyRowColumns = []string{"id", "key_hash", "fingerprint", "status", "headers", "body", "completed_at", "expires_at", "created_at"}
+
+func TestIdempotencyRepository_Claim(t *testing.T) {
+	expectSQL := "INSERT INTO idempotency_keys (key_hash, fingerprint, expires_at, created_at) VALUES ($1, $2, $3, $4) " +
+		"ON CONFLICT (key_hash) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, status = NULL, headers = NULL, body = NULL, " +
+		"completed_at = NULL, expires_at = EXCLUDED.expires_at, created_at = EXCLUDED.created_at " +
+		"WHERE idempotency_keys.expires_at <= EXCLUDED.created_at"
+	now := time.Now()
+	key := domain.IdempotencyKey{KeyHash: "hash", Fingerprint: "fp", ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+
+	t.Run("claimed", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectExec(expectSQL).WithArgs("hash", "fp", key.ExpiresAt, key.CreatedAt).WillReturnResult(sqlmock.NewResult(1, 1))
+
+		repo := repository.NewIdempotencyRepository(db)
+		claimed, err := repo.Claim(context.TODO(), &key)
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+	})
+
+	t.Run("taken", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectExec(expectSQL).WithArgs("hash", "fp", key.ExpiresAt, key.CreatedAt).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		repo := repository.NewIdempotencyRepository(db)
+		claimed, err := repo.Claim(context.TODO(), &key)
+		assert.NoError(t, err)
+		assert.False(t, claimed)
+	})
+}
+
+func TestIdempotencyRepository_FindByKeyHash(t *testing.T) {
+	expectSQL := "SELECT id, key_hash, fingerprint, status, headers, body, completed_at, expires_at, created_at FROM idempotency_keys WHERE key_hash = $1"
+
+	t.Run("completed", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows(idempotencyKeyRowColumns).
+			AddRow(1, "hash", "fp", 201, `{"Location":"/api/v1/user/1"}`, []byte(`{"id":1}`), time.Now(), time.Now(), time.Now())
+		mock.ExpectQuery(expectSQL).WithArgs("hash").WillReturnRows(rows)
+
+		repo := repository.NewIdempotencyRepository(db)
+		key, err := repo.FindByKeyHash(context.TODO(), "hash")
+		assert.NoError(t, err)
+		assert.Equal(t, 201, key.Status)
+		assert.Equal(t, map[string]string{"Location": "/api/v1/user/1"}, key.Header)
+		assert.Equal(t, []byte(`{"id":1}`), key.Body)
+		assert.NotNil(t, key.CompletedAt)
+	})
+
+	t.Run("in flight", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows(idempotencyKeyRowColumns).AddRow(1, "hash", "fp", nil, nil, nil, nil, time.Now(), time.Now())
+		mock.ExpectQuery(expectSQL).WithArgs("hash").WillReturnRows(rows)
+
+		repo := repository.NewIdempotencyRepository(db)
+		key, err := repo.FindByKeyHash(context.TODO(), "hash")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, key.Status)
+		assert.Nil(t, key.Header)
+		assert.Nil(t, key.CompletedAt)
+	})
+
+	t.Run("error:not found", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery(expectSQL).WithArgs("hash").WillReturnRows(sqlmock.NewRows(idempotencyKeyRowColumns))
+
+		repo := repository.NewIdempotencyRepository(db)
+		_, err := repo.FindByKeyHash(context.TODO(), "hash")
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
+	})
+}
+
+func TestIdempotencyRepository_Complete(t *testing.T) {
+	expectSQL := "UPDATE idempotency_keys SET status = $1, headers = $2, body = $3, completed_at = $4 WHERE key_hash = $5"
+	now := time.Now()
+	key := domain.IdempotencyKey{KeyHash: "hash", Status: 201, Header: map[string]string{"Location": "/api/v1/user/1"}, Body: []byte("{}"), CompletedAt: &now}
+
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectExec(expectSQL).WithArgs(201, `{"Location":"/api/v1/user/1"}`, []byte("{}"), AnyTime{}, "hash").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		repo := repository.NewIdempotencyRepository(db)
+		assert.NoError(t, repo.Complete(context.TODO(), &key))
+	})
+
+	t.Run("error:released", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectExec(expectSQL).WithArgs(201, `{"Location":"/api/v1/user/1"}`, []byte("{}"), AnyTime{}, "hash").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		repo := repository.NewIdempotencyRepository(db)
+		assert.ErrorIs(t, repo.Complete(context.TODO(), &key), modelErr.ErrNotFound)
+	})
+}
+
+func TestIdempotencyRepository_Delete(t *testing.T) {
+	db, mock := newUserDBTest(t)
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE key_hash = $1").WithArgs("hash").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := repository.NewIdempotencyRepository(db)
+	assert.NoError(t, repo.Delete(context.TODO(), "hash"))
+}
+
+func TestIdempotencyRepository_DeleteExpired(t *testing.T) {
+	db, mock := newUserDBTest(t)
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE expires_at <= $1").WithArgs(AnyTime{}).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	repo := repository.NewIdempotencyRepository(db)
+	n, err := repo.DeleteExpired(context.TODO(), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/internal/domain"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/model/reqres"
+)
+
+const postColumns = "id, user_id, title, content, created_at, updated_at"
+
+// postSort is the only order of the post list, newest first, cursors are issued for it.
+const postSort = "-id"
+
+type postRepository struct {
+	db *sql.DB
+}
+
+func NewPostRepository(db *sql.DB) domain.PostRepository {
+	return &postRepository{db: db}
+}
+
+func scanPost(row rowScanner) (*domain.Post, error) {
+	var post domain.Post
+	var title, content sql.NullString
+	err := row.Scan(&post.ID, &post.UserID, &title, &content, &post.CreatedAt, &post.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	post.Title, post.Content = title.String, content.String
+	return &post, nil
+}
+
+// Save inserts the post and sets its generated id.
+func (p *postRepository) Save(ctx context.Context, post *domain.Post) error {
+	q := "INSERT INTO posts (user_id, title, content, updated_at, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	err := conn(ctx, p.db).QueryRowContext(ctx, q, post.UserID, post.Title, post.Content, post.UpdatedAt, post.CreatedAt).Scan(&post.ID)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error save post repository")
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (p *postRepository) UpdateByID(ctx context.Context, id int64, post *domain.Post) (*domain.Post, error) {
+	q := "UPDATE posts SET title = $1, content = $2, updated_at = $3 WHERE id = $4 RETURNING " + postColumns
+	updated, err := scanPost(conn(ctx, p.db).QueryRowContext(ctx, q, post.Title, post.Content, time.Now(), id))
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error UpdateByID post repository")
+		return nil, translateError(err)
+	}
+
+	return updated, nil
+}
+
+func (p *postRepository) DeleteByID(ctx context.Context, id int64) error {
+	q := "DELETE FROM posts WHERE id = $1"
+	res, err := conn(ctx, p.db).ExecContext(ctx, q, id)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error DeleteByID post repository")
+		return err
+	}
+
+	return checkRowsAffected(res)
+}
+
+func newPostFilterQuery(req *reqres.ListPostReq) *sqlQuery {
+	q := &sqlQuery{}
+	if req.UserID != 0 {
+		q.where = append(q.where, "user_id = "+q.arg(req.UserID))
+	}
+
+	return q
+}
+
+// FindAll returns one page of the posts, newest first. Like the user list one extra row is fetched to
+// know whether a next page exists.
+func (p *postRepository) FindAll(ctx context.Context, req *reqres.ListPostReq) (*[]domain.Post, *reqres.PageRes, error) {
+	q := newPostFilterQuery(req)
+	if req.Cursor != "" {
+		cursor, err := reqres.DecodeCursor(req.Cursor)
+		if err != nil || cursor.Sort != postSort {
+			log.WithContext(ctx).WithError(err).Warn("error decode cursor FindAll post repository")
+			return nil, nil, fmt.Errorf("%w: invalid cursor", modelErr.ErrBadParamInput)
+		}
+
+		q.where = append(q.where, "id < "+q.arg(cursor.ID))
+	}
+
+	query := "SELECT " + postColumns + " FROM posts" + q.whereClause() + " ORDER BY id DESC LIMIT " + q.arg(req.Limit+1)
+	if req.Cursor == "" {
+		query += " OFFSET " + q.arg(req.Offset)
+	}
+
+	rows, err := conn(ctx, p.db).QueryContext(ctx, query, q.args...)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error FindAll post repository")
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	result := make([]domain.Post, 0, req.Limit+1)
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			log.WithError(err).Error("error while scan row")
+			return nil, nil, err
+		}
+
+		result = append(result, *post)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.WithError(err).Error("error FindAll post repository")
+		return nil, nil, err
+	}
+
+	pageRes := reqres.PageRes{}
+	if len(result) > req.Limit {
+		result = result[:req.Limit]
+		last := result[len(result)-1]
+
+		pageRes.HasMore = true
+		pageRes.NextCursor = reqres.EncodeCursor(reqres.Cursor{ID: last.ID, Sort: postSort})
+	}
+
+	return &result, &pageRes, nil
+}
+
+func (p *postRepository) Count(ctx context.Context, req *reqres.ListPostReq) (int64, error) {
+	var total int64
+	q := newPostFilterQuery(req)
+	err := conn(ctx, p.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM posts"+q.whereClause(), q.args...).Scan(&total)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error Count post repository")
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (p *postRepository) FindByID(ctx context.Context, id int64) (*domain.Post, error) {
+	q := "SELECT " + postColumns + " FROM posts WHERE id = $1"
+	post, err := scanPost(conn(ctx, p.db).QueryRowContext(ctx, q, id))
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error FindByID post repository")
+		return nil, translateError(err)
+	}
+
+	return post, nil
+}
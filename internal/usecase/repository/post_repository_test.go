@@ -0,0 +1,225 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"go-rest-api-boilerplate/internal/domain"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/model/reqres"
+	"go-rest-api-boilerplate/internal/usecase/repository"
+)
+
+var postRowColumns = []string{"id", "user_id", "title", "content", "created_at", "updated_at"}
+
+func TestNewPostRepository(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db, _ := newUserDBTest(t)
+		defer db.Close()
+
+		repo := repository.NewPostRepository(db)
+		assert.NotNil(t, repo)
+	})
+}
+
+func TestPostRepository_Save(t *testing.T) {
+	expectSQL := "INSERT INTO posts (user_id, title, content, updated_at, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	post := domain.Post{UserID: 1, Title: "hello", Content: "world", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery(expectSQL).WithArgs(post.UserID, post.Title, post.Content, post.UpdatedAt, post.CreatedAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+		newPost := post
+		repo := repository.NewPostRepository(db)
+		err := repo.Save(context.TODO(), &newPost)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), newPost.ID)
+	})
+
+	t.Run("error:unknown author", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery(expectSQL).WithArgs(post.UserID, post.Title, post.Content, post.UpdatedAt, post.CreatedAt).
+			WillReturnError(&pq.Error{Code: "23503", Constraint: "posts_user_id_fkey"})
+
+		newPost := post
+		repo := repository.NewPostRepository(db)
+		err := repo.Save(context.TODO(), &newPost)
+		assert.ErrorIs(t, err, modelErr.ErrBadParamInput)
+
+		var fieldErr *modelErr.FieldError
+		assert.ErrorAs(t, err, &fieldErr)
+		assert.Equal(t, "user_id", fieldErr.Field)
+		assert.Equal(t, "exists", fieldErr.Rule)
+	})
+}
+
+func TestPostRepository_UpdateByID(t *testing.T) {
+	expectSQL := "UPDATE posts SET title = $1, content = $2, updated_at = $3 WHERE id = $4 " +
+		"RETURNING id, user_id, title, content, created_at, updated_at"
+
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows(postRowColumns).AddRow(1, 2, "hello", "world", time.Now(), time.Now())
+		mock.ExpectQuery(expectSQL).WithArgs("hello", "world", AnyTime{}, 1).WillReturnRows(rows)
+
+		repo := repository.NewPostRepository(db)
+		post, err := repo.UpdateByID(context.TODO(), 1, &domain.Post{Title: "hello", Content: "world"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), post.UserID)
+	})
+
+	t.Run("error:not found", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery(expectSQL).WithArgs("hello", "world", AnyTime{}, 1).WillReturnRows(sqlmock.NewRows(postRowColumns))
+
+		repo := repository.NewPostRepository(db)
+		_, err := repo.UpdateByID(context.TODO(), 1, &domain.Post{Title: "hello", Content: "world"})
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
+	})
+}
+
+func TestPostRepository_DeleteByID(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectExec("DELETE FROM posts WHERE id = $1").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		repo := repository.NewPostRepository(db)
+		err := repo.DeleteByID(context.TODO(), 1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("error:not found", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectExec("DELETE FROM posts WHERE id = $1").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		repo := repository.NewPostRepository(db)
+		err := repo.DeleteByID(context.TODO(), 1)
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
+	})
+}
+
+func TestPostRepository_FindAll(t *testing.T) {
+	t.Run("success:has more", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows(postRowColumns).
+			AddRow(9, 1, "second", "post", time.Now(), time.Now()).
+			AddRow(8, 1, "first", "post", time.Now(), time.Now())
+		mock.ExpectQuery("SELECT id, user_id, title, content, created_at, updated_at FROM posts WHERE user_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3").
+			WithArgs(int64(1), 2, 0).WillReturnRows(rows)
+
+		repo := repository.NewPostRepository(db)
+		posts, page, err := repo.FindAll(context.TODO(), &reqres.ListPostReq{PageReq: reqres.PageReq{Limit: 1}, UserID: 1})
+		assert.NoError(t, err)
+		assert.Len(t, *posts, 1)
+		assert.True(t, page.HasMore)
+
+		cursor, err := reqres.DecodeCursor(page.NextCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(9), cursor.ID)
+	})
+
+	t.Run("success:cursor", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows(postRowColumns).AddRow(8, 1, "first", "post", time.Now(), time.Now())
+		mock.ExpectQuery("SELECT id, user_id, title, content, created_at, updated_at FROM posts WHERE id < $1 ORDER BY id DESC LIMIT $2").
+			WithArgs(int64(9), 11).WillReturnRows(rows)
+
+		cursor := reqres.EncodeCursor(reqres.Cursor{ID: 9, Sort: "-id"})
+		repo := repository.NewPostRepository(db)
+		posts, page, err := repo.FindAll(context.TODO(), &reqres.ListPostReq{PageReq: reqres.PageReq{Limit: 10, Cursor: cursor}})
+		assert.NoError(t, err)
+		assert.Len(t, *posts, 1)
+		assert.False(t, page.HasMore)
+	})
+
+	t.Run("error:cursor", func(t *testing.T) {
+		db, _ := newUserDBTest(t)
+		defer db.Close()
+
+		cursor := reqres.EncodeCursor(reqres.Cursor{ID: 9, Sort: "created_at"})
+		repo := repository.NewPostRepository(db)
+		_, _, err := repo.FindAll(context.TODO(), &reqres.ListPostReq{PageReq: reqres.PageReq{Limit: 10, Cursor: cursor}})
+		assert.ErrorIs(t, err, modelErr.ErrBadParamInput)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT id, user_id, title, content, created_at, updated_at FROM posts ORDER BY id DESC LIMIT $1 OFFSET $2").
+			WithArgs(11, 0).WillReturnError(errors.New("Unexpexted Error"))
+
+		repo := repository.NewPostRepository(db)
+		posts, page, err := repo.FindAll(context.TODO(), &reqres.ListPostReq{PageReq: reqres.PageReq{Limit: 10}})
+		assert.Error(t, err)
+		assert.Nil(t, posts)
+		assert.Nil(t, page)
+	})
+}
+
+func TestPostRepository_Count(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT COUNT(*) FROM posts WHERE user_id = $1").WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+		repo := repository.NewPostRepository(db)
+		total, err := repo.Count(context.TODO(), &reqres.ListPostReq{UserID: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), total)
+	})
+}
+
+func TestPostRepository_FindByID(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows(postRowColumns).AddRow(1, 2, "hello", nil, time.Now(), time.Now())
+		mock.ExpectQuery("SELECT id, user_id, title, content, created_at, updated_at FROM posts WHERE id = $1").WithArgs(1).WillReturnRows(rows)
+
+		repo := repository.NewPostRepository(db)
+		post, err := repo.FindByID(context.TODO(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", post.Title)
+		assert.Equal(t, "", post.Content)
+	})
+
+	t.Run("error:not found", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT id, user_id, title, content, created_at, updated_at FROM posts WHERE id = $1").WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(postRowColumns))
+
+		repo := repository.NewPostRepository(db)
+		post, err := repo.FindByID(context.TODO(), 1)
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
+		assert.Nil(t, post)
+	})
+}
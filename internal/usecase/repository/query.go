@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"go-rest-api-boilerplate/internal/db"
+)
+
+type querier = db.Querier
+
+// conn returns the transaction ctx runs within or conn, every query of the repositories runs on it so a
+// service can make several writes atomic with TxManager.WithinTx.
+func conn(ctx context.Context, sqlDB *sql.DB) querier {
+	return db.Conn(ctx, sqlDB)
+}
+
+// sqlQuery builds a parameterized query, every value goes through arg so it never ends up in the SQL text.
+type sqlQuery struct {
+	where []string
+	args  []interface{}
+}
+
+func (q *sqlQuery) arg(v interface{}) string {
+	q.args = append(q.args, v)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+func (q *sqlQuery) whereClause() string {
+	if len(q.where) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(q.where, " AND ")
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/internal/domain"
+)
+
+const refreshTokenColumns = "id, user_id, family_id, token_hash, expires_at, used_at, revoked_at, created_at"
+
+type refreshTokenRepository struct {
+	db *sql.DB
+}
+
+func NewRefreshTokenRepository(db *sql.DB) domain.RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func scanRefreshToken(row rowScanner) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	var usedAt, revokedAt sql.NullTime
+	err := row.Scan(&token.ID, &token.UserID, &token.FamilyID, &token.Hash, &token.ExpiresAt, &usedAt, &revokedAt, &token.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	token.UsedAt, token.RevokedAt = nullTimePtr(usedAt), nullTimePtr(revokedAt)
+	return &token, nil
+}
+
+// Save inserts the token and sets its generated id.
+func (t *refreshTokenRepository) Save(ctx context.Context, token *domain.RefreshToken) error {
+	q := "INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	err := conn(ctx, t.db).QueryRowContext(ctx, q, token.UserID, token.FamilyID, token.Hash, token.ExpiresAt, token.CreatedAt).Scan(&token.ID)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error save refresh token repository")
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (t *refreshTokenRepository) FindByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	q := "SELECT " + refreshTokenColumns + " FROM refresh_tokens WHERE token_hash = $1"
+	token, err := scanRefreshToken(conn(ctx, t.db).QueryRowContext(ctx, q, hash))
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error FindByHash refresh token repository")
+		return nil, translateError(err)
+	}
+
+	return token, nil
+}
+
+// MarkUsed only marks a token not used yet, so of two concurrent rotations of the same token one loses
+// with ErrNotFound.
+func (t *refreshTokenRepository) MarkUsed(ctx context.Context, id int64, at time.Time) error {
+	q := "UPDATE refresh_tokens SET used_at = $1 WHERE id = $2 AND used_at IS NULL"
+	res, err := conn(ctx, t.db).ExecContext(ctx, q, at, id)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error MarkUsed refresh token repository")
+		return err
+	}
+
+	return checkRowsAffected(res)
+}
+
+func (t *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	q := "UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL"
+	_, err := conn(ctx, t.db).ExecContext(ctx, q, at, familyID)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error RevokeFamily refresh token repository")
+		return err
+	}
+
+	return nil
+}
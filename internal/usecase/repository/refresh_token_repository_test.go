@@ -0,0 +1,96 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go-rest-api-boilerplate/internal/domain"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/usecase/repository"
+)
+
+var refreshTokenRowColumns = []string{"id", "user_id", "family_id", "token_hash", "expires_at", "used_at", "revoked_at", "created_at"}
+
+func TestRefreshTokenRepository_Save(t *testing.T) {
+	db, mock := newUserDBTest(t)
+	defer db.Close()
+
+	token := domain.RefreshToken{UserID: 1, FamilyID: "family", Hash: "hash", ExpiresAt: time.Now().Add(time.Hour), CreatedAt: time.Now()}
+	mock.ExpectQuery("INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id").
+		WithArgs(1, "family", "hash", token.ExpiresAt, token.CreatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+
+	repo := repository.NewRefreshTokenRepository(db)
+	err := repo.Save(context.TODO(), &token)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), token.ID)
+}
+
+func TestRefreshTokenRepository_FindByHash(t *testing.T) {
+	expectSQL := "SELECT id, user_id, family_id, token_hash, expires_at, used_at, revoked_at, created_at FROM refresh_tokens WHERE token_hash = $1"
+
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows(refreshTokenRowColumns).AddRow(5, 1, "family", "hash", time.Now(), time.Now(), nil, time.Now())
+		mock.ExpectQuery(expectSQL).WithArgs("hash").WillReturnRows(rows)
+
+		repo := repository.NewRefreshTokenRepository(db)
+		token, err := repo.FindByHash(context.TODO(), "hash")
+		assert.NoError(t, err)
+		assert.Equal(t, "family", token.FamilyID)
+		assert.NotNil(t, token.UsedAt)
+		assert.Nil(t, token.RevokedAt)
+	})
+
+	t.Run("error:not found", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery(expectSQL).WithArgs("hash").WillReturnRows(sqlmock.NewRows(refreshTokenRowColumns))
+
+		repo := repository.NewRefreshTokenRepository(db)
+		_, err := repo.FindByHash(context.TODO(), "hash")
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
+	})
+}
+
+func TestRefreshTokenRepository_MarkUsed(t *testing.T) {
+	expectSQL := "UPDATE refresh_tokens SET used_at = $1 WHERE id = $2 AND used_at IS NULL"
+
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectExec(expectSQL).WithArgs(AnyTime{}, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		repo := repository.NewRefreshTokenRepository(db)
+		assert.NoError(t, repo.MarkUsed(context.TODO(), 5, time.Now()))
+	})
+
+	t.Run("error:already used", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectExec(expectSQL).WithArgs(AnyTime{}, 5).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		repo := repository.NewRefreshTokenRepository(db)
+		assert.ErrorIs(t, repo.MarkUsed(context.TODO(), 5, time.Now()), modelErr.ErrNotFound)
+	})
+}
+
+func TestRefreshTokenRepository_RevokeFamily(t *testing.T) {
+	db, mock := newUserDBTest(t)
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL").
+		WithArgs(AnyTime{}, "family").WillReturnResult(sqlmock.NewResult(0, 3))
+
+	repo := repository.NewRefreshTokenRepository(db)
+	assert.NoError(t, repo.RevokeFamily(context.TODO(), "family", time.Now()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
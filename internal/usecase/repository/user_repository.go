@@ -3,12 +3,19 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"go-rest-api-boilerplate/internal/domain"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/model/reqres"
 )
 
+const userColumns = "id, first_name, last_name, email, created_at, updated_at, deleted_at, version"
+
 type userRepository struct {
 	db *sql.DB
 }
@@ -17,75 +24,471 @@ func NewUserRepository(db *sql.DB) domain.UserRepository {
 	return &userRepository{db: db}
 }
 
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row rowScanner) (*domain.User, error) {
+	var user domain.User
+	var lastName sql.NullString
+	var deletedAt sql.NullTime
+	err := row.Scan(&user.ID, &user.FirstName, &lastName, &user.Email, &user.CreatedAt, &user.UpdatedAt, &deletedAt, &user.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	user.LastName = lastName.String
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+	return &user, nil
+}
+
+// Save inserts the user and sets its generated id and first version.
 func (u *userRepository) Save(ctx context.Context, user *domain.User) error {
-	q := "INSERT INTO users (first_name, last_name, email, updated_at, created_at) VALUES ($1, $2, $3, $4, $5)"
-	_, err := u.db.ExecContext(ctx, q, user.FirstName, user.LastName, user.Email, user.UpdatedAt, user.CreatedAt)
+	q := "INSERT INTO users (first_name, last_name, email, password_hash, updated_at, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, version"
+	passwordHash := sql.NullString{String: user.PasswordHash, Valid: user.PasswordHash != ""}
+	err := conn(ctx, u.db).QueryRowContext(ctx, q, user.FirstName, user.LastName, user.Email, passwordHash, user.UpdatedAt, user.CreatedAt).Scan(&user.ID, &user.Version)
 	if err != nil {
 		log.WithContext(ctx).WithError(err).Error("error save user repository")
-		return err
+		return translateError(err)
 	}
 
 	return nil
 }
 
-func (u *userRepository) UpdateByID(ctx context.Context, id int64, user *domain.User) error {
-	q := "UPDATE users SET first_name = $1, last_name = $2, email = $3, updated_at = $4 where id = $5"
-	_, err := u.db.ExecContext(ctx, q, user.FirstName, user.LastName, user.Email, time.Now(), id)
+// UpdateByID replaces the user, only at the given version unless it is 0. A version mismatch is
+// reported as ErrNotFound like a missing user.
+func (u *userRepository) UpdateByID(ctx context.Context, id int64, version int64, user *domain.User) (*domain.User, error) {
+	return updateUserByID(ctx, conn(ctx, u.db), id, version, user)
+}
+
+func updateUserByID(ctx context.Context, db querier, id int64, version int64, user *domain.User) (*domain.User, error) {
+	q := &sqlQuery{}
+	query := "UPDATE users SET first_name = " + q.arg(user.FirstName) + ", last_name = " + q.arg(user.LastName) +
+		", email = " + q.arg(user.Email) + ", updated_at = " + q.arg(time.Now()) + ", version = version + 1" +
+		" WHERE id = " + q.arg(id) + " AND deleted_at IS NULL" + versionCondition(q, version) + " RETURNING " + userColumns
+	updated, err := scanUser(db.QueryRowContext(ctx, query, q.args...))
 	if err != nil {
 		log.WithError(err).Error("error UpdateByID user repository")
-		return err
+		return nil, translateError(err)
 	}
 
-	return nil
+	return updated, nil
 }
 
-func (u *userRepository) DeleteByID(ctx context.Context, id int64) error {
-	q := "DELETE FROM users WHERE id = $1"
-	_, err := u.db.ExecContext(ctx, q, id)
+// versionCondition restricts a write to the given version of the row, it is empty for version 0.
+func versionCondition(q *sqlQuery, version int64) string {
+	if version == 0 {
+		return ""
+	}
+
+	return " AND version = " + q.arg(version)
+}
+
+// PatchByID updates only the columns present in the patch, a null clears the column. Like UpdateByID it
+// only writes the given version unless it is 0.
+func (u *userRepository) PatchByID(ctx context.Context, id int64, version int64, patch *reqres.PatchUserReq) (*domain.User, error) {
+	q := &sqlQuery{}
+	var set []string
+	if patch.FirstName.Set {
+		set = append(set, "first_name = "+q.arg(patch.FirstName))
+	}
+	if patch.LastName.Set {
+		set = append(set, "last_name = "+q.arg(patch.LastName))
+	}
+	if patch.Email.Set {
+		set = append(set, "email = "+q.arg(patch.Email))
+	}
+	set = append(set, "updated_at = "+q.arg(time.Now()), "version = version + 1")
+
+	query := "UPDATE users SET " + strings.Join(set, ", ") + " WHERE id = " + q.arg(id) + " AND deleted_at IS NULL" +
+		versionCondition(q, version) + " RETURNING " + userColumns
+	user, err := scanUser(conn(ctx, u.db).QueryRowContext(ctx, query, q.args...))
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error PatchByID user repository")
+		return nil, translateError(err)
+	}
+
+	return user, nil
+}
+
+// DeleteByID soft deletes the user, the row stays until it is purged. Like UpdateByID it only deletes the
+// given version unless it is 0.
+func (u *userRepository) DeleteByID(ctx context.Context, id int64, version int64) error {
+	return deleteUserByID(ctx, conn(ctx, u.db), id, version)
+}
+
+func deleteUserByID(ctx context.Context, db querier, id int64, version int64) error {
+	q := &sqlQuery{}
+	query := "UPDATE users SET deleted_at = " + q.arg(time.Now()) + ", version = version + 1 WHERE id = " + q.arg(id) +
+		" AND deleted_at IS NULL" + versionCondition(q, version)
+	res, err := db.ExecContext(ctx, query, q.args...)
 	if err != nil {
 		log.WithError(err).Error("error DeleteByID user repository")
 		return err
 	}
 
-	return nil
+	return checkRowsAffected(res)
+}
+
+// Restore brings back a soft deleted user, ErrNotFound when there is no such deleted user.
+func (u *userRepository) Restore(ctx context.Context, id int64) (*domain.User, error) {
+	q := "UPDATE users SET deleted_at = NULL, updated_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NOT NULL RETURNING " + userColumns
+	user, err := scanUser(conn(ctx, u.db).QueryRowContext(ctx, q, time.Now(), id))
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error Restore user repository")
+		return nil, translateError(err)
+	}
+
+	return user, nil
+}
+
+// batchInsertRows caps the rows of one INSERT of a batch, 6 parameters a row stay far below the 65535
+// parameters postgres allows in a statement.
+const batchInsertRows = 1000
+
+// ApplyBatch runs the operations in order, the creates following each other with multi-row INSERTs. With
+// stopOnError the operations after the first failed one are left without result, an atomic batch runs it
+// within a transaction rolled back on that failure.
+func (u *userRepository) ApplyBatch(ctx context.Context, ops []domain.UserBatchOp, stopOnError bool) ([]domain.UserBatchResult, error) {
+	db := conn(ctx, u.db)
+	results := make([]domain.UserBatchResult, len(ops))
+	for i := 0; i < len(ops); {
+		n := 1
+		switch ops[i].Op {
+		case reqres.BatchOpCreate:
+			for i+n < len(ops) && n < batchInsertRows && ops[i+n].Op == reqres.BatchOpCreate {
+				n++
+			}
+			saveUsers(ctx, db, ops[i:i+n], results[i:i+n])
+		case reqres.BatchOpUpdate:
+			results[i].User, results[i].Err = updateUserByID(ctx, db, ops[i].ID, ops[i].Version, ops[i].User)
+		case reqres.BatchOpDelete:
+			results[i].Err = deleteUserByID(ctx, db, ops[i].ID, ops[i].Version)
+		default:
+			results[i].Err = fmt.Errorf("%w: op %s", modelErr.ErrBadParamInput, ops[i].Op)
+		}
+
+		if stopOnError && batchFailed(results[i:i+n]) {
+			return results, nil
+		}
+		i += n
+	}
+
+	return results, nil
+}
+
+func batchFailed(results []domain.UserBatchResult) bool {
+	for _, res := range results {
+		if res.Err != nil {
+			return true
+		}
+	}
+
+	return false
 }
 
-func (u *userRepository) FindAll(ctx context.Context) (*[]domain.User, error) {
-	q := "SELECT * FROM users"
-	rows, err := u.db.QueryContext(ctx, q)
+// saveUsers inserts the users of the create operations with a single INSERT and sets their generated id
+// and version. A user whose email is taken, by an existing user or by an earlier user of the INSERT, is
+// skipped and fails with a conflict, any other error fails every user.
+func saveUsers(ctx context.Context, db querier, ops []domain.UserBatchOp, results []domain.UserBatchResult) {
+	q := &sqlQuery{}
+	values := make([]string, len(ops))
+	for i, op := range ops {
+		passwordHash := sql.NullString{String: op.User.PasswordHash, Valid: op.User.PasswordHash != ""}
+		values[i] = "(" + q.arg(op.User.FirstName) + ", " + q.arg(op.User.LastName) + ", " + q.arg(op.User.Email) + ", " +
+			q.arg(passwordHash) + ", " + q.arg(op.User.UpdatedAt) + ", " + q.arg(op.User.CreatedAt) + ")"
+	}
+
+	query := "INSERT INTO users (first_name, last_name, email, password_hash, updated_at, created_at) VALUES " +
+		strings.Join(values, ", ") + " ON CONFLICT DO NOTHING RETURNING id, version, email"
+	inserted, err := scanInsertedUsers(db.QueryContext(ctx, query, q.args...))
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error saveUsers user repository")
+		for i := range results {
+			results[i].Err = translateError(err)
+		}
+		return
+	}
+
+	// The emails are unique regardless of their case, so they tell which user each returned row is.
+	for i, op := range ops {
+		email := strings.ToLower(op.User.Email)
+		row, ok := inserted[email]
+		if !ok {
+			results[i].Err = &modelErr.FieldError{Field: "email", Rule: "unique", Err: modelErr.ErrConflict}
+			continue
+		}
+
+		delete(inserted, email)
+		op.User.ID, op.User.Version = row.ID, row.Version
+		results[i].User = op.User
+	}
+}
+
+// scanInsertedUsers reads the id and version of the inserted users by their lower case email.
+func scanInsertedUsers(rows *sql.Rows, err error) (map[string]domain.User, error) {
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	result := make([]domain.User, 0)
+	inserted := make(map[string]domain.User)
 	for rows.Next() {
 		var user domain.User
-		err := rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.CreatedAt, &user.UpdatedAt)
+		if err := rows.Scan(&user.ID, &user.Version, &user.Email); err != nil {
+			return nil, err
+		}
+		inserted[strings.ToLower(user.Email)] = user
+	}
+
+	return inserted, rows.Err()
+}
+
+// PurgeDeleted permanently removes the users soft deleted before the given time.
+func (u *userRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	q := "DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < $1"
+	res, err := conn(ctx, u.db).ExecContext(ctx, q, before)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error PurgeDeleted user repository")
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// userSortColumns whitelists the sortable fields of the user list and the column behind each of them,
+// last_name is nullable so it is coalesced to keep the keyset comparison total.
+var userSortColumns = map[string]string{
+	"id":         "id",
+	"first_name": "first_name",
+	"last_name":  "COALESCE(last_name, '')",
+	"email":      "email",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+func newUserFilterQuery(req *reqres.ListUserReq) *sqlQuery {
+	q := &sqlQuery{}
+	if !req.IncludeDeleted {
+		q.where = append(q.where, "deleted_at IS NULL")
+	}
+	if req.Email != "" {
+		q.where = append(q.where, "LOWER(email) = LOWER("+q.arg(req.Email)+")")
+	}
+	if req.FirstName != "" {
+		q.where = append(q.where, "LOWER(first_name) = LOWER("+q.arg(req.FirstName)+")")
+	}
+	if req.LastName != "" {
+		q.where = append(q.where, "LOWER(last_name) = LOWER("+q.arg(req.LastName)+")")
+	}
+	if req.Q != "" {
+		p := q.arg("%" + escapeLike(req.Q) + "%")
+		q.where = append(q.where, "(first_name ILIKE "+p+" OR last_name ILIKE "+p+" OR email ILIKE "+p+")")
+	}
+
+	return q
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func parseUserSort(sort string) (field string, desc bool, err error) {
+	if sort == "" {
+		sort = reqres.DefaultUserSort
+	}
+
+	field = strings.TrimPrefix(sort, "-")
+	if _, ok := userSortColumns[field]; !ok {
+		return "", false, fmt.Errorf("%w: unknown sort field %s", modelErr.ErrBadParamInput, field)
+	}
+
+	return field, strings.HasPrefix(sort, "-"), nil
+}
+
+func userSortValue(user *domain.User, field string) string {
+	switch field {
+	case "id":
+		return strconv.FormatInt(user.ID, 10)
+	case "first_name":
+		return user.FirstName
+	case "last_name":
+		return user.LastName
+	case "email":
+		return user.Email
+	case "updated_at":
+		return user.UpdatedAt.Format(time.RFC3339Nano)
+	default:
+		return user.CreatedAt.Format(time.RFC3339Nano)
+	}
+}
+
+// Each calls fn with every user matching the filters of req by id, as the rows are read. The paging and
+// sort of req are ignored, an error of fn stops the iteration and is returned.
+func (u *userRepository) Each(ctx context.Context, req *reqres.ListUserReq, fn func(user *domain.User) error) error {
+	q := newUserFilterQuery(req)
+	rows, err := conn(ctx, u.db).QueryContext(ctx, "SELECT "+userColumns+" FROM users"+q.whereClause()+" ORDER BY id", q.args...)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error Each user repository")
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Error("error while scan row")
+			return err
+		}
+
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// FindAll returns one page of the filtered users ordered by the requested sort with id as tie breaker.
+// One extra row is fetched to know whether a next page exists, the cursor of the last returned row is
+// handed back as the next cursor.
+func (u *userRepository) FindAll(ctx context.Context, req *reqres.ListUserReq) (*[]domain.User, *reqres.PageRes, error) {
+	field, desc, err := parseUserSort(req.Sort)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	column, op, dir := userSortColumns[field], ">", "ASC"
+	if desc {
+		op, dir = "<", "DESC"
+	}
+
+	q := newUserFilterQuery(req)
+	if req.Cursor != "" {
+		cursor, err := reqres.DecodeCursor(req.Cursor)
+		if err != nil || cursor.Sort != req.Sort {
+			log.WithContext(ctx).WithError(err).Warn("error decode cursor FindAll user repository")
+			return nil, nil, fmt.Errorf("%w: invalid cursor", modelErr.ErrBadParamInput)
+		}
+
+		if field == "id" {
+			q.where = append(q.where, "id "+op+" "+q.arg(cursor.ID))
+		} else {
+			q.where = append(q.where, "("+column+", id) "+op+" ("+q.arg(cursor.Value)+", "+q.arg(cursor.ID)+")")
+		}
+	}
+
+	orderBy := " ORDER BY " + column + " " + dir
+	if field != "id" {
+		orderBy += ", id " + dir
+	}
+
+	query := "SELECT " + userColumns + " FROM users" + q.whereClause() + orderBy + " LIMIT " + q.arg(req.Limit+1)
+	if req.Cursor == "" {
+		query += " OFFSET " + q.arg(req.Offset)
+	}
+
+	rows, err := conn(ctx, u.db).QueryContext(ctx, query, q.args...)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error FindAll user repository")
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	result := make([]domain.User, 0, req.Limit+1)
+	for rows.Next() {
+		user, err := scanUser(rows)
 		if err != nil {
 			log.WithError(err).Error("error while scan row")
-			return nil, err
+			return nil, nil, err
 		}
 
-		result = append(result, user)
+		result = append(result, *user)
 	}
 
 	if err = rows.Err(); err != nil {
 		log.WithError(err).Error("error FindAll user repository")
-		return nil, err
+		return nil, nil, err
+	}
+
+	pageRes := reqres.PageRes{}
+	if len(result) > req.Limit {
+		result = result[:req.Limit]
+		last := result[len(result)-1]
+
+		pageRes.HasMore = true
+		pageRes.NextCursor = reqres.EncodeCursor(reqres.Cursor{
+			Value: userSortValue(&last, field),
+			ID:    last.ID,
+			Sort:  req.Sort,
+		})
 	}
 
-	return &result, nil
+	return &result, &pageRes, nil
 }
 
-func (u *userRepository) FindByID(ctx context.Context, id int64) (*domain.User, error) {
-	var user domain.User
-	q := "SELECT * FROM users WHERE id = $1"
-	err := u.db.QueryRowContext(ctx, q, id).Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.CreatedAt, &user.UpdatedAt)
+func (u *userRepository) Count(ctx context.Context, req *reqres.ListUserReq) (int64, error) {
+	var total int64
+	q := newUserFilterQuery(req)
+	err := conn(ctx, u.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+q.whereClause(), q.args...).Scan(&total)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error Count user repository")
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// FindByID returns the user, a soft deleted one only when includeDeleted is set.
+func (u *userRepository) FindByID(ctx context.Context, id int64, includeDeleted bool) (*domain.User, error) {
+	q := "SELECT " + userColumns + " FROM users WHERE id = $1"
+	if !includeDeleted {
+		q += " AND deleted_at IS NULL"
+	}
+	user, err := scanUser(conn(ctx, u.db).QueryRowContext(ctx, q, id))
 	if err != nil {
 		log.WithError(err).Error("error FindByID user repository")
+		return nil, translateError(err)
+	}
+
+	return user, nil
+}
+
+func scanCredentials(row rowScanner) (*domain.UserCredentials, error) {
+	var credentials domain.UserCredentials
+	var passwordHash sql.NullString
+	err := row.Scan(&credentials.UserID, &passwordHash, &credentials.Role)
+	if err != nil {
 		return nil, err
 	}
 
-	return &user, nil
+	credentials.PasswordHash = passwordHash.String
+	return &credentials, nil
+}
+
+// FindCredentialsByEmail returns the credentials of the user of the email, case insensitively like the
+// email uniqueness. Deleted users have no credentials.
+func (u *userRepository) FindCredentialsByEmail(ctx context.Context, email string) (*domain.UserCredentials, error) {
+	q := "SELECT id, password_hash, role FROM users WHERE LOWER(email) = LOWER($1) AND deleted_at IS NULL"
+	credentials, err := scanCredentials(conn(ctx, u.db).QueryRowContext(ctx, q, email))
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error FindCredentialsByEmail user repository")
+		return nil, translateError(err)
+	}
+
+	return credentials, nil
+}
+
+// FindCredentialsByID returns the credentials of the user, deleted users have none.
+func (u *userRepository) FindCredentialsByID(ctx context.Context, id int64) (*domain.UserCredentials, error) {
+	q := "SELECT id, password_hash, role FROM users WHERE id = $1 AND deleted_at IS NULL"
+	credentials, err := scanCredentials(conn(ctx, u.db).QueryRowContext(ctx, q, id))
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("error FindCredentialsByID user repository")
+		return nil, translateError(err)
+	}
+
+	return credentials, nil
 }
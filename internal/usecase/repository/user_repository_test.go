@@ -4,12 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
+	"go-rest-api-boilerplate/internal/db"
 	"go-rest-api-boilerplate/internal/domain"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/model/reqres"
 	"go-rest-api-boilerplate/internal/usecase/repository"
 )
 
@@ -36,34 +41,266 @@ func TestUserRepository_FindAll(t *testing.T) {
 		db, mock := newUserDBTest(t)
 		defer db.Close()
 
-		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "updated_at", "created_at"}).
-			AddRow(1, "john", "due", "john@mail.com", time.Now(), time.Now()).
-			AddRow(2, "first", "name", "example@mail.com", time.Now(), time.Now())
+		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}).
+			AddRow(1, "john", "due", "john@mail.com", time.Now(), time.Now(), nil, 1).
+			AddRow(2, "first", "name", "example@mail.com", time.Now(), time.Now(), nil, 1)
 
-		mock.ExpectQuery("SELECT * FROM users").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT id, first_name, last_name, email, created_at, updated_at, deleted_at, version FROM users WHERE deleted_at IS NULL ORDER BY created_at ASC, id ASC LIMIT $1 OFFSET $2").
+			WithArgs(11, 0).WillReturnRows(rows)
 		repo := repository.NewUserRepository(db)
 
-		users, err := repo.FindAll(context.TODO())
+		users, page, err := repo.FindAll(context.TODO(), &reqres.ListUserReq{PageReq: reqres.PageReq{Limit: 10}})
 		assert.NoError(t, err)
 		assert.NotNil(t, users)
 
 		assert.Equal(t, "john", (*users)[0].FirstName)
 		assert.Equal(t, "first", (*users)[1].FirstName)
+		assert.False(t, page.HasMore)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("success:has more", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		createdAt := time.Date(2022, 9, 1, 10, 0, 0, 0, time.UTC)
+		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}).
+			AddRow(1, "john", "due", "john@mail.com", createdAt, createdAt, nil, 1).
+			AddRow(2, "first", "name", "example@mail.com", createdAt, createdAt, nil, 1)
+
+		mock.ExpectQuery("SELECT id, first_name, last_name, email, created_at, updated_at, deleted_at, version FROM users WHERE deleted_at IS NULL ORDER BY created_at ASC, id ASC LIMIT $1 OFFSET $2").
+			WithArgs(2, 5).WillReturnRows(rows)
+		repo := repository.NewUserRepository(db)
+
+		users, page, err := repo.FindAll(context.TODO(), &reqres.ListUserReq{PageReq: reqres.PageReq{Limit: 1, Offset: 5}})
+		assert.NoError(t, err)
+		assert.Len(t, *users, 1)
+		assert.True(t, page.HasMore)
+
+		cursor, err := reqres.DecodeCursor(page.NextCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), cursor.ID)
+		assert.Equal(t, createdAt.Format(time.RFC3339Nano), cursor.Value)
+	})
+
+	t.Run("success:cursor", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}).
+			AddRow(3, "john", "due", "john@mail.com", time.Now(), time.Now(), nil, 1)
+
+		cursor := reqres.Cursor{Value: "2022-09-01T10:00:00Z", ID: 2}
+		mock.ExpectQuery("SELECT id, first_name, last_name, email, created_at, updated_at, deleted_at, version FROM users WHERE deleted_at IS NULL AND (created_at, id) > ($1, $2) ORDER BY created_at ASC, id ASC LIMIT $3").
+			WithArgs(cursor.Value, cursor.ID, 11).WillReturnRows(rows)
+		repo := repository.NewUserRepository(db)
+
+		users, page, err := repo.FindAll(context.TODO(), &reqres.ListUserReq{PageReq: reqres.PageReq{Limit: 10, Cursor: reqres.EncodeCursor(cursor)}})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), (*users)[0].ID)
+		assert.False(t, page.HasMore)
+	})
+
+	t.Run("success:filter and sort", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}).
+			AddRow(1, "john", "due", "john@mail.com", time.Now(), time.Now(), nil, 1)
+
+		expectSQL := "SELECT id, first_name, last_name, email, created_at, updated_at, deleted_at, version FROM users " +
+			"WHERE deleted_at IS NULL AND LOWER(email) = LOWER($1) AND LOWER(last_name) = LOWER($2) " +
+			"AND (first_name ILIKE $3 OR last_name ILIKE $3 OR email ILIKE $3) " +
+			"ORDER BY COALESCE(last_name, '') DESC, id DESC LIMIT $4 OFFSET $5"
+		mock.ExpectQuery(expectSQL).WithArgs("john@mail.com", "due", `%jo\_n%`, 11, 0).WillReturnRows(rows)
+		repo := repository.NewUserRepository(db)
+
+		users, _, err := repo.FindAll(context.TODO(), &reqres.ListUserReq{
+			PageReq:  reqres.PageReq{Limit: 10},
+			Email:    "john@mail.com",
+			LastName: "due",
+			Q:        "jo_n",
+			Sort:     "-last_name",
+		})
+		assert.NoError(t, err)
+		assert.Len(t, *users, 1)
+	})
+
+	t.Run("success:include deleted", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}).
+			AddRow(1, "john", "due", "john@mail.com", time.Now(), time.Now(), time.Now(), 1)
+
+		mock.ExpectQuery("SELECT id, first_name, last_name, email, created_at, updated_at, deleted_at, version FROM users ORDER BY created_at ASC, id ASC LIMIT $1 OFFSET $2").
+			WithArgs(11, 0).WillReturnRows(rows)
+		repo := repository.NewUserRepository(db)
+
+		users, _, err := repo.FindAll(context.TODO(), &reqres.ListUserReq{PageReq: reqres.PageReq{Limit: 10}, IncludeDeleted: true})
+		assert.NoError(t, err)
+		assert.NotNil(t, (*users)[0].DeletedAt)
+	})
+
+	t.Run("success:cursor with sort", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}).
+			AddRow(4, "john", "due", "john@mail.com", time.Now(), time.Now(), nil, 1).
+			AddRow(3, "first", "name", "example@mail.com", time.Now(), time.Now(), nil, 1)
+
+		cursor := reqres.Cursor{Value: "5", ID: 5, Sort: "-id"}
+		mock.ExpectQuery("SELECT id, first_name, last_name, email, created_at, updated_at, deleted_at, version FROM users WHERE deleted_at IS NULL AND id < $1 ORDER BY id DESC LIMIT $2").
+			WithArgs(int64(5), 2).WillReturnRows(rows)
+		repo := repository.NewUserRepository(db)
+
+		users, page, err := repo.FindAll(context.TODO(), &reqres.ListUserReq{
+			PageReq: reqres.PageReq{Limit: 1, Cursor: reqres.EncodeCursor(cursor)},
+			Sort:    "-id",
+		})
+		assert.NoError(t, err)
+		assert.Len(t, *users, 1)
+		assert.True(t, page.HasMore)
+
+		next, err := reqres.DecodeCursor(page.NextCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, reqres.Cursor{Value: "4", ID: 4, Sort: "-id"}, *next)
+	})
+
+	t.Run("error:sort", func(t *testing.T) {
+		db, _ := newUserDBTest(t)
+		defer db.Close()
+
+		repo := repository.NewUserRepository(db)
+		users, _, err := repo.FindAll(context.TODO(), &reqres.ListUserReq{PageReq: reqres.PageReq{Limit: 10}, Sort: "password"})
+		assert.ErrorIs(t, err, modelErr.ErrBadParamInput)
+		assert.Nil(t, users)
+	})
+
+	t.Run("error:cursor sort mismatch", func(t *testing.T) {
+		db, _ := newUserDBTest(t)
+		defer db.Close()
+
+		cursor := reqres.EncodeCursor(reqres.Cursor{Value: "5", ID: 5, Sort: "-id"})
+		repo := repository.NewUserRepository(db)
+		users, _, err := repo.FindAll(context.TODO(), &reqres.ListUserReq{PageReq: reqres.PageReq{Limit: 10, Cursor: cursor}})
+		assert.ErrorIs(t, err, modelErr.ErrBadParamInput)
+		assert.Nil(t, users)
 	})
 
 	t.Run("error", func(t *testing.T) {
 		db, mock := newUserDBTest(t)
 		defer db.Close()
 
-		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "updated_at"}).
+		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at"}).
 			AddRow(1, "john", "due", "john@mail.com", time.Now())
 
-		mock.ExpectQuery("SELECT * FROM users").WillReturnRows(rows)
+		mock.ExpectQuery("SELECT id, first_name, last_name, email, created_at, updated_at, deleted_at, version FROM users WHERE deleted_at IS NULL ORDER BY created_at ASC, id ASC LIMIT $1 OFFSET $2").
+			WithArgs(11, 0).WillReturnRows(rows)
 		repo := repository.NewUserRepository(db)
 
-		users, err := repo.FindAll(context.TODO())
+		users, page, err := repo.FindAll(context.TODO(), &reqres.ListUserReq{PageReq: reqres.PageReq{Limit: 10}})
+		assert.Error(t, err)
+		assert.Nil(t, users)
+		assert.Nil(t, page)
+	})
+
+	t.Run("error:cursor", func(t *testing.T) {
+		db, _ := newUserDBTest(t)
+		defer db.Close()
+
+		repo := repository.NewUserRepository(db)
+		users, page, err := repo.FindAll(context.TODO(), &reqres.ListUserReq{PageReq: reqres.PageReq{Limit: 10, Cursor: "not a cursor"}})
 		assert.Error(t, err)
 		assert.Nil(t, users)
+		assert.Nil(t, page)
+	})
+}
+
+func TestUserRepository_Each(t *testing.T) {
+	columns := []string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}
+
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows(columns).
+			AddRow(1, "john", "due", "john@mail.com", time.Now(), time.Now(), nil, 1).
+			AddRow(2, "first", "name", "example@mail.com", time.Now(), time.Now(), time.Now(), 3)
+		mock.ExpectQuery("SELECT id, first_name, last_name, email, created_at, updated_at, deleted_at, version FROM users WHERE (first_name ILIKE $1 OR last_name ILIKE $1 OR email ILIKE $1) ORDER BY id").
+			WithArgs("%mail%").WillReturnRows(rows)
+
+		repo := repository.NewUserRepository(db)
+		var ids []int64
+		err := repo.Each(context.TODO(), &reqres.ListUserReq{Q: "mail", IncludeDeleted: true}, func(user *domain.User) error {
+			ids = append(ids, user.ID)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1, 2}, ids)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error:fn stops the iteration", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows(columns).
+			AddRow(1, "john", "due", "john@mail.com", time.Now(), time.Now(), nil, 1).
+			AddRow(2, "first", "name", "example@mail.com", time.Now(), time.Now(), nil, 1)
+		mock.ExpectQuery("SELECT id, first_name, last_name, email, created_at, updated_at, deleted_at, version FROM users WHERE deleted_at IS NULL ORDER BY id").
+			WillReturnRows(rows)
+
+		repo := repository.NewUserRepository(db)
+		fnErr := errors.New("client gone")
+		calls := 0
+		err := repo.Each(context.TODO(), &reqres.ListUserReq{}, func(user *domain.User) error {
+			calls++
+			return fnErr
+		})
+		assert.ErrorIs(t, err, fnErr)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("error:query", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT id, first_name, last_name, email, created_at, updated_at, deleted_at, version FROM users WHERE deleted_at IS NULL ORDER BY id").
+			WillReturnError(errors.New("Unexpexted Error"))
+
+		repo := repository.NewUserRepository(db)
+		err := repo.Each(context.TODO(), &reqres.ListUserReq{}, func(user *domain.User) error { return nil })
+		assert.Error(t, err)
+	})
+}
+
+func TestUserRepository_Count(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"count"}).AddRow(42)
+		mock.ExpectQuery("SELECT COUNT(*) FROM users WHERE deleted_at IS NULL").WillReturnRows(rows)
+
+		repo := repository.NewUserRepository(db)
+		total, err := repo.Count(context.TODO(), &reqres.ListUserReq{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), total)
+	})
+
+	t.Run("success:filter", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"count"}).AddRow(1)
+		mock.ExpectQuery("SELECT COUNT(*) FROM users WHERE deleted_at IS NULL AND LOWER(first_name) = LOWER($1)").WithArgs("john").WillReturnRows(rows)
+
+		repo := repository.NewUserRepository(db)
+		total, err := repo.Count(context.TODO(), &reqres.ListUserReq{FirstName: "john"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
 	})
 }
 
@@ -72,13 +309,13 @@ func TestUserRepository_FindByID(t *testing.T) {
 		db, mock := newUserDBTest(t)
 		defer db.Close()
 
-		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "updated_at", "created_at"}).
-			AddRow(1, "john", "due", "john@mail.com", time.Now(), time.Now())
+		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}).
+			AddRow(1, "john", "due", "john@mail.com", time.Now(), time.Now(), nil, 1)
 
-		mock.ExpectQuery("SELECT * FROM users WHERE id = $1").WithArgs(1).WillReturnRows(rows)
+		mock.ExpectQuery("SELECT id, first_name, last_name, email, created_at, updated_at, deleted_at, version FROM users WHERE id = $1 AND deleted_at IS NULL").WithArgs(1).WillReturnRows(rows)
 
 		repo := repository.NewUserRepository(db)
-		user, err := repo.FindByID(context.TODO(), 1)
+		user, err := repo.FindByID(context.TODO(), 1, false)
 		assert.NoError(t, err)
 		assert.NotNil(t, user)
 
@@ -86,19 +323,50 @@ func TestUserRepository_FindByID(t *testing.T) {
 		assert.Equal(t, user.FirstName, "john")
 	})
 
+	t.Run("success:null last name", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}).
+			AddRow(1, "john", nil, "john@mail.com", time.Now(), time.Now(), nil, 1)
+
+		mock.ExpectQuery("SELECT id, first_name, last_name, email, created_at, updated_at, deleted_at, version FROM users WHERE id = $1 AND deleted_at IS NULL").WithArgs(1).WillReturnRows(rows)
+
+		repo := repository.NewUserRepository(db)
+		user, err := repo.FindByID(context.TODO(), 1, false)
+		assert.NoError(t, err)
+		assert.Equal(t, "", user.LastName)
+	})
+
+	t.Run("success:include deleted", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		deletedAt := time.Date(2022, 9, 1, 10, 0, 0, 0, time.UTC)
+		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}).
+			AddRow(1, "john", "due", "john@mail.com", time.Now(), time.Now(), deletedAt, 1)
+
+		mock.ExpectQuery("SELECT id, first_name, last_name, email, created_at, updated_at, deleted_at, version FROM users WHERE id = $1").WithArgs(1).WillReturnRows(rows)
+
+		repo := repository.NewUserRepository(db)
+		user, err := repo.FindByID(context.TODO(), 1, true)
+		assert.NoError(t, err)
+		assert.Equal(t, deletedAt, *user.DeletedAt)
+	})
+
 	t.Run("error", func(t *testing.T) {
 		t.Run("errNoRows", func(t *testing.T) {
 			db, mock := newUserDBTest(t)
 			defer db.Close()
 
-			mock.ExpectQuery("SELECT * FROM users WHERE id = $1").WithArgs(1).WillReturnError(sql.ErrNoRows)
+			mock.ExpectQuery("SELECT id, first_name, last_name, email, created_at, updated_at, deleted_at, version FROM users WHERE id = $1 AND deleted_at IS NULL").WithArgs(1).WillReturnError(sql.ErrNoRows)
 
 			repo := repository.NewUserRepository(db)
-			user, err := repo.FindByID(context.TODO(), 1)
+			user, err := repo.FindByID(context.TODO(), 1, false)
 			assert.Error(t, err)
 			assert.Nil(t, user)
 
-			assert.ErrorIs(t, err, sql.ErrNoRows)
+			assert.ErrorIs(t, err, modelErr.ErrNotFound)
 		})
 	})
 }
@@ -116,13 +384,50 @@ func TestUserRepository_Save(t *testing.T) {
 			UpdatedAt: time.Now(),
 		}
 
-		expectSQL := "INSERT INTO users (first_name, last_name, email, updated_at, created_at) VALUES ($1, $2, $3, $4, $5)"
-		mock.ExpectExec(expectSQL).WithArgs(user.FirstName, user.LastName, user.Email, user.UpdatedAt, user.CreatedAt).
-			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectSQL := "INSERT INTO users (first_name, last_name, email, password_hash, updated_at, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, version"
+		mock.ExpectQuery(expectSQL).WithArgs(user.FirstName, user.LastName, user.Email, nil, user.UpdatedAt, user.CreatedAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(7, 1))
+
+		repo := repository.NewUserRepository(db)
+		err := repo.Save(context.TODO(), &user)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), user.ID)
+		assert.Equal(t, int64(1), user.Version)
+	})
+
+	t.Run("success:with password", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		user := domain.User{FirstName: "john", Email: "john@email.test", PasswordHash: "$2a$10$hash"}
+
+		mock.ExpectQuery("INSERT INTO users (first_name, last_name, email, password_hash, updated_at, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, version").WithArgs(user.FirstName, user.LastName, user.Email, "$2a$10$hash", user.UpdatedAt, user.CreatedAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(8, 1))
 
 		repo := repository.NewUserRepository(db)
 		err := repo.Save(context.TODO(), &user)
 		assert.NoError(t, err)
+		assert.Equal(t, int64(8), user.ID)
+	})
+
+	t.Run("error:duplicate email", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		user := domain.User{FirstName: "john", Email: "JOHN@email.test"}
+
+		expectSQL := "INSERT INTO users (first_name, last_name, email, password_hash, updated_at, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, version"
+		mock.ExpectQuery(expectSQL).WithArgs(user.FirstName, user.LastName, user.Email, nil, user.UpdatedAt, user.CreatedAt).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "users_email_unique_idx"})
+
+		repo := repository.NewUserRepository(db)
+		err := repo.Save(context.TODO(), &user)
+		assert.ErrorIs(t, err, modelErr.ErrConflict)
+
+		var fieldErr *modelErr.FieldError
+		assert.ErrorAs(t, err, &fieldErr)
+		assert.Equal(t, "email", fieldErr.Field)
+		assert.Equal(t, "unique", fieldErr.Rule)
 	})
 }
 
@@ -145,13 +450,114 @@ func TestUserRepository_UpdateByID(t *testing.T) {
 			Email:     "john@email.test",
 		}
 
-		expectSQL := "UPDATE users SET first_name = $1, last_name = $2, email = $3, updated_at = $4 where id = $5"
-		mock.ExpectExec(expectSQL).WithArgs(user.FirstName, user.LastName, user.Email, AnyTime{}, 1).
-			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectSQL := "UPDATE users SET first_name = $1, last_name = $2, email = $3, updated_at = $4, version = version + 1 WHERE id = $5 AND deleted_at IS NULL RETURNING id, first_name, last_name, email, created_at, updated_at, deleted_at, version"
+		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}).
+			AddRow(1, "john", "due", "john@email.test", time.Now(), time.Now(), nil, 1)
+		mock.ExpectQuery(expectSQL).WithArgs(user.FirstName, user.LastName, user.Email, AnyTime{}, 1).
+			WillReturnRows(rows)
+
+		repo := repository.NewUserRepository(db)
+		updated, err := repo.UpdateByID(context.TODO(), 1, 0, &user)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), updated.ID)
+		assert.Equal(t, "due", updated.LastName)
+	})
+
+	t.Run("error:not found", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		user := domain.User{FirstName: "john", Email: "john@email.test"}
+
+		expectSQL := "UPDATE users SET first_name = $1, last_name = $2, email = $3, updated_at = $4, version = version + 1 WHERE id = $5 AND deleted_at IS NULL RETURNING id, first_name, last_name, email, created_at, updated_at, deleted_at, version"
+		mock.ExpectQuery(expectSQL).WithArgs(user.FirstName, user.LastName, user.Email, AnyTime{}, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}))
+
+		repo := repository.NewUserRepository(db)
+		_, err := repo.UpdateByID(context.TODO(), 1, 0, &user)
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
+	})
+
+	t.Run("error:duplicate email", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		user := domain.User{FirstName: "john", Email: "john@email.test"}
+
+		expectSQL := "UPDATE users SET first_name = $1, last_name = $2, email = $3, updated_at = $4, version = version + 1 WHERE id = $5 AND deleted_at IS NULL RETURNING id, first_name, last_name, email, created_at, updated_at, deleted_at, version"
+		mock.ExpectQuery(expectSQL).WithArgs(user.FirstName, user.LastName, user.Email, AnyTime{}, 1).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "users_email_unique_idx"})
+
+		repo := repository.NewUserRepository(db)
+		_, err := repo.UpdateByID(context.TODO(), 1, 0, &user)
+		assert.ErrorIs(t, err, modelErr.ErrConflict)
+	})
+
+	t.Run("success:conditional version", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		user := domain.User{FirstName: "john", Email: "john@email.test"}
+
+		expectSQL := "UPDATE users SET first_name = $1, last_name = $2, email = $3, updated_at = $4, version = version + 1 WHERE id = $5 AND deleted_at IS NULL AND version = $6 RETURNING id, first_name, last_name, email, created_at, updated_at, deleted_at, version"
+		mock.ExpectQuery(expectSQL).WithArgs(user.FirstName, user.LastName, user.Email, AnyTime{}, 1, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}).
+				AddRow(1, "john", nil, "john@email.test", time.Now(), time.Now(), nil, 4))
+
+		repo := repository.NewUserRepository(db)
+		updated, err := repo.UpdateByID(context.TODO(), 1, 3, &user)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), updated.Version)
+	})
+}
+
+func TestUserRepository_PatchByID(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery("UPDATE users SET last_name = $1, updated_at = $2, version = version + 1 WHERE id = $3 AND deleted_at IS NULL RETURNING id, first_name, last_name, email, created_at, updated_at, deleted_at, version").
+			WithArgs("due", AnyTime{}, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}).
+				AddRow(1, "john", "due", "john@email.test", time.Now(), time.Now(), nil, 1))
+
+		repo := repository.NewUserRepository(db)
+		user, err := repo.PatchByID(context.TODO(), 1, 0, &reqres.PatchUserReq{LastName: reqres.NewNullString("due")})
+		assert.NoError(t, err)
+		assert.Equal(t, "john", user.FirstName)
+		assert.Equal(t, "due", user.LastName)
+	})
+
+	t.Run("success:null clears", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery("UPDATE users SET first_name = $1, last_name = $2, email = $3, updated_at = $4, version = version + 1 WHERE id = $5 AND deleted_at IS NULL RETURNING id, first_name, last_name, email, created_at, updated_at, deleted_at, version").
+			WithArgs("john", nil, "john@email.test", AnyTime{}, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}).
+				AddRow(1, "john", nil, "john@email.test", time.Now(), time.Now(), nil, 1))
 
 		repo := repository.NewUserRepository(db)
-		err := repo.UpdateByID(context.TODO(), 1, &user)
+		user, err := repo.PatchByID(context.TODO(), 1, 0, &reqres.PatchUserReq{
+			FirstName: reqres.NewNullString("john"),
+			LastName:  reqres.NullString{Set: true},
+			Email:     reqres.NewNullString("john@email.test"),
+		})
 		assert.NoError(t, err)
+		assert.Equal(t, "", user.LastName)
+	})
+
+	t.Run("error:not found", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery("UPDATE users SET last_name = $1, updated_at = $2, version = version + 1 WHERE id = $3 AND deleted_at IS NULL RETURNING id, first_name, last_name, email, created_at, updated_at, deleted_at, version").
+			WithArgs("due", AnyTime{}, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}))
+
+		repo := repository.NewUserRepository(db)
+		_, err := repo.PatchByID(context.TODO(), 1, 0, &reqres.PatchUserReq{LastName: reqres.NewNullString("due")})
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
 	})
 }
 
@@ -160,11 +566,225 @@ func TestUserRepository_DeleteByID(t *testing.T) {
 		db, mock := newUserDBTest(t)
 		defer db.Close()
 
-		mock.ExpectExec("DELETE FROM users WHERE id = $1").WithArgs(1).
+		mock.ExpectExec("UPDATE users SET deleted_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NULL").WithArgs(AnyTime{}, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		repo := repository.NewUserRepository(db)
+		err := repo.DeleteByID(context.TODO(), 1, 0)
+		assert.NoError(t, err)
+	})
+
+	t.Run("error:not found", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectExec("UPDATE users SET deleted_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NULL").WithArgs(AnyTime{}, 1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		repo := repository.NewUserRepository(db)
+		err := repo.DeleteByID(context.TODO(), 1, 0)
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
+	})
+
+	t.Run("error:stale version", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectExec("UPDATE users SET deleted_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NULL AND version = $3").
+			WithArgs(AnyTime{}, 1, 2).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		repo := repository.NewUserRepository(db)
+		err := repo.DeleteByID(context.TODO(), 1, 2)
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
+	})
+}
+
+func TestUserRepository_Restore(t *testing.T) {
+	expectSQL := "UPDATE users SET deleted_at = NULL, updated_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NOT NULL " +
+		"RETURNING id, first_name, last_name, email, created_at, updated_at, deleted_at, version"
+
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}).
+			AddRow(1, "john", "due", "john@mail.com", time.Now(), time.Now(), nil, 1)
+		mock.ExpectQuery(expectSQL).WithArgs(AnyTime{}, 1).WillReturnRows(rows)
+
+		repo := repository.NewUserRepository(db)
+		user, err := repo.Restore(context.TODO(), 1)
+		assert.NoError(t, err)
+		assert.Nil(t, user.DeletedAt)
+	})
+
+	t.Run("error:not found", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery(expectSQL).WithArgs(AnyTime{}, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "created_at", "updated_at", "deleted_at", "version"}))
+
+		repo := repository.NewUserRepository(db)
+		_, err := repo.Restore(context.TODO(), 1)
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
+	})
+
+	t.Run("error:email taken meanwhile", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery(expectSQL).WithArgs(AnyTime{}, 1).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "users_email_unique_idx"})
+
+		repo := repository.NewUserRepository(db)
+		_, err := repo.Restore(context.TODO(), 1)
+		assert.ErrorIs(t, err, modelErr.ErrConflict)
+	})
+}
+
+func TestUserRepository_ApplyBatch(t *testing.T) {
+	insertSQL := "INSERT INTO users (first_name, last_name, email, password_hash, updated_at, created_at) VALUES " +
+		"($1, $2, $3, $4, $5, $6), ($7, $8, $9, $10, $11, $12) ON CONFLICT DO NOTHING RETURNING id, version, email"
+	now := time.Now()
+	newOps := func() []domain.UserBatchOp {
+		return []domain.UserBatchOp{
+			{Op: reqres.BatchOpCreate, User: &domain.User{FirstName: "john", Email: "John@email.test", CreatedAt: now, UpdatedAt: now}},
+			{Op: reqres.BatchOpCreate, User: &domain.User{FirstName: "jane", Email: "jane@email.test", CreatedAt: now, UpdatedAt: now}},
+			{Op: reqres.BatchOpDelete, ID: 3, Version: 2},
+		}
+	}
+
+	t.Run("success:within transaction", func(t *testing.T) {
+		sqlDB, mock := newUserDBTest(t)
+		defer sqlDB.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(insertSQL).
+			WithArgs("john", "", "John@email.test", nil, now, now, "jane", "", "jane@email.test", nil, now, now).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "version", "email"}).AddRow(7, 1, "John@email.test").AddRow(8, 1, "jane@email.test"))
+		mock.ExpectExec("UPDATE users SET deleted_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NULL AND version = $3").
+			WithArgs(AnyTime{}, 3, 2).
 			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		repo := repository.NewUserRepository(sqlDB)
+		var results []domain.UserBatchResult
+		err := db.NewTxManager(sqlDB).WithinTx(context.TODO(), nil, func(ctx context.Context) (err error) {
+			results, err = repo.ApplyBatch(ctx, newOps(), true)
+			return err
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), results[0].User.ID)
+		assert.Equal(t, int64(8), results[1].User.ID)
+		assert.NoError(t, results[2].Err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error:stop on error", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery(insertSQL).
+			WithArgs("john", "", "John@email.test", nil, now, now, "jane", "", "jane@email.test", nil, now, now).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "version", "email"}).AddRow(8, 1, "jane@email.test"))
+
+		repo := repository.NewUserRepository(db)
+		results, err := repo.ApplyBatch(context.TODO(), newOps(), true)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, results[0].Err, modelErr.ErrConflict)
+		assert.Nil(t, results[2].Err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("success:partial", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectQuery(insertSQL).
+			WithArgs("john", "", "John@email.test", nil, now, now, "jane", "", "jane@email.test", nil, now, now).
+			WillReturnError(errors.New("Unexpexted Error"))
+		mock.ExpectExec("UPDATE users SET deleted_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NULL AND version = $3").
+			WithArgs(AnyTime{}, 3, 2).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		repo := repository.NewUserRepository(db)
+		results, err := repo.ApplyBatch(context.TODO(), newOps(), false)
+		assert.NoError(t, err)
+		assert.Error(t, results[0].Err)
+		assert.Error(t, results[1].Err)
+		assert.ErrorIs(t, results[2].Err, modelErr.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserRepository_PurgeDeleted(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		before := time.Date(2022, 9, 1, 10, 0, 0, 0, time.UTC)
+		mock.ExpectExec("DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < $1").WithArgs(before).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		repo := repository.NewUserRepository(db)
+		n, err := repo.PurgeDeleted(context.TODO(), before)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), n)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		mock.ExpectExec("DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < $1").WithArgs(AnyTime{}).
+			WillReturnError(errors.New("Unexpexted Error"))
+
+		repo := repository.NewUserRepository(db)
+		_, err := repo.PurgeDeleted(context.TODO(), time.Now())
+		assert.Error(t, err)
+	})
+}
+
+func TestUserRepository_FindCredentials(t *testing.T) {
+	credentialColumns := []string{"id", "password_hash", "role"}
+
+	t.Run("success:by email", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		expectSQL := "SELECT id, password_hash, role FROM users WHERE LOWER(email) = LOWER($1) AND deleted_at IS NULL"
+		mock.ExpectQuery(expectSQL).WithArgs("John@email.test").
+			WillReturnRows(sqlmock.NewRows(credentialColumns).AddRow(1, "$2a$10$hash", "admin"))
+
+		repo := repository.NewUserRepository(db)
+		credentials, err := repo.FindCredentialsByEmail(context.TODO(), "John@email.test")
+		assert.NoError(t, err)
+		assert.Equal(t, &domain.UserCredentials{UserID: 1, PasswordHash: "$2a$10$hash", Role: "admin"}, credentials)
+	})
+
+	t.Run("success:by id without password", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		expectSQL := "SELECT id, password_hash, role FROM users WHERE id = $1 AND deleted_at IS NULL"
+		mock.ExpectQuery(expectSQL).WithArgs(1).WillReturnRows(sqlmock.NewRows(credentialColumns).AddRow(1, nil, "user"))
 
 		repo := repository.NewUserRepository(db)
-		err := repo.DeleteByID(context.TODO(), 1)
+		credentials, err := repo.FindCredentialsByID(context.TODO(), 1)
 		assert.NoError(t, err)
+		assert.Equal(t, "", credentials.PasswordHash)
+	})
+
+	t.Run("error:not found", func(t *testing.T) {
+		db, mock := newUserDBTest(t)
+		defer db.Close()
+
+		expectSQL := "SELECT id, password_hash, role FROM users WHERE LOWER(email) = LOWER($1) AND deleted_at IS NULL"
+		mock.ExpectQuery(expectSQL).WithArgs("john@email.test").WillReturnRows(sqlmock.NewRows(credentialColumns))
+
+		repo := repository.NewUserRepository(db)
+		_, err := repo.FindCredentialsByEmail(context.TODO(), "john@email.test")
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
 	})
 }
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/config"
+	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/model/auth"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/model/reqres"
+	"go.opentelemetry.io/otel"
+)
+
+// apiKeyScheme starts every plaintext key, the key is apiKeyScheme_<prefix>_<secret> with a random hex
+// prefix and secret.
+const apiKeyScheme = "rak"
+
+// apiKeyTouchInterval throttles the last used writes of a key used by every request of a batch job.
+const apiKeyTouchInterval = time.Minute
+
+type apiKeyService struct {
+	repo domain.ApiKeyRepository
+}
+
+func NewApiKeyService(repo domain.ApiKeyRepository) domain.ApiKeyService {
+	return &apiKeyService{repo: repo}
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken hashes a high entropy secret (API key, refresh token) for storage, unlike a password such a
+// secret needs no slow hash.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseApiKey returns the prefix of a plaintext key, false when it is not shaped like one.
+func parseApiKey(key string) (string, bool) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != apiKeyScheme || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+
+	return parts[1], true
+}
+
+func (a *apiKeyService) Create(ctx context.Context, req *reqres.CreateApiKeyReq) (*domain.ApiKey, string, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "apikey.service.Create")
+	defer span.End()
+
+	for _, scope := range req.Scopes {
+		if !auth.KnownPermission(auth.Permission(scope)) {
+			return nil, "", &modelErr.FieldError{Field: "scopes", Rule: "oneof", Err: modelErr.ErrBadParamInput}
+		}
+	}
+
+	prefix, err := randomHex(4)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext := fmt.Sprintf("%s_%s_%s", apiKeyScheme, prefix, secret)
+
+	now := time.Now()
+	key := domain.ApiKey{
+		Name:      req.Name,
+		Prefix:    prefix,
+		Hash:      hashToken(plaintext),
+		Scopes:    req.Scopes,
+		CreatedAt: now,
+	}
+	if req.TTL > 0 {
+		expiresAt := now.Add(req.TTL)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err = a.repo.Save(ctx, &key); err != nil {
+		return nil, "", err
+	}
+
+	return &key, plaintext, nil
+}
+
+func (a *apiKeyService) FindAll(ctx context.Context) (*[]domain.ApiKey, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "apikey.service.FindAll")
+	defer span.End()
+
+	return a.repo.FindAll(ctx)
+}
+
+func (a *apiKeyService) Revoke(ctx context.Context, id int64) error {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "apikey.service.Revoke")
+	defer span.End()
+
+	return a.repo.Revoke(ctx, id, time.Now())
+}
+
+// Authenticate answers ErrUnauthorized for an unknown, revoked or expired key. The principal of a key is
+// granted its scopes only, its subject apikey:<id> is never the owner of a user.
+func (a *apiKeyService) Authenticate(ctx context.Context, key string) (*auth.Principal, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "apikey.service.Authenticate")
+	defer span.End()
+
+	prefix, ok := parseApiKey(key)
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed api key", modelErr.ErrUnauthorized)
+	}
+
+	apiKey, err := a.repo.FindByPrefix(ctx, prefix)
+	if errors.Is(err, modelErr.ErrNotFound) {
+		return nil, fmt.Errorf("%w: unknown api key", modelErr.ErrUnauthorized)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(apiKey.Hash), []byte(hashToken(key))) != 1 {
+		return nil, fmt.Errorf("%w: unknown api key", modelErr.ErrUnauthorized)
+	}
+
+	now := time.Now()
+	if apiKey.RevokedAt != nil {
+		return nil, fmt.Errorf("%w: api key is revoked", modelErr.ErrUnauthorized)
+	}
+	if apiKey.ExpiresAt != nil && !now.Before(*apiKey.ExpiresAt) {
+		return nil, fmt.Errorf("%w: api key is expired", modelErr.ErrUnauthorized)
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		if err = a.repo.TouchLastUsed(ctx, apiKey.ID, now); err != nil {
+			log.WithContext(ctx).WithError(err).Warnf("failed to record the use of api key %d", apiKey.ID)
+		}
+	}
+
+	scopes := make([]auth.Permission, 0, len(apiKey.Scopes))
+	for _, scope := range apiKey.Scopes {
+		scopes = append(scopes, auth.Permission(scope))
+	}
+
+	return &auth.Principal{Subject: fmt.Sprintf("apikey:%d", apiKey.ID), Scopes: scopes}, nil
+}
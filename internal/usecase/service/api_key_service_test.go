@@ -0,0 +1,144 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/domain/mocks"
+	"go-rest-api-boilerplate/internal/model/auth"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/model/reqres"
+	"go-rest-api-boilerplate/internal/usecase/service"
+)
+
+func TestApiKeyService_Create(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var saved *domain.ApiKey
+		repo := mocks.NewApiKeyRepository(t)
+		repo.On("Save", mock.Anything, mock.AnythingOfType("*domain.ApiKey")).
+			Run(func(args mock.Arguments) { saved = args.Get(1).(*domain.ApiKey) }).
+			Return(nil)
+
+		svc := service.NewApiKeyService(repo)
+		key, plaintext, err := svc.Create(context.TODO(), &reqres.CreateApiKeyReq{Name: "batch", Scopes: []string{"user:list"}, TTL: time.Hour})
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(plaintext, "rak_"+key.Prefix+"_"))
+
+		sum := sha256.Sum256([]byte(plaintext))
+		assert.Equal(t, hex.EncodeToString(sum[:]), saved.Hash)
+		assert.NotContains(t, saved.Hash, plaintext)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *saved.ExpiresAt, time.Minute)
+	})
+
+	t.Run("success:never expires", func(t *testing.T) {
+		repo := mocks.NewApiKeyRepository(t)
+		repo.On("Save", mock.Anything, mock.AnythingOfType("*domain.ApiKey")).Return(nil)
+
+		svc := service.NewApiKeyService(repo)
+		key, _, err := svc.Create(context.TODO(), &reqres.CreateApiKeyReq{Name: "batch", Scopes: []string{"user:list"}})
+		assert.NoError(t, err)
+		assert.Nil(t, key.ExpiresAt)
+	})
+
+	t.Run("error:unknown scope", func(t *testing.T) {
+		svc := service.NewApiKeyService(mocks.NewApiKeyRepository(t))
+		_, _, err := svc.Create(context.TODO(), &reqres.CreateApiKeyReq{Name: "batch", Scopes: []string{"user:everything"}})
+		assert.ErrorIs(t, err, modelErr.ErrBadParamInput)
+	})
+}
+
+func TestApiKeyService_Authenticate(t *testing.T) {
+	const plaintext = "rak_abcd1234_secret"
+	sum := sha256.Sum256([]byte(plaintext))
+	hash := hex.EncodeToString(sum[:])
+
+	past, future, recent := time.Now().Add(-time.Hour), time.Now().Add(time.Hour), time.Now().Add(-time.Second)
+
+	t.Run("success", func(t *testing.T) {
+		repo := mocks.NewApiKeyRepository(t)
+		repo.On("FindByPrefix", mock.Anything, "abcd1234").
+			Return(&domain.ApiKey{ID: 7, Prefix: "abcd1234", Hash: hash, Scopes: []string{"user:list"}, ExpiresAt: &future}, nil)
+		repo.On("TouchLastUsed", mock.Anything, int64(7), mock.AnythingOfType("time.Time")).Return(nil)
+
+		svc := service.NewApiKeyService(repo)
+		principal, err := svc.Authenticate(context.TODO(), plaintext)
+		assert.NoError(t, err)
+		assert.Equal(t, "apikey:7", principal.Subject)
+		assert.True(t, principal.Can(auth.PermUserList))
+		assert.False(t, principal.Can(auth.PermUserDelete))
+
+		_, isUser := principal.UserID()
+		assert.False(t, isUser)
+	})
+
+	t.Run("success:recently used is not touched", func(t *testing.T) {
+		repo := mocks.NewApiKeyRepository(t)
+		repo.On("FindByPrefix", mock.Anything, "abcd1234").
+			Return(&domain.ApiKey{ID: 7, Prefix: "abcd1234", Hash: hash, LastUsedAt: &recent}, nil)
+
+		svc := service.NewApiKeyService(repo)
+		_, err := svc.Authenticate(context.TODO(), plaintext)
+		assert.NoError(t, err)
+	})
+
+	t.Run("success:touch error is not fatal", func(t *testing.T) {
+		repo := mocks.NewApiKeyRepository(t)
+		repo.On("FindByPrefix", mock.Anything, "abcd1234").Return(&domain.ApiKey{ID: 7, Prefix: "abcd1234", Hash: hash}, nil)
+		repo.On("TouchLastUsed", mock.Anything, int64(7), mock.AnythingOfType("time.Time")).Return(errors.New("Unexpexted Error"))
+
+		svc := service.NewApiKeyService(repo)
+		_, err := svc.Authenticate(context.TODO(), plaintext)
+		assert.NoError(t, err)
+	})
+
+	rejected := []struct {
+		name string
+		key  string
+		row  *domain.ApiKey
+		err  error
+	}{
+		{name: "malformed", key: "abcd1234"},
+		{name: "unknown", key: plaintext, err: modelErr.ErrNotFound},
+		{name: "wrong secret", key: "rak_abcd1234_other", row: &domain.ApiKey{ID: 7, Hash: hash}},
+		{name: "revoked", key: plaintext, row: &domain.ApiKey{ID: 7, Hash: hash, RevokedAt: &past}},
+		{name: "expired", key: plaintext, row: &domain.ApiKey{ID: 7, Hash: hash, ExpiresAt: &past}},
+	}
+	for _, c := range rejected {
+		t.Run("error:"+c.name, func(t *testing.T) {
+			repo := mocks.NewApiKeyRepository(t)
+			if c.row != nil || c.err != nil {
+				repo.On("FindByPrefix", mock.Anything, "abcd1234").Return(c.row, c.err)
+			}
+
+			svc := service.NewApiKeyService(repo)
+			_, err := svc.Authenticate(context.TODO(), c.key)
+			assert.ErrorIs(t, err, modelErr.ErrUnauthorized)
+		})
+	}
+
+	t.Run("error:repository", func(t *testing.T) {
+		repo := mocks.NewApiKeyRepository(t)
+		repo.On("FindByPrefix", mock.Anything, "abcd1234").Return(nil, errors.New("Unexpexted Error"))
+
+		svc := service.NewApiKeyService(repo)
+		_, err := svc.Authenticate(context.TODO(), plaintext)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, modelErr.ErrUnauthorized)
+	})
+}
+
+func TestApiKeyService_Revoke(t *testing.T) {
+	repo := mocks.NewApiKeyRepository(t)
+	repo.On("Revoke", mock.Anything, int64(7), mock.AnythingOfType("time.Time")).Return(modelErr.ErrNotFound)
+
+	svc := service.NewApiKeyService(repo)
+	assert.ErrorIs(t, svc.Revoke(context.TODO(), 7), modelErr.ErrNotFound)
+}
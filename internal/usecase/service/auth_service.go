@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/config"
+	"go-rest-api-boilerplate/internal/domain"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/model/reqres"
+	"go.opentelemetry.io/otel"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AuthConfig signs the access tokens, the JWT middleware verifies them with the same secret, issuer and
+// audience.
+type AuthConfig struct {
+	Secret          []byte
+	Issuer          string
+	Audience        string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// NewAuthConfig reads the AuthConfig from the JWT_* and AUTH_* config.
+func NewAuthConfig() AuthConfig {
+	return AuthConfig{
+		Secret:          []byte(config.App.JwtHmacSecret),
+		Issuer:          config.App.JwtIssuer,
+		Audience:        config.App.JwtAudience,
+		AccessTokenTTL:  config.App.AuthAccessTokenTTL,
+		RefreshTokenTTL: config.App.AuthRefreshTokenTTL,
+	}
+}
+
+var errInvalidCredentials = fmt.Errorf("%w: invalid email or password", modelErr.ErrUnauthorized)
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// compareDummyHash spends the time of a password comparison, so a login with an unknown email takes as
+// long as one with a wrong password.
+func compareDummyHash(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	})
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// HashPassword hashes a password with bcrypt for storage.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+type authService struct {
+	userRepo  domain.UserRepository
+	tokenRepo domain.RefreshTokenRepository
+	tx        domain.TxManager
+	cfg       AuthConfig
+}
+
+func NewAuthService(userRepo domain.UserRepository, tokenRepo domain.RefreshTokenRepository, tx domain.TxManager, cfg AuthConfig) domain.AuthService {
+	return &authService{userRepo: userRepo, tokenRepo: tokenRepo, tx: tx, cfg: cfg}
+}
+
+func (a *authService) Login(ctx context.Context, req *reqres.LoginReq) (*reqres.TokenRes, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "auth.service.Login")
+	defer span.End()
+
+	credentials, err := a.userRepo.FindCredentialsByEmail(ctx, req.Email)
+	if errors.Is(err, modelErr.ErrNotFound) {
+		compareDummyHash(req.Password)
+		return nil, errInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if credentials.PasswordHash == "" {
+		compareDummyHash(req.Password)
+		return nil, errInvalidCredentials
+	}
+
+	if err = bcrypt.CompareHashAndPassword([]byte(credentials.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, errInvalidCredentials
+	}
+
+	family, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.issue(ctx, credentials, family)
+}
+
+// Refresh rotates a refresh token: the token is used up and a new one of the same family is issued with a
+// new access token. Presenting a used token again means it leaked, the whole family is then revoked so
+// neither the thief nor the user can refresh any longer.
+func (a *authService) Refresh(ctx context.Context, req *reqres.RefreshTokenReq) (*reqres.TokenRes, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "auth.service.Refresh")
+	defer span.End()
+
+	token, err := a.tokenRepo.FindByHash(ctx, hashToken(req.RefreshToken))
+	if errors.Is(err, modelErr.ErrNotFound) {
+		return nil, fmt.Errorf("%w: invalid refresh token", modelErr.ErrUnauthorized)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	switch {
+	case token.RevokedAt != nil:
+		return nil, fmt.Errorf("%w: refresh token is revoked", modelErr.ErrUnauthorized)
+	case token.UsedAt != nil:
+		return nil, a.revokeReused(ctx, token)
+	case !now.Before(token.ExpiresAt):
+		return nil, fmt.Errorf("%w: refresh token is expired", modelErr.ErrUnauthorized)
+	}
+
+	// The token is marked used and its successor saved within a transaction, a failure to issue the
+	// successor leaves the token unused so the client may retry it without being taken for a thief.
+	var res *reqres.TokenRes
+	reused := false
+	err = a.tx.WithinTx(ctx, nil, func(ctx context.Context) error {
+		err := a.tokenRepo.MarkUsed(ctx, token.ID, now)
+		if errors.Is(err, modelErr.ErrNotFound) {
+			reused = true
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		credentials, err := a.userRepo.FindCredentialsByID(ctx, token.UserID)
+		if errors.Is(err, modelErr.ErrNotFound) {
+			return fmt.Errorf("%w: user no longer exists", modelErr.ErrUnauthorized)
+		}
+		if err != nil {
+			return err
+		}
+
+		res, err = a.issue(ctx, credentials, token.FamilyID)
+		return err
+	})
+	if reused {
+		return nil, a.revokeReused(ctx, token)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (a *authService) revokeReused(ctx context.Context, token *domain.RefreshToken) error {
+	log.WithContext(ctx).Warnf("refresh token reuse detected for user %d, revoking token family %s", token.UserID, token.FamilyID)
+	if err := a.tokenRepo.RevokeFamily(ctx, token.FamilyID, time.Now()); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%w: refresh token reuse detected", modelErr.ErrUnauthorized)
+}
+
+// Logout revokes the family of the refresh token, logging out with an unknown token succeeds. The access
+// tokens already issued stay valid until they expire.
+func (a *authService) Logout(ctx context.Context, req *reqres.RefreshTokenReq) error {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "auth.service.Logout")
+	defer span.End()
+
+	token, err := a.tokenRepo.FindByHash(ctx, hashToken(req.RefreshToken))
+	if errors.Is(err, modelErr.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return a.tokenRepo.RevokeFamily(ctx, token.FamilyID, time.Now())
+}
+
+// issue signs an access token for the user and saves a new refresh token of the family.
+func (a *authService) issue(ctx context.Context, credentials *domain.UserCredentials, family string) (*reqres.TokenRes, error) {
+	if len(a.cfg.Secret) == 0 {
+		return nil, errors.New("no JWT_HMAC_SECRET configured to sign access tokens")
+	}
+
+	now := time.Now()
+	jti, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{
+		"sub":   strconv.FormatInt(credentials.UserID, 10),
+		"roles": []string{credentials.Role},
+		"iat":   now.Unix(),
+		"exp":   now.Add(a.cfg.AccessTokenTTL).Unix(),
+		"jti":   jti,
+	}
+	if a.cfg.Issuer != "" {
+		claims["iss"] = a.cfg.Issuer
+	}
+	if a.cfg.Audience != "" {
+		claims["aud"] = a.cfg.Audience
+	}
+
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.cfg.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.tokenRepo.Save(ctx, &domain.RefreshToken{
+		UserID:    credentials.UserID,
+		FamilyID:  family,
+		Hash:      hashToken(refreshToken),
+		ExpiresAt: now.Add(a.cfg.RefreshTokenTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &reqres.TokenRes{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(a.cfg.AccessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
@@ -0,0 +1,228 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/domain/mocks"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/model/reqres"
+	"go-rest-api-boilerplate/internal/usecase/service"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testAuthConfig = service.AuthConfig{
+	Secret:          []byte("test-secret"),
+	Issuer:          "https://issuer.test",
+	Audience:        "api",
+	AccessTokenTTL:  15 * time.Minute,
+	RefreshTokenTTL: time.Hour,
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestAuthService_Login(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	credentials := &domain.UserCredentials{UserID: 2, PasswordHash: string(hash), Role: "user"}
+
+	t.Run("success", func(t *testing.T) {
+		var saved *domain.RefreshToken
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.On("FindCredentialsByEmail", mock.Anything, "john@m.co").Return(credentials, nil)
+		tokenRepo := mocks.NewRefreshTokenRepository(t)
+		tokenRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.RefreshToken")).
+			Run(func(args mock.Arguments) { saved = args.Get(1).(*domain.RefreshToken) }).
+			Return(nil)
+
+		svc := service.NewAuthService(userRepo, tokenRepo, &testTx{}, testAuthConfig)
+		token, err := svc.Login(context.TODO(), &reqres.LoginReq{Email: "john@m.co", Password: "secret123"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Bearer", token.TokenType)
+		assert.Equal(t, int64(900), token.ExpiresIn)
+
+		claims := jwt.MapClaims{}
+		_, err = jwt.ParseWithClaims(token.AccessToken, claims, func(*jwt.Token) (interface{}, error) { return testAuthConfig.Secret, nil })
+		assert.NoError(t, err)
+		assert.Equal(t, "2", claims["sub"])
+		assert.Equal(t, []interface{}{"user"}, claims["roles"])
+		assert.Equal(t, "https://issuer.test", claims["iss"])
+		assert.Equal(t, "api", claims["aud"])
+
+		assert.Equal(t, int64(2), saved.UserID)
+		assert.Equal(t, sha256Hex(token.RefreshToken), saved.Hash)
+		assert.NotEmpty(t, saved.FamilyID)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), saved.ExpiresAt, time.Minute)
+	})
+
+	rejected := []struct {
+		name        string
+		password    string
+		credentials *domain.UserCredentials
+		err         error
+	}{
+		{name: "wrong password", password: "wrong123", credentials: credentials},
+		{name: "unknown email", password: "secret123", err: modelErr.ErrNotFound},
+		{name: "no password", password: "secret123", credentials: &domain.UserCredentials{UserID: 2, Role: "user"}},
+	}
+	for _, c := range rejected {
+		t.Run("error:"+c.name, func(t *testing.T) {
+			userRepo := mocks.NewUserRepository(t)
+			userRepo.On("FindCredentialsByEmail", mock.Anything, "john@m.co").Return(c.credentials, c.err)
+
+			svc := service.NewAuthService(userRepo, mocks.NewRefreshTokenRepository(t), &testTx{}, testAuthConfig)
+			_, err := svc.Login(context.TODO(), &reqres.LoginReq{Email: "john@m.co", Password: c.password})
+			assert.ErrorIs(t, err, modelErr.ErrUnauthorized)
+			assert.Contains(t, err.Error(), "invalid email or password")
+		})
+	}
+
+	t.Run("error:no signing secret", func(t *testing.T) {
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.On("FindCredentialsByEmail", mock.Anything, "john@m.co").Return(credentials, nil)
+
+		svc := service.NewAuthService(userRepo, mocks.NewRefreshTokenRepository(t), &testTx{}, service.AuthConfig{})
+		_, err := svc.Login(context.TODO(), &reqres.LoginReq{Email: "john@m.co", Password: "secret123"})
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, modelErr.ErrUnauthorized)
+	})
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	const refreshToken = "refresh"
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	valid := func() *domain.RefreshToken {
+		return &domain.RefreshToken{ID: 5, UserID: 2, FamilyID: "family", Hash: sha256Hex(refreshToken), ExpiresAt: future}
+	}
+
+	t.Run("success:rotation", func(t *testing.T) {
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.On("FindCredentialsByID", mock.Anything, int64(2)).Return(&domain.UserCredentials{UserID: 2, Role: "admin"}, nil)
+		tokenRepo := mocks.NewRefreshTokenRepository(t)
+		tokenRepo.On("FindByHash", mock.Anything, sha256Hex(refreshToken)).Return(valid(), nil)
+		tokenRepo.On("MarkUsed", mock.Anything, int64(5), mock.AnythingOfType("time.Time")).Return(nil)
+		tokenRepo.On("Save", mock.Anything, mock.MatchedBy(func(token *domain.RefreshToken) bool {
+			return token.FamilyID == "family" && token.UserID == 2
+		})).Return(nil)
+
+		svc := service.NewAuthService(userRepo, tokenRepo, &testTx{}, testAuthConfig)
+		token, err := svc.Refresh(context.TODO(), &reqres.RefreshTokenReq{RefreshToken: refreshToken})
+		assert.NoError(t, err)
+		assert.NotEqual(t, refreshToken, token.RefreshToken)
+	})
+
+	t.Run("error:issue rolls the rotation back", func(t *testing.T) {
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.On("FindCredentialsByID", mock.Anything, int64(2)).Return(&domain.UserCredentials{UserID: 2, Role: "user"}, nil)
+		tokenRepo := mocks.NewRefreshTokenRepository(t)
+		tokenRepo.On("FindByHash", mock.Anything, sha256Hex(refreshToken)).Return(valid(), nil)
+		tokenRepo.On("MarkUsed", mock.Anything, int64(5), mock.AnythingOfType("time.Time")).Return(nil)
+		tokenRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.RefreshToken")).Return(errors.New("Unexpexted Error"))
+
+		tx := &testTx{}
+		svc := service.NewAuthService(userRepo, tokenRepo, tx, testAuthConfig)
+		_, err := svc.Refresh(context.TODO(), &reqres.RefreshTokenReq{RefreshToken: refreshToken})
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, modelErr.ErrUnauthorized)
+		assert.Equal(t, 1, tx.runs)
+		assert.Error(t, tx.err, "marking the token used is rolled back")
+		tokenRepo.AssertNotCalled(t, "RevokeFamily", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error:reuse revokes the family", func(t *testing.T) {
+		used := valid()
+		used.UsedAt = &past
+		tokenRepo := mocks.NewRefreshTokenRepository(t)
+		tokenRepo.On("FindByHash", mock.Anything, sha256Hex(refreshToken)).Return(used, nil)
+		tokenRepo.On("RevokeFamily", mock.Anything, "family", mock.AnythingOfType("time.Time")).Return(nil)
+
+		svc := service.NewAuthService(mocks.NewUserRepository(t), tokenRepo, &testTx{}, testAuthConfig)
+		_, err := svc.Refresh(context.TODO(), &reqres.RefreshTokenReq{RefreshToken: refreshToken})
+		assert.ErrorIs(t, err, modelErr.ErrUnauthorized)
+		assert.Contains(t, err.Error(), "reuse detected")
+	})
+
+	t.Run("error:concurrent reuse revokes the family", func(t *testing.T) {
+		tokenRepo := mocks.NewRefreshTokenRepository(t)
+		tokenRepo.On("FindByHash", mock.Anything, sha256Hex(refreshToken)).Return(valid(), nil)
+		tokenRepo.On("MarkUsed", mock.Anything, int64(5), mock.AnythingOfType("time.Time")).Return(modelErr.ErrNotFound)
+		tokenRepo.On("RevokeFamily", mock.Anything, "family", mock.AnythingOfType("time.Time")).Return(nil)
+
+		svc := service.NewAuthService(mocks.NewUserRepository(t), tokenRepo, &testTx{}, testAuthConfig)
+		_, err := svc.Refresh(context.TODO(), &reqres.RefreshTokenReq{RefreshToken: refreshToken})
+		assert.ErrorIs(t, err, modelErr.ErrUnauthorized)
+	})
+
+	revoked, expired := valid(), valid()
+	revoked.RevokedAt = &past
+	expired.ExpiresAt = past
+	rejected := []struct {
+		name  string
+		token *domain.RefreshToken
+		err   error
+	}{
+		{name: "unknown", err: modelErr.ErrNotFound},
+		{name: "revoked", token: revoked},
+		{name: "expired", token: expired},
+	}
+	for _, c := range rejected {
+		t.Run("error:"+c.name, func(t *testing.T) {
+			tokenRepo := mocks.NewRefreshTokenRepository(t)
+			tokenRepo.On("FindByHash", mock.Anything, sha256Hex(refreshToken)).Return(c.token, c.err)
+
+			svc := service.NewAuthService(mocks.NewUserRepository(t), tokenRepo, &testTx{}, testAuthConfig)
+			_, err := svc.Refresh(context.TODO(), &reqres.RefreshTokenReq{RefreshToken: refreshToken})
+			assert.ErrorIs(t, err, modelErr.ErrUnauthorized)
+		})
+	}
+
+	t.Run("error:user deleted", func(t *testing.T) {
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.On("FindCredentialsByID", mock.Anything, int64(2)).Return(nil, modelErr.ErrNotFound)
+		tokenRepo := mocks.NewRefreshTokenRepository(t)
+		tokenRepo.On("FindByHash", mock.Anything, sha256Hex(refreshToken)).Return(valid(), nil)
+		tokenRepo.On("MarkUsed", mock.Anything, int64(5), mock.AnythingOfType("time.Time")).Return(nil)
+
+		svc := service.NewAuthService(userRepo, tokenRepo, &testTx{}, testAuthConfig)
+		_, err := svc.Refresh(context.TODO(), &reqres.RefreshTokenReq{RefreshToken: refreshToken})
+		assert.ErrorIs(t, err, modelErr.ErrUnauthorized)
+	})
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		tokenRepo := mocks.NewRefreshTokenRepository(t)
+		tokenRepo.On("FindByHash", mock.Anything, sha256Hex("refresh")).Return(&domain.RefreshToken{ID: 5, FamilyID: "family"}, nil)
+		tokenRepo.On("RevokeFamily", mock.Anything, "family", mock.AnythingOfType("time.Time")).Return(nil)
+
+		svc := service.NewAuthService(mocks.NewUserRepository(t), tokenRepo, &testTx{}, testAuthConfig)
+		assert.NoError(t, svc.Logout(context.TODO(), &reqres.RefreshTokenReq{RefreshToken: "refresh"}))
+	})
+
+	t.Run("success:unknown token", func(t *testing.T) {
+		tokenRepo := mocks.NewRefreshTokenRepository(t)
+		tokenRepo.On("FindByHash", mock.Anything, sha256Hex("refresh")).Return(nil, modelErr.ErrNotFound)
+
+		svc := service.NewAuthService(mocks.NewUserRepository(t), tokenRepo, &testTx{}, testAuthConfig)
+		assert.NoError(t, svc.Logout(context.TODO(), &reqres.RefreshTokenReq{RefreshToken: "refresh"}))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		tokenRepo := mocks.NewRefreshTokenRepository(t)
+		tokenRepo.On("FindByHash", mock.Anything, sha256Hex("refresh")).Return(nil, errors.New("Unexpexted Error"))
+
+		svc := service.NewAuthService(mocks.NewUserRepository(t), tokenRepo, &testTx{}, testAuthConfig)
+		assert.Error(t, svc.Logout(context.TODO(), &reqres.RefreshTokenReq{RefreshToken: "refresh"}))
+	})
+}
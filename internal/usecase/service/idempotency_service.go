@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-rest-api-boilerplate/config"
+	"go-rest-api-boilerplate/internal/domain"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go.opentelemetry.io/otel"
+)
+
+// IdempotencyConfig sets how long a key is remembered, a retry after the TTL is handled as a new request.
+type IdempotencyConfig struct {
+	TTL time.Duration
+}
+
+// NewIdempotencyConfig reads the IdempotencyConfig from the IDEMPOTENCY_* config.
+func NewIdempotencyConfig() IdempotencyConfig {
+	return IdempotencyConfig{TTL: config.App.IdempotencyTTL}
+}
+
+type idempotencyService struct {
+	repo domain.IdempotencyRepository
+	cfg  IdempotencyConfig
+}
+
+func NewIdempotencyService(repo domain.IdempotencyRepository, cfg IdempotencyConfig) domain.IdempotencyService {
+	return &idempotencyService{repo: repo, cfg: cfg}
+}
+
+func (i *idempotencyService) Begin(ctx context.Context, key, fingerprint string) (*domain.IdempotencyKey, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "idempotency.service.Begin")
+	defer span.End()
+
+	now := time.Now()
+	claimed, err := i.repo.Claim(ctx, &domain.IdempotencyKey{
+		KeyHash:     hashToken(key),
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(i.cfg.TTL),
+		CreatedAt:   now,
+	})
+	if err != nil || claimed {
+		return nil, err
+	}
+
+	stored, err := i.repo.FindByKeyHash(ctx, hashToken(key))
+	if errors.Is(err, modelErr.ErrNotFound) {
+		// Released by the first request in the meantime.
+		return nil, fmt.Errorf("%w: a request with this Idempotency-Key is in progress", modelErr.ErrConflict)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case stored.Fingerprint != fingerprint:
+		return nil, fmt.Errorf("%w: Idempotency-Key was used with a different request", modelErr.ErrBadParamInput)
+	case stored.CompletedAt == nil:
+		return nil, fmt.Errorf("%w: a request with this Idempotency-Key is in progress", modelErr.ErrConflict)
+	}
+
+	return stored, nil
+}
+
+func (i *idempotencyService) Complete(ctx context.Context, key string, status int, header map[string]string, body []byte) error {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "idempotency.service.Complete")
+	defer span.End()
+
+	now := time.Now()
+	return i.repo.Complete(ctx, &domain.IdempotencyKey{
+		KeyHash:     hashToken(key),
+		Status:      status,
+		Header:      header,
+		Body:        body,
+		CompletedAt: &now,
+	})
+}
+
+func (i *idempotencyService) Release(ctx context.Context, key string) error {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "idempotency.service.Release")
+	defer span.End()
+
+	return i.repo.Delete(ctx, hashToken(key))
+}
+
+func (i *idempotencyService) PurgeExpired(ctx context.Context) (int64, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "idempotency.service.PurgeExpired")
+	defer span.End()
+
+	return i.repo.DeleteExpired(ctx, time.Now())
+}
@@ -0,0 +1,100 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/domain/mocks"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/usecase/service"
+)
+
+func TestIdempotencyService_Begin(t *testing.T) {
+	sum := sha256.Sum256([]byte("ip:1.2.3.4 key-1"))
+	keyHash := hex.EncodeToString(sum[:])
+	cfg := service.IdempotencyConfig{TTL: time.Hour}
+	completedAt := time.Now()
+
+	t.Run("claimed", func(t *testing.T) {
+		var claimed *domain.IdempotencyKey
+		repo := mocks.NewIdempotencyRepository(t)
+		repo.On("Claim", mock.Anything, mock.AnythingOfType("*domain.IdempotencyKey")).
+			Run(func(args mock.Arguments) { claimed = args.Get(1).(*domain.IdempotencyKey) }).
+			Return(true, nil)
+
+		svc := service.NewIdempotencyService(repo, cfg)
+		stored, err := svc.Begin(context.TODO(), "ip:1.2.3.4 key-1", "fp")
+		assert.NoError(t, err)
+		assert.Nil(t, stored)
+		assert.Equal(t, keyHash, claimed.KeyHash)
+		assert.Equal(t, "fp", claimed.Fingerprint)
+		assert.Equal(t, time.Hour, claimed.ExpiresAt.Sub(claimed.CreatedAt))
+	})
+
+	cases := []struct {
+		name   string
+		stored *domain.IdempotencyKey
+		err    error
+	}{
+		{name: "replay", stored: &domain.IdempotencyKey{Fingerprint: "fp", Status: 201, CompletedAt: &completedAt}},
+		{name: "error:different request", stored: &domain.IdempotencyKey{Fingerprint: "other", Status: 201, CompletedAt: &completedAt}, err: modelErr.ErrBadParamInput},
+		{name: "error:in flight", stored: &domain.IdempotencyKey{Fingerprint: "fp"}, err: modelErr.ErrConflict},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			repo := mocks.NewIdempotencyRepository(t)
+			repo.On("Claim", mock.Anything, mock.AnythingOfType("*domain.IdempotencyKey")).Return(false, nil)
+			repo.On("FindByKeyHash", mock.Anything, keyHash).Return(c.stored, nil)
+
+			svc := service.NewIdempotencyService(repo, cfg)
+			stored, err := svc.Begin(context.TODO(), "ip:1.2.3.4 key-1", "fp")
+			if c.err != nil {
+				assert.ErrorIs(t, err, c.err)
+				assert.Nil(t, stored)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.stored, stored)
+		})
+	}
+
+	t.Run("error:released meanwhile", func(t *testing.T) {
+		repo := mocks.NewIdempotencyRepository(t)
+		repo.On("Claim", mock.Anything, mock.AnythingOfType("*domain.IdempotencyKey")).Return(false, nil)
+		repo.On("FindByKeyHash", mock.Anything, keyHash).Return(nil, modelErr.ErrNotFound)
+
+		svc := service.NewIdempotencyService(repo, cfg)
+		_, err := svc.Begin(context.TODO(), "ip:1.2.3.4 key-1", "fp")
+		assert.ErrorIs(t, err, modelErr.ErrConflict)
+	})
+}
+
+func TestIdempotencyService_Complete(t *testing.T) {
+	var completed *domain.IdempotencyKey
+	repo := mocks.NewIdempotencyRepository(t)
+	repo.On("Complete", mock.Anything, mock.AnythingOfType("*domain.IdempotencyKey")).
+		Run(func(args mock.Arguments) { completed = args.Get(1).(*domain.IdempotencyKey) }).
+		Return(nil)
+
+	svc := service.NewIdempotencyService(repo, service.IdempotencyConfig{TTL: time.Hour})
+	err := svc.Complete(context.TODO(), "key", 201, map[string]string{"Location": "/api/v1/user/1"}, []byte("{}"))
+	assert.NoError(t, err)
+	assert.Equal(t, 201, completed.Status)
+	assert.Equal(t, []byte("{}"), completed.Body)
+	assert.NotNil(t, completed.CompletedAt)
+}
+
+func TestIdempotencyService_Release(t *testing.T) {
+	sum := sha256.Sum256([]byte("key"))
+	repo := mocks.NewIdempotencyRepository(t)
+	repo.On("Delete", mock.Anything, hex.EncodeToString(sum[:])).Return(nil)
+
+	svc := service.NewIdempotencyService(repo, service.IdempotencyConfig{TTL: time.Hour})
+	assert.NoError(t, svc.Release(context.TODO(), "key"))
+}
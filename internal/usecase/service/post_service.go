@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-rest-api-boilerplate/config"
+	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/model/auth"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/model/reqres"
+	"go.opentelemetry.io/otel"
+)
+
+type postService struct {
+	repo     domain.PostRepository
+	userRepo domain.UserRepository
+}
+
+func NewPostService(repo domain.PostRepository, userRepo domain.UserRepository) domain.PostService {
+	return &postService{repo: repo, userRepo: userRepo}
+}
+
+// authorizePostOwner lets the caller of ctx act on the posts of the user id when it is that user, or when
+// it may act on the posts of any user. The permission of the action itself is checked at the route.
+func authorizePostOwner(ctx context.Context, userID int64) error {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return modelErr.ErrUnauthorized
+	}
+
+	if principal.Can(auth.PermPostAny) {
+		return nil
+	}
+
+	if id, ok := principal.UserID(); ok && id == userID {
+		return nil
+	}
+
+	return modelErr.ErrForbidden
+}
+
+// authorizePost looks the post up and lets the caller of ctx act on it when it may act on the posts of its
+// author.
+func (p *postService) authorizePost(ctx context.Context, id int64) error {
+	post, err := p.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return authorizePostOwner(ctx, post.UserID)
+}
+
+// Create saves a post for an existing, not deleted, author.
+func (p *postService) Create(ctx context.Context, req *reqres.CreatePostReq) (*domain.Post, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "post.service.Create")
+	defer span.End()
+
+	if err := authorizePostOwner(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+
+	_, err := p.userRepo.FindByID(ctx, req.UserID, false)
+	if errors.Is(err, modelErr.ErrNotFound) {
+		return nil, &modelErr.FieldError{Field: "user_id", Rule: "exists", Err: modelErr.ErrBadParamInput}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	newPost := domain.Post{
+		UserID:    req.UserID,
+		Title:     req.Title,
+		Content:   req.Content,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err = p.repo.Save(ctx, &newPost)
+	if err != nil {
+		return nil, err
+	}
+
+	return &newPost, nil
+}
+
+func (p *postService) UpdateByID(ctx context.Context, id int64, req *reqres.UpdatePostReq) (*domain.Post, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "post.service.UpdateByID")
+	defer span.End()
+
+	if err := p.authorizePost(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return p.repo.UpdateByID(ctx, id, &domain.Post{Title: req.Title, Content: req.Content})
+}
+
+func (p *postService) DeleteByID(ctx context.Context, id int64) error {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "post.service.DeleteByID")
+	defer span.End()
+
+	if err := p.authorizePost(ctx, id); err != nil {
+		return err
+	}
+
+	return p.repo.DeleteByID(ctx, id)
+}
+
+// FindAll lists the posts of every user. Narrowed to a user, the list follows the policy of FindAllByUser.
+func (p *postService) FindAll(ctx context.Context, req *reqres.ListPostReq) (*[]domain.Post, *reqres.PageRes, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "post.service.FindAll")
+	defer span.End()
+
+	if req.UserID != 0 {
+		if err := authorizePostOwner(ctx, req.UserID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	posts, pageRes, err := p.repo.FindAll(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if req.WithTotal {
+		total, err := p.repo.Count(ctx, req)
+		if err != nil {
+			return nil, nil, err
+		}
+		pageRes.Total = &total
+	}
+
+	return posts, pageRes, nil
+}
+
+// FindAllByUser lists the posts of a user, ErrNotFound when the user does not exist. Only the user itself,
+// or a caller that may act on the posts of any user, lists them.
+func (p *postService) FindAllByUser(ctx context.Context, userID int64, req *reqres.ListPostReq) (*[]domain.Post, *reqres.PageRes, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "post.service.FindAllByUser")
+	defer span.End()
+
+	if err := authorizePostOwner(ctx, userID); err != nil {
+		return nil, nil, err
+	}
+
+	_, err := p.userRepo.FindByID(ctx, userID, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.UserID = userID
+	return p.FindAll(ctx, req)
+}
+
+func (p *postService) FindByID(ctx context.Context, id int64) (*domain.Post, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "post.service.FindByID")
+	defer span.End()
+
+	return p.repo.FindByID(ctx, id)
+}
@@ -0,0 +1,245 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/domain/mocks"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/internal/model/reqres"
+	"go-rest-api-boilerplate/internal/usecase/service"
+)
+
+func TestNewPostService(t *testing.T) {
+	svc := service.NewPostService(nil, nil)
+	assert.NotNil(t, svc)
+}
+
+func TestPostService_Create(t *testing.T) {
+	req := reqres.CreatePostReq{UserID: 1, Title: "hello", Content: "world"}
+
+	t.Run("success", func(t *testing.T) {
+		repo := mocks.NewPostRepository(t)
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.On("FindByID", mock.Anything, int64(1), false).Return(&domain.User{ID: 1}, nil)
+		repo.On("Save", mock.Anything, mock.AnythingOfType("*domain.Post")).
+			Run(func(args mock.Arguments) { args.Get(1).(*domain.Post).ID = 5 }).
+			Return(nil)
+
+		svc := service.NewPostService(repo, userRepo)
+		post, err := svc.Create(adminCtx, &req)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), post.ID)
+		assert.Equal(t, int64(1), post.UserID)
+	})
+
+	t.Run("error:unknown author", func(t *testing.T) {
+		repo := mocks.NewPostRepository(t)
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.On("FindByID", mock.Anything, int64(1), false).Return(nil, modelErr.ErrNotFound)
+
+		svc := service.NewPostService(repo, userRepo)
+		_, err := svc.Create(adminCtx, &req)
+		assert.ErrorIs(t, err, modelErr.ErrBadParamInput)
+
+		var fieldErr *modelErr.FieldError
+		assert.ErrorAs(t, err, &fieldErr)
+		assert.Equal(t, "user_id", fieldErr.Field)
+	})
+
+	t.Run("error:post for another user", func(t *testing.T) {
+		svc := service.NewPostService(mocks.NewPostRepository(t), mocks.NewUserRepository(t))
+		post, err := svc.Create(userCtx, &req)
+		assert.ErrorIs(t, err, modelErr.ErrForbidden)
+		assert.Nil(t, post)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		repo := mocks.NewPostRepository(t)
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.On("FindByID", mock.Anything, int64(1), false).Return(&domain.User{ID: 1}, nil)
+		repo.On("Save", mock.Anything, mock.AnythingOfType("*domain.Post")).Return(errors.New("Unexpexted Error"))
+
+		svc := service.NewPostService(repo, userRepo)
+		post, err := svc.Create(adminCtx, &req)
+		assert.Error(t, err)
+		assert.Nil(t, post)
+	})
+}
+
+func TestPostService_UpdateByID(t *testing.T) {
+	req := &reqres.UpdatePostReq{Title: "hello", Content: "world"}
+
+	t.Run("success", func(t *testing.T) {
+		repo := mocks.NewPostRepository(t)
+		repo.On("FindByID", mock.Anything, int64(1)).Return(&domain.Post{ID: 1, UserID: 2}, nil)
+		repo.On("UpdateByID", mock.Anything, int64(1), &domain.Post{Title: "hello", Content: "world"}).
+			Return(&domain.Post{ID: 1, Title: "hello"}, nil)
+
+		svc := service.NewPostService(repo, nil)
+		post, err := svc.UpdateByID(userCtx, 1, req)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", post.Title)
+	})
+
+	t.Run("error:not the author", func(t *testing.T) {
+		repo := mocks.NewPostRepository(t)
+		repo.On("FindByID", mock.Anything, int64(1)).Return(&domain.Post{ID: 1, UserID: 3}, nil)
+
+		svc := service.NewPostService(repo, nil)
+		post, err := svc.UpdateByID(userCtx, 1, req)
+		assert.ErrorIs(t, err, modelErr.ErrForbidden)
+		assert.Nil(t, post)
+	})
+
+	t.Run("error:not found", func(t *testing.T) {
+		repo := mocks.NewPostRepository(t)
+		repo.On("FindByID", mock.Anything, int64(1)).Return(nil, modelErr.ErrNotFound)
+
+		svc := service.NewPostService(repo, nil)
+		_, err := svc.UpdateByID(adminCtx, 1, req)
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
+	})
+}
+
+func TestPostService_DeleteByID(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		repo := mocks.NewPostRepository(t)
+		repo.On("FindByID", mock.Anything, int64(1)).Return(&domain.Post{ID: 1, UserID: 3}, nil)
+		repo.On("DeleteByID", mock.Anything, int64(1)).Return(nil)
+
+		svc := service.NewPostService(repo, nil)
+		err := svc.DeleteByID(adminCtx, 1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("error:not the author", func(t *testing.T) {
+		repo := mocks.NewPostRepository(t)
+		repo.On("FindByID", mock.Anything, int64(1)).Return(&domain.Post{ID: 1, UserID: 3}, nil)
+
+		svc := service.NewPostService(repo, nil)
+		err := svc.DeleteByID(userCtx, 1)
+		assert.ErrorIs(t, err, modelErr.ErrForbidden)
+	})
+
+	t.Run("error:unauthenticated", func(t *testing.T) {
+		repo := mocks.NewPostRepository(t)
+		repo.On("FindByID", mock.Anything, int64(1)).Return(&domain.Post{ID: 1, UserID: 3}, nil)
+
+		svc := service.NewPostService(repo, nil)
+		err := svc.DeleteByID(context.TODO(), 1)
+		assert.ErrorIs(t, err, modelErr.ErrUnauthorized)
+	})
+}
+
+func TestPostService_FindAll(t *testing.T) {
+	posts := []domain.Post{{ID: 1, UserID: 1, Title: "hello"}}
+
+	t.Run("success:with total", func(t *testing.T) {
+		req := reqres.ListPostReq{PageReq: reqres.PageReq{Limit: 10, WithTotal: true}}
+		repo := mocks.NewPostRepository(t)
+		repo.On("FindAll", mock.Anything, &req).Return(&posts, &reqres.PageRes{}, nil)
+		repo.On("Count", mock.Anything, &req).Return(int64(1), nil)
+
+		svc := service.NewPostService(repo, nil)
+		result, page, err := svc.FindAll(context.TODO(), &req)
+		assert.NoError(t, err)
+		assert.Len(t, *result, 1)
+		assert.Equal(t, int64(1), *page.Total)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		repo := mocks.NewPostRepository(t)
+		repo.On("FindAll", mock.Anything, mock.AnythingOfType("*reqres.ListPostReq")).Return(nil, nil, errors.New("Unexpexted Error"))
+
+		svc := service.NewPostService(repo, nil)
+		_, _, err := svc.FindAll(context.TODO(), &reqres.ListPostReq{})
+		assert.Error(t, err)
+	})
+
+	t.Run("success:own posts", func(t *testing.T) {
+		req := reqres.ListPostReq{PageReq: reqres.PageReq{Limit: 10}, UserID: 2}
+		repo := mocks.NewPostRepository(t)
+		repo.On("FindAll", mock.Anything, &req).Return(&posts, &reqres.PageRes{}, nil)
+
+		svc := service.NewPostService(repo, nil)
+		_, _, err := svc.FindAll(userCtx, &req)
+		assert.NoError(t, err)
+	})
+
+	t.Run("error:posts of another user", func(t *testing.T) {
+		svc := service.NewPostService(mocks.NewPostRepository(t), nil)
+		_, _, err := svc.FindAll(userCtx, &reqres.ListPostReq{PageReq: reqres.PageReq{Limit: 10}, UserID: 3})
+		assert.ErrorIs(t, err, modelErr.ErrForbidden)
+	})
+}
+
+func TestPostService_FindAllByUser(t *testing.T) {
+	posts := []domain.Post{{ID: 1, UserID: 2, Title: "hello"}}
+
+	t.Run("success", func(t *testing.T) {
+		repo := mocks.NewPostRepository(t)
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.On("FindByID", mock.Anything, int64(2), false).Return(&domain.User{ID: 2}, nil)
+		repo.On("FindAll", mock.Anything, &reqres.ListPostReq{PageReq: reqres.PageReq{Limit: 10}, UserID: 2}).
+			Return(&posts, &reqres.PageRes{}, nil)
+
+		svc := service.NewPostService(repo, userRepo)
+		result, _, err := svc.FindAllByUser(adminCtx, 2, &reqres.ListPostReq{PageReq: reqres.PageReq{Limit: 10}})
+		assert.NoError(t, err)
+		assert.Len(t, *result, 1)
+	})
+
+	t.Run("error:user not found", func(t *testing.T) {
+		repo := mocks.NewPostRepository(t)
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.On("FindByID", mock.Anything, int64(2), false).Return(nil, modelErr.ErrNotFound)
+
+		svc := service.NewPostService(repo, userRepo)
+		_, _, err := svc.FindAllByUser(adminCtx, 2, &reqres.ListPostReq{PageReq: reqres.PageReq{Limit: 10}})
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
+	})
+
+	t.Run("success:own posts", func(t *testing.T) {
+		repo := mocks.NewPostRepository(t)
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.On("FindByID", mock.Anything, int64(2), false).Return(&domain.User{ID: 2}, nil)
+		repo.On("FindAll", mock.Anything, mock.AnythingOfType("*reqres.ListPostReq")).Return(&posts, &reqres.PageRes{}, nil)
+
+		svc := service.NewPostService(repo, userRepo)
+		_, _, err := svc.FindAllByUser(userCtx, 2, &reqres.ListPostReq{PageReq: reqres.PageReq{Limit: 10}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("error:posts of another user", func(t *testing.T) {
+		svc := service.NewPostService(mocks.NewPostRepository(t), mocks.NewUserRepository(t))
+		_, _, err := svc.FindAllByUser(userCtx, 3, &reqres.ListPostReq{PageReq: reqres.PageReq{Limit: 10}})
+		assert.ErrorIs(t, err, modelErr.ErrForbidden)
+	})
+}
+
+func TestPostService_FindByID(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		repo := mocks.NewPostRepository(t)
+		repo.On("FindByID", mock.Anything, int64(1)).Return(&domain.Post{ID: 1}, nil)
+
+		svc := service.NewPostService(repo, nil)
+		post, err := svc.FindByID(context.TODO(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), post.ID)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		repo := mocks.NewPostRepository(t)
+		repo.On("FindByID", mock.Anything, int64(1)).Return(nil, modelErr.ErrNotFound)
+
+		svc := service.NewPostService(repo, nil)
+		post, err := svc.FindByID(context.TODO(), 1)
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
+		assert.Nil(t, post)
+	})
+}
@@ -2,73 +2,450 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"go-rest-api-boilerplate/config"
 	"go-rest-api-boilerplate/internal/domain"
+	"go-rest-api-boilerplate/internal/model/auth"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
 	"go-rest-api-boilerplate/internal/model/reqres"
 	"go.opentelemetry.io/otel"
 )
 
 type userService struct {
-	repo domain.UserRepository
+	repo     domain.UserRepository
+	postRepo domain.PostRepository
+	tx       domain.TxManager
 }
 
-func NewUserService(repo domain.UserRepository) domain.UserService {
-	return &userService{repo: repo}
+func NewUserService(repo domain.UserRepository, postRepo domain.PostRepository, tx domain.TxManager) domain.UserService {
+	return &userService{repo: repo, postRepo: postRepo, tx: tx}
 }
 
-func (u *userService) Create(ctx context.Context, req *reqres.CreateUserReq) error {
+// batchTxOptions runs an atomic batch on a snapshot of the users, a concurrent write to one of them fails
+// the batch to serialize and has it run again instead of applying it over a changed user.
+var batchTxOptions = &sql.TxOptions{Isolation: sql.LevelRepeatableRead}
+
+// authorizeOwner lets the caller of ctx act on the user id when it is that user, or when it may act on any
+// user. The permission of the action itself is checked at the route.
+func authorizeOwner(ctx context.Context, id int64) error {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return modelErr.ErrUnauthorized
+	}
+
+	if principal.Can(auth.PermUserAny) {
+		return nil
+	}
+
+	if userID, ok := principal.UserID(); ok && userID == id {
+		return nil
+	}
+
+	return modelErr.ErrForbidden
+}
+
+// authorizeIncludeDeleted lets the caller of ctx see soft deleted users only when it may act on any user or
+// restore them, the others are answered as if they asked for something they may not do.
+func authorizeIncludeDeleted(ctx context.Context, includeDeleted bool) error {
+	if !includeDeleted {
+		return nil
+	}
+
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return modelErr.ErrUnauthorized
+	}
+
+	if principal.Can(auth.PermUserAny) || principal.Can(auth.PermUserRestore) {
+		return nil
+	}
+
+	return modelErr.ErrForbidden
+}
+
+func (u *userService) Create(ctx context.Context, req *reqres.CreateUserReq) (*domain.User, error) {
 	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "user.service.Create")
 	defer span.End()
 
-	newUser := domain.User{
+	user, err := newUser(req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.repo.Save(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debugf("last insertid :%v", user.ID)
+	return user, nil
+}
+
+// CreateWithFirstPost saves the user and its first post within a transaction, so a post failing to save
+// leaves no user behind.
+func (u *userService) CreateWithFirstPost(ctx context.Context, req *reqres.CreateUserReq, postReq *reqres.FirstPostReq) (*domain.User, *domain.Post, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "user.service.CreateWithFirstPost")
+	defer span.End()
+
+	var user *domain.User
+	var post *domain.Post
+	err := u.tx.WithinTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		user, err = newUser(req)
+		if err != nil {
+			return err
+		}
+		if err := u.repo.Save(ctx, user); err != nil {
+			return err
+		}
+
+		post = &domain.Post{
+			UserID:    user.ID,
+			Title:     postReq.Title,
+			Content:   postReq.Content,
+			CreatedAt: user.CreatedAt,
+			UpdatedAt: user.CreatedAt,
+		}
+		return u.postRepo.Save(ctx, post)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, post, nil
+}
+
+// newUser builds the user created by req, with the hash of its password when it has one.
+func newUser(req *reqres.CreateUserReq) (*domain.User, error) {
+	now := time.Now()
+	user := &domain.User{
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
 		Email:     req.Email,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
-	err := u.repo.Save(ctx, &newUser)
-	if err != nil {
+	if req.Password != "" {
+		hash, err := HashPassword(req.Password)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordHash = hash
+	}
+
+	return user, nil
+}
+
+// versionError tells apart a conditional write that matched no row because the user has moved past that
+// version, reported as ErrPreconditionFailed, from a write to a missing user.
+func (u *userService) versionError(ctx context.Context, id int64, version int64, err error) error {
+	if version == 0 || !errors.Is(err, modelErr.ErrNotFound) {
 		return err
 	}
 
-	log.Debugf("last insertid :%v", newUser.ID)
-	return nil
+	if _, findErr := u.repo.FindByID(ctx, id, false); findErr == nil {
+		return modelErr.ErrPreconditionFailed
+	}
+
+	return err
 }
 
-func (u *userService) UpdateByID(ctx context.Context, id int64, req *reqres.UpdateUserReq) error {
+func (u *userService) UpdateByID(ctx context.Context, id int64, version int64, req *reqres.UpdateUserReq) (*domain.User, error) {
 	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "user.service.UpdateByID")
 	defer span.End()
 
+	if err := authorizeOwner(ctx, id); err != nil {
+		return nil, err
+	}
+
 	newUser := domain.User{
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
 		Email:     req.Email,
 	}
 
-	return u.repo.UpdateByID(ctx, id, &newUser)
+	user, err := u.repo.UpdateByID(ctx, id, version, &newUser)
+	if err != nil {
+		return nil, u.versionError(ctx, id, version, err)
+	}
+
+	return user, nil
 }
 
-func (u *userService) DeleteByID(ctx context.Context, id int64) error {
+// PatchByID applies a merge patch, an empty patch leaves the user untouched and returns it as is.
+func (u *userService) PatchByID(ctx context.Context, id int64, version int64, req *reqres.PatchUserReq) (*domain.User, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "user.service.PatchByID")
+	defer span.End()
+
+	if err := authorizeOwner(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if req.IsEmpty() {
+		user, err := u.repo.FindByID(ctx, id, false)
+		if err == nil && version != 0 && user.Version != version {
+			return nil, modelErr.ErrPreconditionFailed
+		}
+		return user, err
+	}
+
+	user, err := u.repo.PatchByID(ctx, id, version, req)
+	if err != nil {
+		return nil, u.versionError(ctx, id, version, err)
+	}
+
+	return user, nil
+}
+
+func (u *userService) DeleteByID(ctx context.Context, id int64, version int64) error {
 	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "user.service.DeleteByID")
 	defer span.End()
 
-	return u.repo.DeleteByID(ctx, id)
+	if err := authorizeOwner(ctx, id); err != nil {
+		return err
+	}
+
+	return u.versionError(ctx, id, version, u.repo.DeleteByID(ctx, id, version))
+}
+
+// Restore brings back a soft deleted user, restoring a user that is not deleted returns it as is.
+func (u *userService) Restore(ctx context.Context, id int64) (*domain.User, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "user.service.Restore")
+	defer span.End()
+
+	if err := authorizeOwner(ctx, id); err != nil {
+		return nil, err
+	}
+
+	user, err := u.repo.Restore(ctx, id)
+	if errors.Is(err, modelErr.ErrNotFound) {
+		return u.repo.FindByID(ctx, id, false)
+	}
+
+	return user, err
+}
+
+// batchPermissions is the permission each operation of a batch requires, the route only requires one
+// of them.
+var batchPermissions = map[string]auth.Permission{
+	reqres.BatchOpCreate: auth.PermUserCreate,
+	reqres.BatchOpUpdate: auth.PermUserUpdate,
+	reqres.BatchOpDelete: auth.PermUserDelete,
 }
 
-func (u *userService) FindAll(ctx context.Context) (*[]domain.User, error) {
+func (u *userService) Batch(ctx context.Context, req *reqres.BatchUserReq) ([]domain.UserBatchResult, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "user.service.Batch")
+	defer span.End()
+
+	results := make([]domain.UserBatchResult, len(req.Operations))
+	ops := make([]domain.UserBatchOp, 0, len(req.Operations))
+	indexes := make([]int, 0, len(req.Operations))
+	for i := range req.Operations {
+		op, err := newBatchOp(ctx, &req.Operations[i])
+		if err != nil {
+			if req.Atomic {
+				return nil, batchError(i, err)
+			}
+			results[i].Err = err
+			continue
+		}
+
+		ops = append(ops, op)
+		indexes = append(indexes, i)
+	}
+
+	if len(ops) == 0 {
+		return results, nil
+	}
+
+	applied, err := u.applyBatch(ctx, ops, req.Atomic)
+	if err != nil {
+		return nil, err
+	}
+
+	for j, res := range applied {
+		res.Err = u.versionError(ctx, ops[j].ID, ops[j].Version, res.Err)
+		if req.Atomic && res.Err != nil {
+			return nil, batchError(indexes[j], res.Err)
+		}
+		results[indexes[j]] = res
+	}
+
+	log.WithContext(ctx).Infof("applied a batch of %d user operations", len(ops))
+	return results, nil
+}
+
+// applyBatch applies the operations of an atomic batch within a transaction rolled back when one of them
+// fails, the failure is then reported with the results.
+func (u *userService) applyBatch(ctx context.Context, ops []domain.UserBatchOp, atomic bool) ([]domain.UserBatchResult, error) {
+	if !atomic {
+		return u.repo.ApplyBatch(ctx, ops, false)
+	}
+
+	var applied []domain.UserBatchResult
+	err := u.tx.WithinTx(ctx, batchTxOptions, func(ctx context.Context) error {
+		var err error
+		applied, err = u.repo.ApplyBatch(ctx, ops, true)
+		if err != nil {
+			return err
+		}
+
+		for _, res := range applied {
+			if res.Err != nil {
+				return &batchRollback{err: res.Err}
+			}
+		}
+		return nil
+	})
+
+	var rollback *batchRollback
+	if err != nil && !errors.As(err, &rollback) {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// batchRollback rolls an atomic batch back on the failure of one of its operations. It unwraps to that
+// failure so a serialization failure still has the transaction retried.
+type batchRollback struct {
+	err error
+}
+
+func (e *batchRollback) Error() string {
+	return e.err.Error()
+}
+
+func (e *batchRollback) Unwrap() error {
+	return e.err
+}
+
+// newBatchOp authorizes the operation of a batch and turns it into the operation of the repository.
+func newBatchOp(ctx context.Context, req *reqres.BatchUserOpReq) (domain.UserBatchOp, error) {
+	op := domain.UserBatchOp{Op: req.Op, ID: req.ID, Version: req.Version}
+
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return op, modelErr.ErrUnauthorized
+	}
+	perm, ok := batchPermissions[req.Op]
+	if !ok {
+		return op, fmt.Errorf("%w: op %s", modelErr.ErrBadParamInput, req.Op)
+	}
+	if !principal.Can(perm) {
+		return op, fmt.Errorf("%w: %s is required", modelErr.ErrForbidden, perm)
+	}
+	if req.Op != reqres.BatchOpCreate {
+		if err := authorizeOwner(ctx, req.ID); err != nil {
+			return op, err
+		}
+	}
+	if req.Op == reqres.BatchOpDelete {
+		return op, nil
+	}
+	if req.Op == reqres.BatchOpUpdate && req.User.Password != "" {
+		// An update replaces the profile of the user only, its password is not changed by a batch.
+		return op, &modelErr.FieldError{Field: "user.password", Rule: "excluded", Err: modelErr.ErrBadParamInput}
+	}
+
+	now := time.Now()
+	op.User = &domain.User{
+		FirstName: req.User.FirstName,
+		LastName:  req.User.LastName,
+		Email:     req.User.Email,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.Op == reqres.BatchOpCreate && req.User.Password != "" {
+		hash, err := HashPassword(req.User.Password)
+		if err != nil {
+			return op, err
+		}
+		op.User.PasswordHash = hash
+	}
+
+	return op, nil
+}
+
+// batchError ties the error of an operation to its index in the batch, a field error names the field as
+// operations[i].field.
+func batchError(index int, err error) error {
+	var fieldErr *modelErr.FieldError
+	if errors.As(err, &fieldErr) {
+		return &modelErr.FieldError{
+			Field: fmt.Sprintf("operations[%d].%s", index, fieldErr.Field),
+			Rule:  fieldErr.Rule,
+			Err:   fieldErr.Err,
+		}
+	}
+
+	return fmt.Errorf("operations[%d]: %w", index, err)
+}
+
+// PurgeDeleted permanently removes the users soft deleted longer than the retention ago.
+func (u *userService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "user.service.PurgeDeleted")
+	defer span.End()
+
+	n, err := u.repo.PurgeDeleted(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+
+	log.WithContext(ctx).Infof("purged %d soft deleted users", n)
+	return n, nil
+}
+
+func (u *userService) FindAll(ctx context.Context, req *reqres.ListUserReq) (*[]domain.User, *reqres.PageRes, error) {
 	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "user.service.FindAll")
 	defer span.End()
 
-	return u.repo.FindAll(ctx)
+	if err := authorizeIncludeDeleted(ctx, req.IncludeDeleted); err != nil {
+		return nil, nil, err
+	}
+
+	users, pageRes, err := u.repo.FindAll(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if req.WithTotal {
+		total, err := u.repo.Count(ctx, req)
+		if err != nil {
+			return nil, nil, err
+		}
+		pageRes.Total = &total
+	}
+
+	return users, pageRes, nil
+}
+
+func (u *userService) Export(ctx context.Context, req *reqres.ListUserReq, fn func(user *domain.User) error) error {
+	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "user.service.Export")
+	defer span.End()
+
+	if err := authorizeIncludeDeleted(ctx, req.IncludeDeleted); err != nil {
+		return err
+	}
+
+	return u.repo.Each(ctx, req, fn)
 }
 
-func (u *userService) FindByID(ctx context.Context, id int64) (*domain.User, error) {
+func (u *userService) FindByID(ctx context.Context, id int64, includeDeleted bool) (*domain.User, error) {
 	ctx, span := otel.Tracer(config.App.ServiceName).Start(ctx, "user.service.FindById")
 	defer span.End()
 
-	return u.repo.FindByID(ctx, id)
+	if err := authorizeOwner(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if err := authorizeIncludeDeleted(ctx, includeDeleted); err != nil {
+		return nil, err
+	}
+
+	return u.repo.FindByID(ctx, id, includeDeleted)
 }
@@ -2,6 +2,7 @@ package service_test
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"testing"
 	"time"
@@ -10,12 +11,35 @@ import (
 	"github.com/stretchr/testify/mock"
 	"go-rest-api-boilerplate/internal/domain"
 	"go-rest-api-boilerplate/internal/domain/mocks"
+	"go-rest-api-boilerplate/internal/model/auth"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
 	"go-rest-api-boilerplate/internal/model/reqres"
 	"go-rest-api-boilerplate/internal/usecase/service"
+	"golang.org/x/crypto/bcrypt"
 )
 
+var adminCtx = auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "1", Roles: []auth.Role{auth.RoleAdmin}})
+
+// userCtx is the context of user 2 holding the user role only.
+var userCtx = auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "2", Roles: []auth.Role{auth.RoleUser}})
+
+// testTx runs the unit of work right away and records how often, with which options and with which error,
+// an error is what rolls a transaction back.
+type testTx struct {
+	runs int
+	opts *sql.TxOptions
+	err  error
+}
+
+func (tx *testTx) WithinTx(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	tx.runs++
+	tx.opts = opts
+	tx.err = fn(ctx)
+	return tx.err
+}
+
 func TestNewUserService(t *testing.T) {
-	svc := service.NewUserService(nil)
+	svc := service.NewUserService(nil, nil, nil)
 	assert.NotNil(t, svc)
 }
 
@@ -33,25 +57,71 @@ func TestUserService_FindAll(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		repo := mocks.NewUserRepository(t)
-		repo.On("FindAll", mock.Anything).Return(&mockUsersResult, nil)
+		repo.On("FindAll", mock.Anything, mock.AnythingOfType("*reqres.ListUserReq")).
+			Return(&mockUsersResult, &reqres.PageRes{HasMore: true, NextCursor: "next"}, nil)
 
-		svc := service.NewUserService(repo)
-		users, err := svc.FindAll(context.TODO())
+		svc := service.NewUserService(repo, nil, &testTx{})
+		users, page, err := svc.FindAll(context.TODO(), &reqres.ListUserReq{PageReq: reqres.PageReq{Limit: 1}})
 		assert.NoError(t, err)
 
 		assert.Equal(t, "john", (*users)[0].FirstName)
+		assert.True(t, page.HasMore)
+		assert.Equal(t, "next", page.NextCursor)
+		assert.Nil(t, page.Total)
+	})
+
+	t.Run("success:with total", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("FindAll", mock.Anything, mock.AnythingOfType("*reqres.ListUserReq")).
+			Return(&mockUsersResult, &reqres.PageRes{}, nil)
+		repo.On("Count", mock.Anything, mock.AnythingOfType("*reqres.ListUserReq")).Return(int64(1), nil)
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		_, page, err := svc.FindAll(context.TODO(), &reqres.ListUserReq{PageReq: reqres.PageReq{Limit: 10, WithTotal: true}})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), *page.Total)
 	})
 
 	t.Run("error", func(t *testing.T) {
 		repo := mocks.NewUserRepository(t)
-		repo.On("FindAll", mock.Anything).Return(nil, errors.New("Unexpexted Error"))
+		repo.On("FindAll", mock.Anything, mock.AnythingOfType("*reqres.ListUserReq")).Return(nil, nil, errors.New("Unexpexted Error"))
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		users, page, err := svc.FindAll(context.TODO(), &reqres.ListUserReq{PageReq: reqres.PageReq{Limit: 10}})
+
+		assert.Error(t, err)
+		assert.Nil(t, users)
+		assert.Nil(t, page)
+	})
 
-		svc := service.NewUserService(repo)
-		users, err := svc.FindAll(context.TODO())
+	t.Run("error:count", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("FindAll", mock.Anything, mock.AnythingOfType("*reqres.ListUserReq")).
+			Return(&mockUsersResult, &reqres.PageRes{}, nil)
+		repo.On("Count", mock.Anything, mock.AnythingOfType("*reqres.ListUserReq")).Return(int64(0), errors.New("Unexpexted Error"))
 
+		svc := service.NewUserService(repo, nil, &testTx{})
+		users, _, err := svc.FindAll(context.TODO(), &reqres.ListUserReq{PageReq: reqres.PageReq{Limit: 10, WithTotal: true}})
 		assert.Error(t, err)
 		assert.Nil(t, users)
 	})
+
+	t.Run("success:include deleted by admin", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("FindAll", mock.Anything, &reqres.ListUserReq{IncludeDeleted: true}).Return(&mockUsersResult, &reqres.PageRes{}, nil)
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		_, _, err := svc.FindAll(adminCtx, &reqres.ListUserReq{IncludeDeleted: true})
+		assert.NoError(t, err)
+	})
+
+	t.Run("error:include deleted forbidden", func(t *testing.T) {
+		svc := service.NewUserService(mocks.NewUserRepository(t), nil, &testTx{})
+		users, page, err := svc.FindAll(userCtx, &reqres.ListUserReq{IncludeDeleted: true})
+		assert.ErrorIs(t, err, modelErr.ErrForbidden)
+		assert.Nil(t, users)
+		assert.Nil(t, page)
+	})
 }
 
 func TestUserService_FindByID(t *testing.T) {
@@ -66,25 +136,51 @@ func TestUserService_FindByID(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		repo := mocks.NewUserRepository(t)
-		repo.On("FindByID", mock.Anything, mock.AnythingOfType("int64")).
+		repo.On("FindByID", mock.Anything, mock.AnythingOfType("int64"), false).
 			Return(&mockUserResult, nil)
 
-		svc := service.NewUserService(repo)
-		user, err := svc.FindByID(context.TODO(), mockUserResult.ID)
+		svc := service.NewUserService(repo, nil, &testTx{})
+		user, err := svc.FindByID(adminCtx, mockUserResult.ID, false)
 		assert.NoError(t, err)
 		assert.Equal(t, "john", user.FirstName)
 	})
 
 	t.Run("error", func(t *testing.T) {
 		repo := mocks.NewUserRepository(t)
-		repo.On("FindByID", mock.Anything, mock.AnythingOfType("int64")).Return(nil, errors.New("Unexpexted Error"))
+		repo.On("FindByID", mock.Anything, mock.AnythingOfType("int64"), false).Return(nil, errors.New("Unexpexted Error"))
 
-		svc := service.NewUserService(repo)
-		user, err := svc.FindByID(context.TODO(), mockUserResult.ID)
+		svc := service.NewUserService(repo, nil, &testTx{})
+		user, err := svc.FindByID(adminCtx, mockUserResult.ID, false)
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
 	})
+
+	t.Run("success:include deleted by admin", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("FindByID", mock.Anything, int64(2), true).Return(&mockUserResult, nil)
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		_, err := svc.FindByID(adminCtx, 2, true)
+		assert.NoError(t, err)
+	})
+
+	t.Run("error:include deleted forbidden to the owner", func(t *testing.T) {
+		svc := service.NewUserService(mocks.NewUserRepository(t), nil, &testTx{})
+		user, err := svc.FindByID(userCtx, 2, true)
+		assert.ErrorIs(t, err, modelErr.ErrForbidden)
+		assert.Nil(t, user)
+	})
+
+	t.Run("success:include deleted by restore scope", func(t *testing.T) {
+		restoreCtx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "2", Scopes: []auth.Permission{auth.PermUserRestore}})
+		repo := mocks.NewUserRepository(t)
+		repo.On("FindByID", mock.Anything, int64(2), true).Return(&mockUserResult, nil)
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		_, err := svc.FindByID(restoreCtx, 2, true)
+		assert.NoError(t, err)
+	})
 }
 
 func TestUserService_Create(t *testing.T) {
@@ -97,11 +193,27 @@ func TestUserService_Create(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		repo := mocks.NewUserRepository(t)
 		repo.On("Save", mock.Anything, mock.AnythingOfType("*domain.User")).
+			Run(func(args mock.Arguments) { args.Get(1).(*domain.User).ID = 7 }).
 			Return(nil)
 
-		svc := service.NewUserService(repo)
-		err := svc.Create(context.TODO(), &req)
+		svc := service.NewUserService(repo, nil, &testTx{})
+		user, err := svc.Create(context.TODO(), &req)
 		assert.NoError(t, err)
+		assert.Equal(t, int64(7), user.ID)
+		assert.Equal(t, req.Email, user.Email)
+		assert.Empty(t, user.PasswordHash)
+	})
+
+	t.Run("success:with password", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("Save", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil)
+
+		withPassword := req
+		withPassword.Password = "secret123"
+		svc := service.NewUserService(repo, nil, &testTx{})
+		user, err := svc.Create(context.TODO(), &withPassword)
+		assert.NoError(t, err)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("secret123")))
 	})
 
 	t.Run("error", func(t *testing.T) {
@@ -109,9 +221,10 @@ func TestUserService_Create(t *testing.T) {
 		repo.On("Save", mock.Anything, mock.AnythingOfType("*domain.User")).
 			Return(errors.New("Unexpexted Error"))
 
-		svc := service.NewUserService(repo)
-		err := svc.Create(context.TODO(), &req)
+		svc := service.NewUserService(repo, nil, &testTx{})
+		user, err := svc.Create(context.TODO(), &req)
 		assert.Error(t, err)
+		assert.Nil(t, user)
 	})
 }
 
@@ -124,21 +237,86 @@ func TestUserService_UpdateByID(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		repo := mocks.NewUserRepository(t)
-		repo.On("UpdateByID", mock.Anything, mock.AnythingOfType("int64"), mock.AnythingOfType("*domain.User")).
-			Return(nil)
+		repo.On("UpdateByID", mock.Anything, mock.AnythingOfType("int64"), int64(0), mock.AnythingOfType("*domain.User")).
+			Return(&domain.User{ID: 1, FirstName: "john"}, nil)
 
-		svc := service.NewUserService(repo)
-		err := svc.UpdateByID(context.TODO(), 1, &req)
+		svc := service.NewUserService(repo, nil, &testTx{})
+		user, err := svc.UpdateByID(adminCtx, 1, 0, &req)
 		assert.NoError(t, err)
+		assert.Equal(t, int64(1), user.ID)
 	})
 
 	t.Run("error", func(t *testing.T) {
 		repo := mocks.NewUserRepository(t)
-		repo.On("UpdateByID", mock.Anything, mock.AnythingOfType("int64"), mock.AnythingOfType("*domain.User")).
-			Return(errors.New("Unexpexted Error"))
+		repo.On("UpdateByID", mock.Anything, mock.AnythingOfType("int64"), int64(0), mock.AnythingOfType("*domain.User")).
+			Return(nil, errors.New("Unexpexted Error"))
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		_, err := svc.UpdateByID(adminCtx, 1, 0, &req)
+		assert.Error(t, err)
+	})
+
+	t.Run("error:stale version", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("UpdateByID", mock.Anything, int64(1), int64(2), mock.AnythingOfType("*domain.User")).
+			Return(nil, modelErr.ErrNotFound).Once()
+		repo.On("FindByID", mock.Anything, int64(1), false).Return(&domain.User{ID: 1, Version: 3}, nil).Once()
 
-		svc := service.NewUserService(repo)
-		err := svc.UpdateByID(context.TODO(), 1, &req)
+		svc := service.NewUserService(repo, nil, &testTx{})
+		_, err := svc.UpdateByID(adminCtx, 1, 2, &req)
+		assert.ErrorIs(t, err, modelErr.ErrPreconditionFailed)
+	})
+
+	t.Run("error:not found with version", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("UpdateByID", mock.Anything, int64(1), int64(2), mock.AnythingOfType("*domain.User")).
+			Return(nil, modelErr.ErrNotFound).Once()
+		repo.On("FindByID", mock.Anything, int64(1), false).Return(nil, modelErr.ErrNotFound).Once()
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		_, err := svc.UpdateByID(adminCtx, 1, 2, &req)
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
+	})
+}
+
+func TestUserService_PatchByID(t *testing.T) {
+	req := reqres.PatchUserReq{LastName: reqres.NewNullString("due")}
+
+	t.Run("success", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("PatchByID", mock.Anything, int64(1), int64(0), &req).Return(&domain.User{ID: 1, LastName: "due"}, nil)
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		user, err := svc.PatchByID(adminCtx, 1, 0, &req)
+		assert.NoError(t, err)
+		assert.Equal(t, "due", user.LastName)
+	})
+
+	t.Run("success:empty patch", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("FindByID", mock.Anything, int64(1), false).Return(&domain.User{ID: 1}, nil)
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		user, err := svc.PatchByID(adminCtx, 1, 0, &reqres.PatchUserReq{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), user.ID)
+	})
+
+	t.Run("error:empty patch with stale version", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("FindByID", mock.Anything, int64(1), false).Return(&domain.User{ID: 1, Version: 3}, nil).Once()
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		_, err := svc.PatchByID(adminCtx, 1, 2, &reqres.PatchUserReq{})
+		assert.ErrorIs(t, err, modelErr.ErrPreconditionFailed)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("PatchByID", mock.Anything, int64(1), int64(0), &req).Return(nil, errors.New("Unexpexted Error"))
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		_, err := svc.PatchByID(adminCtx, 1, 0, &req)
 		assert.Error(t, err)
 	})
 }
@@ -146,21 +324,370 @@ func TestUserService_UpdateByID(t *testing.T) {
 func TestUserService_DeleteByID(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		repo := mocks.NewUserRepository(t)
-		repo.On("DeleteByID", mock.Anything, mock.AnythingOfType("int64")).
+		repo.On("DeleteByID", mock.Anything, mock.AnythingOfType("int64"), int64(0)).
 			Return(nil)
 
-		svc := service.NewUserService(repo)
-		err := svc.DeleteByID(context.TODO(), 1)
+		svc := service.NewUserService(repo, nil, &testTx{})
+		err := svc.DeleteByID(adminCtx, 1, 0)
 		assert.NoError(t, err)
 	})
 
 	t.Run("error", func(t *testing.T) {
 		repo := mocks.NewUserRepository(t)
-		repo.On("DeleteByID", mock.Anything, mock.AnythingOfType("int64")).
+		repo.On("DeleteByID", mock.Anything, mock.AnythingOfType("int64"), int64(0)).
 			Return(errors.New("Unexpexted Error"))
 
-		svc := service.NewUserService(repo)
-		err := svc.DeleteByID(context.TODO(), 1)
+		svc := service.NewUserService(repo, nil, &testTx{})
+		err := svc.DeleteByID(adminCtx, 1, 0)
 		assert.Error(t, err)
 	})
+
+	t.Run("error:stale version", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("DeleteByID", mock.Anything, int64(1), int64(2)).Return(modelErr.ErrNotFound).Once()
+		repo.On("FindByID", mock.Anything, int64(1), false).Return(&domain.User{ID: 1, Version: 3}, nil).Once()
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		err := svc.DeleteByID(adminCtx, 1, 2)
+		assert.ErrorIs(t, err, modelErr.ErrPreconditionFailed)
+	})
+}
+
+func TestUserService_Restore(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("Restore", mock.Anything, int64(1)).Return(&domain.User{ID: 1}, nil)
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		user, err := svc.Restore(adminCtx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), user.ID)
+	})
+
+	t.Run("success:not deleted", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("Restore", mock.Anything, int64(1)).Return(nil, modelErr.ErrNotFound)
+		repo.On("FindByID", mock.Anything, int64(1), false).Return(&domain.User{ID: 1}, nil)
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		user, err := svc.Restore(adminCtx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), user.ID)
+	})
+
+	t.Run("error:not found", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("Restore", mock.Anything, int64(1)).Return(nil, modelErr.ErrNotFound)
+		repo.On("FindByID", mock.Anything, int64(1), false).Return(nil, modelErr.ErrNotFound)
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		_, err := svc.Restore(adminCtx, 1)
+		assert.ErrorIs(t, err, modelErr.ErrNotFound)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("Restore", mock.Anything, int64(1)).Return(nil, errors.New("Unexpexted Error"))
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		_, err := svc.Restore(adminCtx, 1)
+		assert.Error(t, err)
+	})
+}
+
+func TestUserService_CreateWithFirstPost(t *testing.T) {
+	req := reqres.CreateUserReq{FirstName: "john", Email: "john@email.test"}
+	postReq := reqres.FirstPostReq{Title: "hello", Content: "world"}
+
+	t.Run("success", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("Save", mock.Anything, mock.AnythingOfType("*domain.User")).
+			Run(func(args mock.Arguments) { args.Get(1).(*domain.User).ID = 7 }).
+			Return(nil)
+		postRepo := mocks.NewPostRepository(t)
+		postRepo.On("Save", mock.Anything, mock.MatchedBy(func(post *domain.Post) bool {
+			return post.UserID == 7 && post.Title == "hello"
+		})).Run(func(args mock.Arguments) { args.Get(1).(*domain.Post).ID = 3 }).Return(nil)
+
+		tx := &testTx{}
+		svc := service.NewUserService(repo, postRepo, tx)
+		user, post, err := svc.CreateWithFirstPost(adminCtx, &req, &postReq)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, tx.runs)
+		assert.Equal(t, int64(7), user.ID)
+		assert.Equal(t, int64(3), post.ID)
+		assert.Equal(t, user.ID, post.UserID)
+	})
+
+	t.Run("error:post rolls the user back", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("Save", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil)
+		postRepo := mocks.NewPostRepository(t)
+		postRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.Post")).Return(errors.New("Unexpexted Error"))
+
+		tx := &testTx{}
+		svc := service.NewUserService(repo, postRepo, tx)
+		user, post, err := svc.CreateWithFirstPost(adminCtx, &req, &postReq)
+		assert.Error(t, err)
+		assert.Error(t, tx.err, "the transaction is rolled back")
+		assert.Nil(t, user)
+		assert.Nil(t, post)
+	})
+
+	t.Run("error:user", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("Save", mock.Anything, mock.AnythingOfType("*domain.User")).
+			Return(&modelErr.FieldError{Field: "email", Rule: "unique", Err: modelErr.ErrConflict})
+
+		svc := service.NewUserService(repo, mocks.NewPostRepository(t), &testTx{})
+		_, _, err := svc.CreateWithFirstPost(adminCtx, &req, &postReq)
+		assert.ErrorIs(t, err, modelErr.ErrConflict)
+	})
+}
+
+func TestUserService_Batch(t *testing.T) {
+	createOp := reqres.BatchUserOpReq{Op: reqres.BatchOpCreate, User: &reqres.CreateUserReq{FirstName: "john", Email: "john@email.test"}}
+	deleteOp := reqres.BatchUserOpReq{Op: reqres.BatchOpDelete, ID: 2, Version: 3}
+
+	t.Run("success", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("ApplyBatch", mock.Anything, mock.MatchedBy(func(ops []domain.UserBatchOp) bool {
+			return len(ops) == 2 && ops[0].User.Email == "john@email.test" && ops[1].ID == 2 && ops[1].Version == 3 && ops[1].User == nil
+		}), true).Return([]domain.UserBatchResult{{User: &domain.User{ID: 1}}, {}}, nil)
+
+		tx := &testTx{}
+		svc := service.NewUserService(repo, nil, tx)
+		results, err := svc.Batch(adminCtx, &reqres.BatchUserReq{Atomic: true, Operations: []reqres.BatchUserOpReq{createOp, deleteOp}})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, tx.runs)
+		assert.Equal(t, sql.LevelRepeatableRead, tx.opts.Isolation)
+		assert.NoError(t, tx.err)
+		assert.Len(t, results, 2)
+		assert.Equal(t, int64(1), results[0].User.ID)
+		assert.NoError(t, results[1].Err)
+	})
+
+	t.Run("success:partial", func(t *testing.T) {
+		updateOp := reqres.BatchUserOpReq{Op: reqres.BatchOpUpdate, ID: 2, User: &reqres.CreateUserReq{FirstName: "john", Email: "john@email.test"}}
+
+		repo := mocks.NewUserRepository(t)
+		repo.On("ApplyBatch", mock.Anything, mock.MatchedBy(func(ops []domain.UserBatchOp) bool {
+			return len(ops) == 1 && ops[0].Op == reqres.BatchOpUpdate
+		}), false).Return([]domain.UserBatchResult{{Err: modelErr.ErrNotFound}}, nil)
+
+		tx := &testTx{}
+		svc := service.NewUserService(repo, nil, tx)
+		results, err := svc.Batch(userCtx, &reqres.BatchUserReq{Operations: []reqres.BatchUserOpReq{createOp, updateOp, deleteOp}})
+		assert.NoError(t, err)
+		assert.Zero(t, tx.runs)
+		assert.ErrorIs(t, results[0].Err, modelErr.ErrForbidden)
+		assert.ErrorIs(t, results[1].Err, modelErr.ErrNotFound)
+		assert.ErrorIs(t, results[2].Err, modelErr.ErrForbidden)
+	})
+
+	t.Run("error:update with a password", func(t *testing.T) {
+		updateOp := reqres.BatchUserOpReq{Op: reqres.BatchOpUpdate, ID: 2, User: &reqres.CreateUserReq{FirstName: "john", Email: "john@email.test", Password: "secret123"}}
+
+		svc := service.NewUserService(mocks.NewUserRepository(t), nil, &testTx{})
+		_, err := svc.Batch(adminCtx, &reqres.BatchUserReq{Atomic: true, Operations: []reqres.BatchUserOpReq{updateOp}})
+		assert.ErrorIs(t, err, modelErr.ErrBadParamInput)
+
+		var fieldErr *modelErr.FieldError
+		assert.ErrorAs(t, err, &fieldErr)
+		assert.Equal(t, "operations[0].user.password", fieldErr.Field)
+		assert.Equal(t, "excluded", fieldErr.Rule)
+	})
+
+	t.Run("error:atomic conflict", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("ApplyBatch", mock.Anything, mock.Anything, true).Return([]domain.UserBatchResult{
+			{User: &domain.User{ID: 1}},
+			{Err: &modelErr.FieldError{Field: "email", Rule: "unique", Err: modelErr.ErrConflict}},
+		}, nil)
+
+		tx := &testTx{}
+		svc := service.NewUserService(repo, nil, tx)
+		_, err := svc.Batch(adminCtx, &reqres.BatchUserReq{Atomic: true, Operations: []reqres.BatchUserOpReq{createOp, createOp}})
+		assert.ErrorIs(t, err, modelErr.ErrConflict)
+		assert.ErrorIs(t, tx.err, modelErr.ErrConflict, "the transaction is rolled back")
+
+		var fieldErr *modelErr.FieldError
+		assert.ErrorAs(t, err, &fieldErr)
+		assert.Equal(t, "operations[1].email", fieldErr.Field)
+	})
+
+	t.Run("error:atomic stale version", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("ApplyBatch", mock.Anything, mock.Anything, true).Return([]domain.UserBatchResult{{Err: modelErr.ErrNotFound}}, nil)
+		repo.On("FindByID", mock.Anything, int64(2), false).Return(&domain.User{ID: 2, Version: 4}, nil)
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		_, err := svc.Batch(adminCtx, &reqres.BatchUserReq{Atomic: true, Operations: []reqres.BatchUserOpReq{deleteOp}})
+		assert.ErrorIs(t, err, modelErr.ErrPreconditionFailed)
+		assert.Contains(t, err.Error(), "operations[0]")
+	})
+
+	t.Run("error:atomic transaction", func(t *testing.T) {
+		tx := mocks.NewTxManager(t)
+		tx.On("WithinTx", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("Unexpexted Error"))
+
+		svc := service.NewUserService(mocks.NewUserRepository(t), nil, tx)
+		results, err := svc.Batch(adminCtx, &reqres.BatchUserReq{Atomic: true, Operations: []reqres.BatchUserOpReq{createOp}})
+		assert.Error(t, err)
+		assert.Nil(t, results)
+	})
+
+	t.Run("error:atomic forbidden", func(t *testing.T) {
+		svc := service.NewUserService(mocks.NewUserRepository(t), nil, &testTx{})
+		_, err := svc.Batch(userCtx, &reqres.BatchUserReq{Atomic: true, Operations: []reqres.BatchUserOpReq{createOp}})
+		assert.ErrorIs(t, err, modelErr.ErrForbidden)
+	})
+}
+
+func TestUserService_Export(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		req := &reqres.ListUserReq{IncludeDeleted: true}
+		repo := mocks.NewUserRepository(t)
+		repo.On("Each", mock.Anything, req, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			fn := args.Get(2).(func(user *domain.User) error)
+			_ = fn(&domain.User{ID: 1})
+			_ = fn(&domain.User{ID: 2})
+		})
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		var ids []int64
+		err := svc.Export(adminCtx, req, func(user *domain.User) error {
+			ids = append(ids, user.ID)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1, 2}, ids)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("Each", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("Unexpexted Error"))
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		err := svc.Export(context.TODO(), &reqres.ListUserReq{}, func(user *domain.User) error { return nil })
+		assert.Error(t, err)
+	})
+
+	t.Run("error:include deleted forbidden", func(t *testing.T) {
+		svc := service.NewUserService(mocks.NewUserRepository(t), nil, &testTx{})
+		err := svc.Export(userCtx, &reqres.ListUserReq{IncludeDeleted: true}, func(user *domain.User) error { return nil })
+		assert.ErrorIs(t, err, modelErr.ErrForbidden)
+	})
+}
+
+func TestUserService_PurgeDeleted(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("PurgeDeleted", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+			return time.Since(before) >= 24*time.Hour
+		})).Return(int64(2), nil)
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		n, err := svc.PurgeDeleted(context.TODO(), 24*time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("PurgeDeleted", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), errors.New("Unexpexted Error"))
+
+		svc := service.NewUserService(repo, nil, &testTx{})
+		_, err := svc.PurgeDeleted(context.TODO(), 24*time.Hour)
+		assert.Error(t, err)
+	})
+}
+
+func TestUserService_Ownership(t *testing.T) {
+	withPrincipal := func(sub string, roles ...auth.Role) context.Context {
+		return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: sub, Roles: roles})
+	}
+	callers := []struct {
+		name string
+		ctx  context.Context
+		err  error
+	}{
+		{name: "admin", ctx: withPrincipal("1", auth.RoleAdmin)},
+		{name: "owner", ctx: withPrincipal("2", auth.RoleUser)},
+		{name: "other user", ctx: withPrincipal("3", auth.RoleUser), err: modelErr.ErrForbidden},
+		{name: "non numeric subject", ctx: withPrincipal("service-account", auth.RoleUser), err: modelErr.ErrForbidden},
+		{name: "unauthenticated", ctx: context.Background(), err: modelErr.ErrUnauthorized},
+	}
+	patch := reqres.PatchUserReq{LastName: reqres.NewNullString("due")}
+	methods := []struct {
+		name   string
+		expect func(repo *mocks.UserRepository)
+		call   func(ctx context.Context, svc domain.UserService) error
+	}{
+		{
+			name: "FindByID",
+			expect: func(repo *mocks.UserRepository) {
+				repo.On("FindByID", mock.Anything, int64(2), false).Return(&domain.User{ID: 2}, nil)
+			},
+			call: func(ctx context.Context, svc domain.UserService) error {
+				_, err := svc.FindByID(ctx, 2, false)
+				return err
+			},
+		},
+		{
+			name: "UpdateByID",
+			expect: func(repo *mocks.UserRepository) {
+				repo.On("UpdateByID", mock.Anything, int64(2), int64(0), mock.AnythingOfType("*domain.User")).Return(&domain.User{ID: 2}, nil)
+			},
+			call: func(ctx context.Context, svc domain.UserService) error {
+				_, err := svc.UpdateByID(ctx, 2, 0, &reqres.UpdateUserReq{})
+				return err
+			},
+		},
+		{
+			name: "PatchByID",
+			expect: func(repo *mocks.UserRepository) {
+				repo.On("PatchByID", mock.Anything, int64(2), int64(0), &patch).Return(&domain.User{ID: 2}, nil)
+			},
+			call: func(ctx context.Context, svc domain.UserService) error {
+				_, err := svc.PatchByID(ctx, 2, 0, &patch)
+				return err
+			},
+		},
+		{
+			name:   "DeleteByID",
+			expect: func(repo *mocks.UserRepository) { repo.On("DeleteByID", mock.Anything, int64(2), int64(0)).Return(nil) },
+			call: func(ctx context.Context, svc domain.UserService) error {
+				return svc.DeleteByID(ctx, 2, 0)
+			},
+		},
+		{
+			name: "Restore",
+			expect: func(repo *mocks.UserRepository) {
+				repo.On("Restore", mock.Anything, int64(2)).Return(&domain.User{ID: 2}, nil)
+			},
+			call: func(ctx context.Context, svc domain.UserService) error {
+				_, err := svc.Restore(ctx, 2)
+				return err
+			},
+		},
+	}
+
+	for _, m := range methods {
+		for _, c := range callers {
+			t.Run(m.name+"/"+c.name, func(t *testing.T) {
+				repo := mocks.NewUserRepository(t)
+				if c.err == nil {
+					m.expect(repo)
+				}
+
+				err := m.call(c.ctx, service.NewUserService(repo, nil, &testTx{}))
+				if c.err == nil {
+					assert.NoError(t, err)
+				} else {
+					assert.ErrorIs(t, err, c.err)
+				}
+			})
+		}
+	}
 }
@@ -0,0 +1,23 @@
+-- Emails were not unique before this index, the users sharing an email ignoring case are reported instead
+-- of failing on the index with a bare unique violation. They are resolved by hand, see the README.
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(email || ' (ids ' || ids || ')', ', ')
+    INTO duplicates
+    FROM (
+        SELECT LOWER(email) AS email, string_agg(id::TEXT, ' ' ORDER BY id) AS ids
+        FROM users
+        GROUP BY LOWER(email)
+        HAVING COUNT(*) > 1
+    ) AS shared;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'users share an email, resolve them before the unique email index is built: %', duplicates
+            USING HINT = 'see "Duplicate emails" in the README';
+    END IF;
+END
+$$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_unique_idx ON users (LOWER(email))
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS users_email_unique_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_unique_idx ON users (LOWER(email));
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+DROP INDEX IF EXISTS users_email_unique_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_unique_idx ON users (LOWER(email)) WHERE deleted_at IS NULL
\ No newline at end of file
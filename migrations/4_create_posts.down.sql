@@ -0,0 +1 @@
+DROP TABLE IF EXISTS posts
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS posts (
+    ID SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    title VARCHAR(120),
+    content TEXT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    CONSTRAINT posts_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS posts_user_id_idx ON posts (user_id)
\ No newline at end of file
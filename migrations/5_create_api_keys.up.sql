@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    ID SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS api_keys_prefix_unique_idx ON api_keys (prefix)
\ No newline at end of file
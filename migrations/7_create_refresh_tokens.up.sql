@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    ID SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    family_id VARCHAR(32) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    CONSTRAINT refresh_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS refresh_tokens_token_hash_unique_idx ON refresh_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens (family_id)
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    ID SERIAL PRIMARY KEY,
+    key_hash VARCHAR(64) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    status INTEGER,
+    headers TEXT,
+    body BYTEA,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idempotency_keys_key_hash_unique_idx ON idempotency_keys (key_hash);
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at)
\ No newline at end of file
@@ -1,7 +1,19 @@
 package httputil
 
 type ApiResponse struct {
-	Error   bool        `json:"error"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	Error      bool         `json:"error"`
+	Code       string       `json:"code,omitempty"`
+	Message    string       `json:"message"`
+	Data       interface{}  `json:"data,omitempty"`
+	Pagination interface{}  `json:"pagination,omitempty"`
+	Errors     []FieldError `json:"errors,omitempty"`
+	// RequestID is set on errors, for the client to report it.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// FieldError describes why a single input field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
 }
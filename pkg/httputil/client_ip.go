@@ -0,0 +1,83 @@
+package httputil
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TrustedProxies holds the networks of the reverse proxies allowed to tell the client address with
+// X-Forwarded-For.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses CIDRs like "10.0.0.0/8", a bare IP is a network of its own.
+func ParseTrustedProxies(entries []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("trusted proxy %q is not an IP or CIDR", entry)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			entry = fmt.Sprintf("%s/%d", entry, bits)
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q is not an IP or CIDR", entry)
+		}
+		proxies = append(proxies, network)
+	}
+
+	return proxies, nil
+}
+
+func (t TrustedProxies) trusted(ip net.IP) bool {
+	for _, network := range t {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClientIP returns the address of the client of r. The X-Forwarded-For addresses are only believed when
+// the request comes from a trusted proxy, and are walked from the closest one, the first address not
+// belonging to a trusted proxy is the client.
+func (t TrustedProxies) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !t.trusted(ip) {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if hop == nil {
+			break
+		}
+
+		ip = hop
+		if !t.trusted(ip) {
+			break
+		}
+	}
+
+	return ip.String()
+}
@@ -0,0 +1,57 @@
+package httputil_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-rest-api-boilerplate/pkg/httputil"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := httputil.ParseTrustedProxies([]string{"10.0.0.0/8", " 192.168.1.1 ", "::1", ""})
+	require.NoError(t, err)
+	assert.Len(t, proxies, 3)
+	assert.Equal(t, "192.168.1.1/32", proxies[1].String())
+	assert.Equal(t, "::1/128", proxies[2].String())
+
+	_, err = httputil.ParseTrustedProxies([]string{"proxy.local"})
+	assert.Error(t, err)
+	_, err = httputil.ParseTrustedProxies([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestTrustedProxies_ClientIP(t *testing.T) {
+	proxies, err := httputil.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		ip         string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.7:5000", ip: "203.0.113.7"},
+		{name: "untrusted peer forwarding", remoteAddr: "203.0.113.7:5000", forwarded: []string{"198.51.100.1"}, ip: "203.0.113.7"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.2:5000", forwarded: []string{"198.51.100.1"}, ip: "198.51.100.1"},
+		{name: "spoofed first hop", remoteAddr: "10.0.0.2:5000", forwarded: []string{"1.1.1.1, 198.51.100.1, 10.0.0.3"}, ip: "198.51.100.1"},
+		{name: "several headers", remoteAddr: "10.0.0.2:5000", forwarded: []string{"198.51.100.1", "10.0.0.3"}, ip: "198.51.100.1"},
+		{name: "only proxies", remoteAddr: "10.0.0.2:5000", forwarded: []string{"10.0.0.4, 10.0.0.3"}, ip: "10.0.0.4"},
+		{name: "garbage hop", remoteAddr: "10.0.0.2:5000", forwarded: []string{"198.51.100.1, unknown"}, ip: "10.0.0.2"},
+		{name: "trusted proxy without header", remoteAddr: "10.0.0.2:5000", ip: "10.0.0.2"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = c.remoteAddr
+			for _, f := range c.forwarded {
+				r.Header.Add("X-Forwarded-For", f)
+			}
+
+			assert.Equal(t, c.ip, proxies.ClientIP(r))
+		})
+	}
+}
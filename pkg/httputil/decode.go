@@ -0,0 +1,118 @@
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+)
+
+const (
+	ContentTypeJSON = "application/json"
+	// ContentTypeMergePatchJSON is the media type of a JSON merge patch (RFC 7396).
+	ContentTypeMergePatchJSON = "application/merge-patch+json"
+)
+
+var (
+	// ErrUnsupportedMediaType is answered with 415 to a body of a media type the route does not accept.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	// ErrBodyTooLarge is answered with 413 to a body over the size limit of the route.
+	ErrBodyTooLarge = errors.New("request body too large")
+)
+
+// DecodeJSON decodes the JSON body of r into v. The body must be sent as application/json or one of
+// mediaTypes (a request without Content-Type is read as JSON), must hold a single JSON value and no
+// field unknown to v, the errors wrap ErrUnsupportedMediaType, ErrBodyTooLarge or
+// modelErr.ErrBadParamInput.
+func DecodeJSON(r *http.Request, v interface{}, mediaTypes ...string) error {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || !acceptedMediaType(mediaType, mediaTypes) {
+			return fmt.Errorf("%w: %s, send %s", ErrUnsupportedMediaType, contentType, strings.Join(append([]string{ContentTypeJSON}, mediaTypes...), " or "))
+		}
+	}
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return decodeError(err)
+	}
+
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		if err != nil && isBodyTooLarge(err) {
+			return ErrBodyTooLarge
+		}
+		return fmt.Errorf("%w: body must hold a single JSON value", modelErr.ErrBadParamInput)
+	}
+
+	return nil
+}
+
+// ReadBody reads the whole body of r, a body over the limit set by http.MaxBytesReader fails with
+// ErrBodyTooLarge.
+func ReadBody(r *http.Request) ([]byte, error) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil && isBodyTooLarge(err) {
+		return nil, ErrBodyTooLarge
+	}
+
+	return b, err
+}
+
+func acceptedMediaType(mediaType string, mediaTypes []string) bool {
+	if mediaType == ContentTypeJSON {
+		return true
+	}
+	for _, m := range mediaTypes {
+		if mediaType == m {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodeError tells the client what is wrong with the body without the Go types of the error.
+func decodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case isBodyTooLarge(err):
+		return ErrBodyTooLarge
+	case errors.Is(err, io.EOF):
+		return fmt.Errorf("%w: body is empty", modelErr.ErrBadParamInput)
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("%w: body is not valid JSON", modelErr.ErrBadParamInput)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return fmt.Errorf("%w: %s must be a %s", modelErr.ErrBadParamInput, typeErr.Field, jsonType(typeErr.Type.String()))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return fmt.Errorf("%w: unknown field %s", modelErr.ErrBadParamInput, strings.TrimPrefix(err.Error(), "json: unknown field "))
+	}
+
+	return fmt.Errorf("%w: cannot receive the payload schema", modelErr.ErrBadParamInput)
+}
+
+// isBodyTooLarge reports the error of a body read through http.MaxBytesReader.
+func isBodyTooLarge(err error) bool {
+	return strings.Contains(err.Error(), "http: request body too large")
+}
+
+func jsonType(goType string) string {
+	switch {
+	case strings.HasPrefix(goType, "int"), strings.HasPrefix(goType, "uint"), strings.HasPrefix(goType, "float"):
+		return "number"
+	case goType == "bool":
+		return "boolean"
+	case strings.HasPrefix(goType, "[]"):
+		return "array"
+	case goType == "string":
+		return "string"
+	}
+
+	return "object"
+}
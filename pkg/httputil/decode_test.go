@@ -0,0 +1,82 @@
+package httputil_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/pkg/httputil"
+)
+
+type decodeReq struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	newRequest := func(contentType, body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		return r
+	}
+
+	t.Run("success", func(t *testing.T) {
+		for _, contentType := range []string{"application/json", "application/json; charset=utf-8", ""} {
+			var req decodeReq
+			err := httputil.DecodeJSON(newRequest(contentType, `{"name":"john","age":20}`+"\n"), &req)
+			assert.NoError(t, err, contentType)
+			assert.Equal(t, decodeReq{Name: "john", Age: 20}, req)
+		}
+	})
+
+	t.Run("extra media type", func(t *testing.T) {
+		var req decodeReq
+		err := httputil.DecodeJSON(newRequest(httputil.ContentTypeMergePatchJSON, `{"name":"john"}`), &req, httputil.ContentTypeMergePatchJSON)
+		assert.NoError(t, err)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		cases := []struct {
+			name        string
+			contentType string
+			body        string
+			err         error
+			message     string
+		}{
+			{name: "content type", contentType: "text/plain", body: `{"name":"john"}`, err: httputil.ErrUnsupportedMediaType},
+			{name: "merge patch not accepted", contentType: httputil.ContentTypeMergePatchJSON, body: `{}`, err: httputil.ErrUnsupportedMediaType},
+			{name: "invalid content type", contentType: "json;;", body: `{}`, err: httputil.ErrUnsupportedMediaType},
+			{name: "unknown field", body: `{"name":"john","admin":true}`, err: modelErr.ErrBadParamInput, message: `unknown field "admin"`},
+			{name: "trailing data", body: `{"name":"john"}{"name":"jane"}`, err: modelErr.ErrBadParamInput, message: "single JSON value"},
+			{name: "trailing garbage", body: `{"name":"john"} x`, err: modelErr.ErrBadParamInput, message: "single JSON value"},
+			{name: "empty", body: ``, err: modelErr.ErrBadParamInput, message: "body is empty"},
+			{name: "syntax", body: `{"name":`, err: modelErr.ErrBadParamInput, message: "not valid JSON"},
+			{name: "type", body: `{"age":"20"}`, err: modelErr.ErrBadParamInput, message: "age must be a number"},
+		}
+
+		for _, c := range cases {
+			var req decodeReq
+			err := httputil.DecodeJSON(newRequest(c.contentType, c.body), &req)
+			assert.ErrorIs(t, err, c.err, c.name)
+			assert.Contains(t, err.Error(), c.message, c.name)
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		r := newRequest("", `{"name":"`+strings.Repeat("a", 100)+`"}`)
+		r.Body = http.MaxBytesReader(httptest.NewRecorder(), r.Body, 32)
+
+		var req decodeReq
+		err := httputil.DecodeJSON(r, &req)
+		assert.ErrorIs(t, err, httputil.ErrBodyTooLarge)
+
+		status, code := httputil.ErrorStatus(err)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+		assert.Equal(t, httputil.CodeBodyTooLarge, code)
+	})
+}
@@ -0,0 +1,137 @@
+package httputil
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/pkg/requestid"
+)
+
+// Stable machine-readable error codes, clients should branch on these instead of the message.
+const (
+	CodeBadParamInput    = "bad_param_input"
+	CodeValidationFailed = "validation_failed"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeRateLimited      = "rate_limited"
+	CodePrecondition     = "precondition_failed"
+	CodeUnsupportedMedia = "unsupported_media_type"
+	CodeBodyTooLarge     = "body_too_large"
+	CodeInternal         = "internal_error"
+)
+
+// FieldErrorer is implemented by errors listing the rejected input fields, like validation errors.
+type FieldErrorer interface {
+	error
+	FieldErrors() []FieldError
+}
+
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+// errorMappings translates the domain errors of internal/model/error and the request errors of this
+// package, matched with errors.Is so wrapped errors are mapped as well.
+var errorMappings = []errorMapping{
+	{err: modelErr.ErrNotFound, status: http.StatusNotFound, code: CodeNotFound},
+	{err: modelErr.ErrConflict, status: http.StatusConflict, code: CodeConflict},
+	{err: modelErr.ErrBadParamInput, status: http.StatusUnprocessableEntity, code: CodeBadParamInput},
+	{err: modelErr.ErrUnauthorized, status: http.StatusUnauthorized, code: CodeUnauthorized},
+	{err: modelErr.ErrForbidden, status: http.StatusForbidden, code: CodeForbidden},
+	{err: modelErr.ErrRateLimited, status: http.StatusTooManyRequests, code: CodeRateLimited},
+	{err: modelErr.ErrPreconditionFailed, status: http.StatusPreconditionFailed, code: CodePrecondition},
+	{err: ErrUnsupportedMediaType, status: http.StatusUnsupportedMediaType, code: CodeUnsupportedMedia},
+	{err: ErrBodyTooLarge, status: http.StatusRequestEntityTooLarge, code: CodeBodyTooLarge},
+}
+
+// ErrorStatus returns the HTTP status and error code of err, unknown errors are internal errors.
+func ErrorStatus(err error) (int, string) {
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			return m.status, m.code
+		}
+	}
+
+	var fieldErrorer FieldErrorer
+	if errors.As(err, &fieldErrorer) {
+		return http.StatusUnprocessableEntity, CodeValidationFailed
+	}
+
+	return http.StatusInternalServerError, CodeInternal
+}
+
+// RespondWithErr writes err with the status and code it maps to, as problem details or as the legacy
+// ApiResponse depending on the error format and the Accept header. Internal errors are logged and their
+// message is hidden from the client.
+func RespondWithErr(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := ErrorStatus(err)
+	if status >= http.StatusInternalServerError {
+		log.WithContext(r.Context()).WithError(err).Error("internal error while handling request")
+	}
+
+	message, fieldErrs := ErrorMessage(err)
+
+	if !wantsProblem(r) {
+		RespondWithJSON(w, status, ApiResponse{
+			Error:     true,
+			Code:      code,
+			Message:   message,
+			Errors:    fieldErrs,
+			RequestID: requestid.FromContext(r.Context()),
+		})
+		return
+	}
+
+	RespondWithProblem(w, Problem{
+		Type:      ProblemType(code),
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    message,
+		Instance:  r.URL.Path,
+		Code:      code,
+		Errors:    fieldErrs,
+		RequestID: requestid.FromContext(r.Context()),
+	})
+}
+
+// ErrorMessage returns the message and the field errors err is answered with, the message of an internal
+// error is hidden.
+func ErrorMessage(err error) (string, []FieldError) {
+	if status, _ := ErrorStatus(err); status >= http.StatusInternalServerError {
+		return http.StatusText(status), nil
+	}
+
+	var fieldErr *modelErr.FieldError
+	var fieldErrorer FieldErrorer
+	switch {
+	case errors.As(err, &fieldErr):
+		return fieldErr.Err.Error(), []FieldError{fieldErrorMessage(fieldErr)}
+	case errors.As(err, &fieldErrorer):
+		return "request validation failed", fieldErrorer.FieldErrors()
+	}
+
+	return err.Error(), nil
+}
+
+func fieldErrorMessage(err *modelErr.FieldError) FieldError {
+	message := fmt.Sprintf("%s is not valid", err.Field)
+	switch {
+	case errors.Is(err, modelErr.ErrConflict):
+		message = fmt.Sprintf("%s is already in use", err.Field)
+	case err.Rule == "exists":
+		message = fmt.Sprintf("%s does not exist", err.Field)
+	}
+
+	return FieldError{
+		Field:   err.Field,
+		Rule:    err.Rule,
+		Message: message,
+	}
+}
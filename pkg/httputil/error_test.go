@@ -0,0 +1,155 @@
+package httputil_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	modelErr "go-rest-api-boilerplate/internal/model/error"
+	"go-rest-api-boilerplate/pkg/httputil"
+	"go-rest-api-boilerplate/pkg/requestid"
+	"go-rest-api-boilerplate/pkg/validation"
+)
+
+func TestErrorStatus(t *testing.T) {
+	validationErr := validation.Errors{{Field: "email", Rule: "required", Message: "email is a required field"}}
+
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{err: modelErr.ErrNotFound, status: http.StatusNotFound, code: httputil.CodeNotFound},
+		{err: fmt.Errorf("find user: %w", modelErr.ErrNotFound), status: http.StatusNotFound, code: httputil.CodeNotFound},
+		{err: modelErr.ErrConflict, status: http.StatusConflict, code: httputil.CodeConflict},
+		{err: &modelErr.FieldError{Field: "email", Err: modelErr.ErrConflict}, status: http.StatusConflict, code: httputil.CodeConflict},
+		{err: modelErr.ErrBadParamInput, status: http.StatusUnprocessableEntity, code: httputil.CodeBadParamInput},
+		{err: fmt.Errorf("%w: token is expired", modelErr.ErrUnauthorized), status: http.StatusUnauthorized, code: httputil.CodeUnauthorized},
+		{err: modelErr.ErrForbidden, status: http.StatusForbidden, code: httputil.CodeForbidden},
+		{err: modelErr.ErrRateLimited, status: http.StatusTooManyRequests, code: httputil.CodeRateLimited},
+		{err: modelErr.ErrPreconditionFailed, status: http.StatusPreconditionFailed, code: httputil.CodePrecondition},
+		{err: httputil.ErrUnsupportedMediaType, status: http.StatusUnsupportedMediaType, code: httputil.CodeUnsupportedMedia},
+		{err: httputil.ErrBodyTooLarge, status: http.StatusRequestEntityTooLarge, code: httputil.CodeBodyTooLarge},
+		{err: validationErr, status: http.StatusUnprocessableEntity, code: httputil.CodeValidationFailed},
+		{err: errors.New("Unexpexted Error"), status: http.StatusInternalServerError, code: httputil.CodeInternal},
+	}
+
+	for _, c := range cases {
+		status, code := httputil.ErrorStatus(c.err)
+		assert.Equal(t, c.status, status, c.err.Error())
+		assert.Equal(t, c.code, code, c.err.Error())
+	}
+}
+
+func TestRespondWithErr(t *testing.T) {
+	t.Run("problem details", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/user/1", nil)
+		r.Header.Set("Accept", httputil.ContentTypeProblemJSON)
+		httputil.RespondWithErr(w, r, modelErr.ErrNotFound)
+
+		var problem httputil.Problem
+		json.NewDecoder(w.Body).Decode(&problem)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, httputil.ContentTypeProblemJSON, w.Header().Get("Content-Type"))
+		assert.Equal(t, httputil.Problem{
+			Type:     httputil.ProblemType(httputil.CodeNotFound),
+			Title:    http.StatusText(http.StatusNotFound),
+			Status:   http.StatusNotFound,
+			Detail:   modelErr.ErrNotFound.Error(),
+			Instance: "/api/v1/user/1",
+			Code:     httputil.CodeNotFound,
+		}, problem)
+	})
+
+	t.Run("internal error message is hidden", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", httputil.ContentTypeProblemJSON)
+		httputil.RespondWithErr(w, r, errors.New("pq: connection refused"))
+
+		var problem httputil.Problem
+		json.NewDecoder(w.Body).Decode(&problem)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, http.StatusText(http.StatusInternalServerError), problem.Detail)
+		assert.Equal(t, httputil.CodeInternal, problem.Code)
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("Accept", httputil.ContentTypeProblemJSON)
+		httputil.RespondWithErr(w, r, validation.Errors{{Field: "email", Rule: "required", Message: "email is a required field"}})
+
+		var problem httputil.Problem
+		json.NewDecoder(w.Body).Decode(&problem)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, httputil.CodeValidationFailed, problem.Code)
+		assert.Equal(t, []httputil.FieldError{{Field: "email", Rule: "required", Message: "email is a required field"}}, problem.Errors)
+	})
+
+	t.Run("request id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", httputil.ContentTypeProblemJSON)
+		r = r.WithContext(requestid.NewContext(r.Context(), "req-1"))
+		httputil.RespondWithErr(w, r, modelErr.ErrNotFound)
+
+		var problem httputil.Problem
+		json.NewDecoder(w.Body).Decode(&problem)
+		assert.Equal(t, "req-1", problem.RequestID)
+	})
+
+	t.Run("legacy", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		httputil.RespondWithErr(w, r, &modelErr.FieldError{Field: "email", Rule: "unique", Err: modelErr.ErrConflict})
+
+		var response httputil.ApiResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.Equal(t, true, response.Error)
+		assert.Equal(t, modelErr.ErrConflict.Error(), response.Message)
+		assert.Equal(t, []httputil.FieldError{{Field: "email", Rule: "unique", Message: "email is already in use"}}, response.Errors)
+	})
+
+	t.Run("legacy with problem accept header", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", "application/json, application/problem+json;q=0.9")
+		httputil.RespondWithErr(w, r, modelErr.ErrNotFound)
+
+		assert.Equal(t, httputil.ContentTypeProblemJSON, w.Header().Get("Content-Type"))
+	})
+
+	t.Run("problem format", func(t *testing.T) {
+		httputil.SetErrorFormat(httputil.ErrorFormatProblem)
+		defer httputil.SetErrorFormat(httputil.ErrorFormatLegacy)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		httputil.RespondWithErr(w, r, modelErr.ErrNotFound)
+
+		assert.Equal(t, httputil.ContentTypeProblemJSON, w.Header().Get("Content-Type"))
+	})
+
+	t.Run("problem format with json accept header", func(t *testing.T) {
+		httputil.SetErrorFormat(httputil.ErrorFormatProblem)
+		defer httputil.SetErrorFormat(httputil.ErrorFormatLegacy)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", "application/json")
+		httputil.RespondWithErr(w, r, modelErr.ErrNotFound)
+
+		var response httputil.ApiResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.Equal(t, true, response.Error)
+	})
+}
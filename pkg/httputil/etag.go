@@ -0,0 +1,35 @@
+package httputil
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ETag quotes value into a strong entity tag.
+func ETag(value string) string {
+	return `"` + value + `"`
+}
+
+// ParseETags splits the entity tags of an If-Match or If-None-Match header, "*" is kept as is.
+func ParseETags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// IfNoneMatch reports whether the If-None-Match header of r matches etag, so a GET can be answered with
+// 304. The tags are compared weakly (RFC 9110 section 13.1.2).
+func IfNoneMatch(r *http.Request, etag string) bool {
+	for _, tag := range ParseETags(r.Header.Get("If-None-Match")) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,36 @@
+package httputil_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go-rest-api-boilerplate/pkg/httputil"
+)
+
+func TestParseETags(t *testing.T) {
+	assert.Equal(t, []string{`"1"`, `W/"2"`}, httputil.ParseETags(` "1", W/"2",`))
+	assert.Equal(t, []string{"*"}, httputil.ParseETags("*"))
+	assert.Nil(t, httputil.ParseETags(""))
+}
+
+func TestIfNoneMatch(t *testing.T) {
+	cases := []struct {
+		header string
+		match  bool
+	}{
+		{header: "", match: false},
+		{header: `"3"`, match: true},
+		{header: `"1", "3"`, match: true},
+		{header: `W/"3"`, match: true},
+		{header: "*", match: true},
+		{header: `"2"`, match: false},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("If-None-Match", c.header)
+		assert.Equal(t, c.match, httputil.IfNoneMatch(r, httputil.ETag("3")), c.header)
+	}
+}
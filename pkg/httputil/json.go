@@ -5,14 +5,6 @@ import (
 	"net/http"
 )
 
-func RespondWithError(w http.ResponseWriter, code int, message string) {
-	RespondWithJSON(w, code, ApiResponse{
-		Error:   true,
-		Message: message,
-		Data:    nil,
-	})
-}
-
 func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, _ := json.Marshal(payload)
 
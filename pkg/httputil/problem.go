@@ -0,0 +1,80 @@
+package httputil
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const ContentTypeProblemJSON = "application/problem+json"
+
+// ErrorFormat selects the body of error responses.
+type ErrorFormat string
+
+const (
+	// ErrorFormatProblem writes RFC 7807 problem details.
+	ErrorFormatProblem ErrorFormat = "problem"
+	// ErrorFormatLegacy writes the ApiResponse envelope, kept for clients predating problem details.
+	ErrorFormatLegacy ErrorFormat = "legacy"
+)
+
+var errorFormat = ErrorFormatLegacy
+
+// SetErrorFormat sets the error format of the clients that do not ask for one, see wantsProblem.
+func SetErrorFormat(format ErrorFormat) {
+	if format != ErrorFormatProblem {
+		format = ErrorFormatLegacy
+	}
+	errorFormat = format
+}
+
+// Problem is an RFC 7807 problem details object, Code and Errors are extension members.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	// RequestID is the id of the failed request, for the client to report it.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// ProblemType is the type URI reference of the problem identified by code.
+func ProblemType(code string) string {
+	return "/problems/" + code
+}
+
+// wantsProblem negotiates the error format: a client accepting application/problem+json gets problem
+// details, one accepting application/json but not problem details gets the legacy envelope, the others
+// get the configured format.
+func wantsProblem(r *http.Request) bool {
+	acceptsJSON := false
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case ContentTypeProblemJSON:
+			return true
+		case "application/json":
+			acceptsJSON = true
+		}
+	}
+
+	if acceptsJSON {
+		return false
+	}
+	return errorFormat == ErrorFormatProblem
+}
+
+func RespondWithProblem(w http.ResponseWriter, problem Problem) {
+	response, _ := json.Marshal(problem)
+
+	w.Header().Set("Content-Type", ContentTypeProblemJSON)
+	w.WriteHeader(problem.Status)
+	w.Write(response)
+}
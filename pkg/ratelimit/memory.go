@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the idle buckets are dropped from a MemoryStore.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// refill adds the tokens refilled since the last take, up to the size of the bucket.
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(float64(b.limit.Requests), b.tokens+now.Sub(b.last).Seconds()*b.limit.rate())
+	b.last = now
+}
+
+// MemoryStore keeps the buckets in the memory of the process, each instance enforces its own limit.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, lastSweep: time.Now(), now: time.Now}
+}
+
+func (m *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Requests), last: now, limit: limit}
+		m.buckets[key] = b
+	}
+	b.refill(now)
+
+	if b.tokens < 1 {
+		return b.result(), nil
+	}
+
+	b.tokens--
+	return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+}
+
+func (m *MemoryStore) Peek(_ context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.buckets[key]
+	if !ok || b.limit != limit {
+		return Result{Allowed: true, Remaining: limit.Requests}, nil
+	}
+	b.refill(m.now())
+
+	return b.result(), nil
+}
+
+// result is the outcome of taking a token from the bucket as it is.
+func (b *bucket) result() Result {
+	if b.tokens < 1 {
+		retryAfter := time.Duration((1 - b.tokens) / b.limit.rate() * float64(time.Second))
+		return Result{Allowed: false, Remaining: 0, RetryAfter: retryAfter}
+	}
+
+	return Result{Allowed: true, Remaining: int(b.tokens)}
+}
+
+// sweep drops the buckets refilled to the full since their last take, a new bucket would be the same.
+func (m *MemoryStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, b := range m.buckets {
+		if now.Sub(b.last) >= b.limit.Period {
+			delete(m.buckets, key)
+		}
+	}
+	m.lastSweep = now
+}
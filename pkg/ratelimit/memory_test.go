@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestStore() (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{now: time.Now()}
+	store := NewMemoryStore()
+	store.now = clock.Now
+	return store, clock
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	limit := Limit{Requests: 2, Period: time.Minute}
+
+	t.Run("burst then refill", func(t *testing.T) {
+		store, clock := newTestStore()
+
+		res, err := store.Take(context.TODO(), "a", limit)
+		assert.NoError(t, err)
+		assert.Equal(t, Result{Allowed: true, Remaining: 1}, res)
+
+		res, _ = store.Take(context.TODO(), "a", limit)
+		assert.Equal(t, Result{Allowed: true, Remaining: 0}, res)
+
+		res, _ = store.Take(context.TODO(), "a", limit)
+		assert.False(t, res.Allowed)
+		assert.Equal(t, 30*time.Second, res.RetryAfter)
+
+		clock.now = clock.now.Add(30 * time.Second)
+		res, _ = store.Take(context.TODO(), "a", limit)
+		assert.Equal(t, Result{Allowed: true, Remaining: 0}, res)
+	})
+
+	t.Run("keys are independent", func(t *testing.T) {
+		store, _ := newTestStore()
+
+		store.Take(context.TODO(), "a", limit)
+		store.Take(context.TODO(), "a", limit)
+		res, _ := store.Take(context.TODO(), "b", limit)
+		assert.True(t, res.Allowed)
+	})
+
+	t.Run("refill never exceeds the limit", func(t *testing.T) {
+		store, clock := newTestStore()
+
+		store.Take(context.TODO(), "a", limit)
+		clock.now = clock.now.Add(time.Hour)
+		res, _ := store.Take(context.TODO(), "a", limit)
+		assert.Equal(t, 1, res.Remaining)
+	})
+
+	t.Run("idle buckets are swept", func(t *testing.T) {
+		store, clock := newTestStore()
+
+		store.Take(context.TODO(), "a", limit)
+		clock.now = clock.now.Add(2 * time.Minute)
+		store.Take(context.TODO(), "b", limit)
+		assert.Len(t, store.buckets, 1)
+	})
+}
+
+func TestMemoryStore_Peek(t *testing.T) {
+	limit := Limit{Requests: 1, Period: time.Minute}
+	store, clock := newTestStore()
+
+	res, err := store.Peek(context.TODO(), "a", limit)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{Allowed: true, Remaining: 1}, res)
+	assert.Empty(t, store.buckets, "peeking creates no bucket")
+
+	store.Take(context.TODO(), "a", limit)
+	res, _ = store.Peek(context.TODO(), "a", limit)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Minute, res.RetryAfter)
+
+	clock.now = clock.now.Add(time.Minute)
+	res, _ = store.Peek(context.TODO(), "a", limit)
+	assert.True(t, res.Allowed)
+	res, _ = store.Take(context.TODO(), "a", limit)
+	assert.True(t, res.Allowed, "peeking takes no token")
+}
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("10/1m")
+	assert.NoError(t, err)
+	assert.Equal(t, Limit{Requests: 10, Period: time.Minute}, limit)
+
+	for _, s := range []string{"10", "0/1m", "x/1m", "10/x", "10/-1s"} {
+		_, err := ParseLimit(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests per Period, as a token bucket holding up to Requests tokens refilled evenly over
+// the Period.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// rate is the number of tokens refilled per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// ParseLimit parses a limit written like 100/1m.
+func ParseLimit(s string) (Limit, error) {
+	requests, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("rate limit %q is not requests/period", s)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n < 1 {
+		return Limit{}, fmt.Errorf("rate limit %q requests must be a positive number", s)
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q period must be a positive duration", s)
+	}
+
+	return Limit{Requests: n, Period: d}, nil
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	// Allowed is false when the bucket was empty.
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket.
+	Remaining int
+	// RetryAfter is how long until the next token, zero when one is left.
+	RetryAfter time.Duration
+}
+
+// Store keeps the token buckets, it must be safe for concurrent use. A store shared by several
+// instances, like Redis, enforces a single limit across them.
+type Store interface {
+	// Take takes a token from the bucket of key, the bucket is created full on first use.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	// Peek tells whether a token could be taken from the bucket of key without taking it.
+	Peek(ctx context.Context, key string, limit Limit) (Result, error)
+}
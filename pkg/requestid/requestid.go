@@ -0,0 +1,69 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Header carries the request id, accepted from the client or generated, and echoed on the response.
+const Header = "X-Request-ID"
+
+// maxLength bounds an accepted request id, a longer one is replaced by a generated id.
+const maxLength = 128
+
+type requestIDKey struct{}
+
+// New generates a request id.
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid reports whether a request id sent by a client can be used as is: at most 128 characters among
+// letters, digits and -_.:, so it cannot forge log lines or headers.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewContext returns a copy of ctx carrying the request id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// FromContext returns the request id of ctx, empty outside a request.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// LogHook adds the request_id field to the entries logged with log.WithContext in a request.
+type LogHook struct{}
+
+func (LogHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (LogHook) Fire(entry *log.Entry) error {
+	if id := FromContext(entry.Context); id != "" {
+		entry.Data["request_id"] = id
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package requestid_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go-rest-api-boilerplate/pkg/requestid"
+)
+
+func TestValid(t *testing.T) {
+	assert.True(t, requestid.Valid(requestid.New()))
+	assert.True(t, requestid.Valid("client-42_retry.1:a"))
+
+	for _, id := range []string{"", "has space", "new\nline", "quote\"", strings.Repeat("a", 129)} {
+		assert.False(t, requestid.Valid(id), id)
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := requestid.NewContext(context.Background(), "abc")
+	assert.Equal(t, "abc", requestid.FromContext(ctx))
+	assert.Equal(t, "", requestid.FromContext(context.Background()))
+}
+
+func TestLogHook(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&log.JSONFormatter{})
+	logger.AddHook(requestid.LogHook{})
+
+	logger.WithContext(requestid.NewContext(context.Background(), "abc")).Info("with id")
+	logger.WithContext(context.Background()).Info("without id")
+	logger.Info("no context")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+
+	var entries []map[string]interface{}
+	for _, line := range lines {
+		var entry map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	assert.Equal(t, "abc", entries[0]["request_id"])
+	assert.NotContains(t, entries[1], "request_id")
+	assert.NotContains(t, entries[2], "request_id")
+}
@@ -0,0 +1,171 @@
+package validation
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	idTranslations "github.com/go-playground/validator/v10/translations/id"
+	log "github.com/sirupsen/logrus"
+	"go-rest-api-boilerplate/pkg/httputil"
+)
+
+// Validator wraps a single go-playground validator, it is safe for concurrent use and meant to be
+// created once and shared by the handlers.
+type Validator struct {
+	validate *validator.Validate
+	uni      *ut.UniversalTranslator
+}
+
+func New() *Validator {
+	enLocale := en.New()
+	uni := ut.New(enLocale, enLocale, id.New())
+
+	validate := validator.New()
+	validate.RegisterTagNameFunc(jsonTagName)
+
+	enTrans, _ := uni.GetTranslator("en")
+	if err := enTranslations.RegisterDefaultTranslations(validate, enTrans); err != nil {
+		log.WithError(err).Fatal("failed to register en validator translations")
+	}
+
+	idTrans, _ := uni.GetTranslator("id")
+	if err := idTranslations.RegisterDefaultTranslations(validate, idTrans); err != nil {
+		log.WithError(err).Fatal("failed to register id validator translations")
+	}
+
+	return &Validator{validate: validate, uni: uni}
+}
+
+// RegisterValuer validates the given driver.Valuer types by the value they hold, a NULL is validated as
+// an empty value.
+func (v *Validator) RegisterValuer(types ...interface{}) {
+	v.validate.RegisterCustomTypeFunc(valuerValue, types...)
+}
+
+func valuerValue(field reflect.Value) interface{} {
+	valuer, ok := field.Interface().(driver.Valuer)
+	if !ok {
+		return nil
+	}
+
+	val, err := valuer.Value()
+	if err != nil {
+		return nil
+	}
+
+	return val
+}
+
+// RegisterStructValidation registers a struct level validation for rules spanning more than a field tag.
+func (v *Validator) RegisterStructValidation(fn validator.StructLevelFunc, types ...interface{}) {
+	v.validate.RegisterStructValidation(fn, types...)
+}
+
+// RegisterValidation registers a custom validation tag. messages holds its message per locale (en, id),
+// {0} standing for the field name, a locale without a message falls back to the english one.
+func (v *Validator) RegisterValidation(tag string, fn validator.Func, messages map[string]string) {
+	if err := v.validate.RegisterValidation(tag, fn); err != nil {
+		log.WithError(err).Fatalf("failed to register the %s validation", tag)
+	}
+
+	for _, locale := range []string{"en", "id"} {
+		message, ok := messages[locale]
+		if !ok {
+			message = messages["en"]
+		}
+
+		trans, _ := v.uni.GetTranslator(locale)
+		err := v.validate.RegisterTranslation(tag, trans, func(ut ut.Translator) error {
+			return ut.Add(tag, message, true)
+		}, func(ut ut.Translator, fe validator.FieldError) string {
+			t, _ := ut.T(fe.Tag(), fe.Field())
+			return t
+		})
+		if err != nil {
+			log.WithError(err).Fatalf("failed to register the %s %s validator translation", locale, tag)
+		}
+	}
+}
+
+// jsonTagName names fields after their json tag, so errors speak the same names as the payload.
+func jsonTagName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
+
+// Struct validates s. Failures are returned as Errors with the messages translated to the first
+// supported language of acceptLanguage (an Accept-Language header value), english otherwise.
+func (v *Validator) Struct(s interface{}, acceptLanguage string) error {
+	err := v.validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	trans, _ := v.uni.FindTranslator(parseAcceptLanguage(acceptLanguage)...)
+
+	errs := make(Errors, 0, len(validationErrs))
+	for _, e := range validationErrs {
+		errs = append(errs, httputil.FieldError{
+			Field:   e.Field(),
+			Rule:    e.Tag(),
+			Message: e.Translate(trans),
+		})
+	}
+
+	return errs
+}
+
+// parseAcceptLanguage turns "id-ID,en;q=0.8" into the translator locales id_ID, id, en keeping the header
+// order, the base language of a regional tag is tried right after it.
+func parseAcceptLanguage(header string) []string {
+	var locales []string
+	for _, part := range strings.Split(header, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		tag = strings.ReplaceAll(tag, "-", "_")
+		locales = append(locales, tag)
+		if i := strings.Index(tag, "_"); i > 0 {
+			locales = append(locales, tag[:i])
+		}
+	}
+
+	return locales
+}
+
+// Errors lists the rejected fields of a request.
+type Errors []httputil.FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, f := range e {
+		messages = append(messages, f.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+func (e Errors) FieldErrors() []httputil.FieldError {
+	return e
+}
@@ -0,0 +1,100 @@
+package validation_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/stretchr/testify/assert"
+	"go-rest-api-boilerplate/pkg/httputil"
+	"go-rest-api-boilerplate/pkg/validation"
+)
+
+type testReq struct {
+	FirstName string `json:"first_name,omitempty" validate:"required"`
+	Email     string `json:"email" validate:"omitempty,email"`
+	Internal  string `json:"-" validate:"max=1"`
+}
+
+func TestValidator_Struct(t *testing.T) {
+	v := validation.New()
+
+	t.Run("success", func(t *testing.T) {
+		err := v.Struct(&testReq{FirstName: "john", Email: "john@m.co"}, "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		err := v.Struct(&testReq{Email: "john"}, "")
+
+		var errs validation.Errors
+		assert.ErrorAs(t, err, &errs)
+		assert.Equal(t, validation.Errors{
+			{Field: "first_name", Rule: "required", Message: "first_name is a required field"},
+			{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+		}, errs)
+	})
+
+	t.Run("error:translated", func(t *testing.T) {
+		cases := []struct {
+			acceptLanguage string
+			message        string
+		}{
+			{acceptLanguage: "id", message: "first_name wajib diisi"},
+			{acceptLanguage: "id-ID,en;q=0.8", message: "first_name wajib diisi"},
+			{acceptLanguage: "fr-CH, fr;q=0.9, en;q=0.8", message: "first_name is a required field"},
+			{acceptLanguage: "de", message: "first_name is a required field"},
+		}
+
+		for _, c := range cases {
+			err := v.Struct(&testReq{}, c.acceptLanguage)
+			assert.Equal(t, c.message, err.(validation.Errors)[0].Message, c.acceptLanguage)
+		}
+	})
+
+	t.Run("field errors", func(t *testing.T) {
+		err := v.Struct(&testReq{}, "")
+
+		var fieldErrorer httputil.FieldErrorer
+		assert.ErrorAs(t, err, &fieldErrorer)
+		assert.Len(t, fieldErrorer.FieldErrors(), 1)
+	})
+}
+
+type codeReq struct {
+	Code string `json:"code" validate:"upper"`
+}
+
+func TestValidator_RegisterValidation(t *testing.T) {
+	v := validation.New()
+	v.RegisterValidation("upper", func(fl validator.FieldLevel) bool {
+		return strings.ToUpper(fl.Field().String()) == fl.Field().String()
+	}, map[string]string{"en": "{0} must be upper case", "id": "{0} harus huruf besar"})
+
+	assert.NoError(t, v.Struct(&codeReq{Code: "ABC"}, ""))
+
+	cases := []struct {
+		acceptLanguage string
+		message        string
+	}{
+		{acceptLanguage: "", message: "code must be upper case"},
+		{acceptLanguage: "id", message: "code harus huruf besar"},
+	}
+	for _, c := range cases {
+		err := v.Struct(&codeReq{Code: "abc"}, c.acceptLanguage)
+		assert.Equal(t, validation.Errors{{Field: "code", Rule: "upper", Message: c.message}}, err, c.acceptLanguage)
+	}
+
+	t.Run("english fallback", func(t *testing.T) {
+		type nameReq struct {
+			Name string `json:"name" validate:"lower"`
+		}
+		v.RegisterValidation("lower", func(fl validator.FieldLevel) bool {
+			return strings.ToLower(fl.Field().String()) == fl.Field().String()
+		}, map[string]string{"en": "{0} must be lower case"})
+
+		err := v.Struct(&nameReq{Name: "ABC"}, "id")
+		assert.Equal(t, validation.Errors{{Field: "name", Rule: "lower", Message: "name must be lower case"}}, err)
+	})
+}